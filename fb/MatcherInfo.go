@@ -78,8 +78,54 @@ func (rcv *MatcherInfo) MutatePolicyEffect(n byte) bool {
 	return rcv._tab.MutateByteSlot(10, n)
 }
 
+func (rcv *MatcherInfo) Request(obj *CIStr) *CIStr {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(12))
+	if o != 0 {
+		x := rcv._tab.Indirect(o + rcv._tab.Pos)
+		if obj == nil {
+			obj = new(CIStr)
+		}
+		obj.Init(rcv._tab.Bytes, x)
+		return obj
+	}
+	return nil
+}
+
+func (rcv *MatcherInfo) RequestFields(obj *CIStr, j int) bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(14))
+	if o != 0 {
+		x := rcv._tab.Vector(o)
+		x += flatbuffers.UOffsetT(j) * 4
+		x = rcv._tab.Indirect(x)
+		obj.Init(rcv._tab.Bytes, x)
+		return true
+	}
+	return false
+}
+
+func (rcv *MatcherInfo) RequestFieldsLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(14))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
+func (rcv *MatcherInfo) Policy(obj *CIStr) *CIStr {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(16))
+	if o != 0 {
+		x := rcv._tab.Indirect(o + rcv._tab.Pos)
+		if obj == nil {
+			obj = new(CIStr)
+		}
+		obj.Init(rcv._tab.Bytes, x)
+		return obj
+	}
+	return nil
+}
+
 func MatcherInfoStart(builder *flatbuffers.Builder) {
-	builder.StartObject(4)
+	builder.StartObject(7)
 }
 func MatcherInfoAddId(builder *flatbuffers.Builder, id uint64) {
 	builder.PrependUint64Slot(0, id, 0)
@@ -93,6 +139,18 @@ func MatcherInfoAddRaw(builder *flatbuffers.Builder, raw flatbuffers.UOffsetT) {
 func MatcherInfoAddPolicyEffect(builder *flatbuffers.Builder, policyEffect byte) {
 	builder.PrependByteSlot(3, policyEffect, 0)
 }
+func MatcherInfoAddRequest(builder *flatbuffers.Builder, request flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(4, flatbuffers.UOffsetT(request), 0)
+}
+func MatcherInfoAddRequestFields(builder *flatbuffers.Builder, requestFields flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(5, flatbuffers.UOffsetT(requestFields), 0)
+}
+func MatcherInfoStartRequestFieldsVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(4, numElems, 4)
+}
+func MatcherInfoAddPolicy(builder *flatbuffers.Builder, policy flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(6, flatbuffers.UOffsetT(policy), 0)
+}
 func MatcherInfoEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
@@ -38,7 +38,19 @@ func (i *iter) Item() db.Item {
 }
 
 func (t txn) CommitAt(commitTs uint64, callback func(error)) error {
-	return t.txn.CommitAt(commitTs, callback)
+	if callback != nil {
+		return t.txn.CommitAt(commitTs, func(err error) {
+			callback(convertErr(err))
+		})
+	}
+	return convertErr(t.txn.CommitAt(commitTs, nil))
+}
+
+func convertErr(err error) error {
+	if err == badger.ErrConflict {
+		return db.ErrConflict
+	}
+	return err
 }
 
 func (t txn) Discard() {
@@ -92,7 +104,14 @@ func (t txn) NewIterator(iterOpt adapter2.IteratorOptions) db.Iterator {
 }
 
 func (t txn) Get(k []byte) (db.Item, error) {
-	return t.txn.Get(k)
+	item, err := t.txn.Get(k)
+	if err == badger.ErrKeyNotFound {
+		return nil, db.ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
 }
 
 func (b adapter) NewTransactionAt(readTs uint64, update bool) db.Txn {
@@ -109,6 +128,10 @@ func (b adapter) SetDiscardTs(ts uint64) {
 	b.db.SetDiscardTs(ts)
 }
 
+func (b adapter) MaxVersion() uint64 {
+	return b.db.MaxVersion()
+}
+
 func OpenManaged(opt badger.Options) (db.DB, error) {
 	db, err := badger.OpenManaged(opt)
 	if err != nil {
@@ -22,6 +22,8 @@ import (
 var (
 	// ErrKeyNotFound is returned when key isn't found on a txn.Get.
 	ErrKeyNotFound = errors.New("Key not found")
+	// ErrConflict is returned when a transaction conflicts with another transaction committed after its read timestamp.
+	ErrConflict = errors.New("Transaction Conflict. Please retry")
 )
 
 type Item interface {
@@ -74,5 +76,8 @@ type DB interface {
 	// versions can be discarded from the LSM tree, and thence from the value log to
 	// reclaim disk space. Can only be used with managed transactions.
 	SetDiscardTs(ts uint64)
+	// MaxVersion returns the greatest commit timestamp of the stored versions,
+	// the timestamps of a reopened database continue from it.
+	MaxVersion() uint64
 	Close() error
 }
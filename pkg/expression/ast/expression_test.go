@@ -437,6 +437,40 @@ func TestLogicalOperationExpr_Evaluate(t *testing.T) {
 				Value: "",
 			},
 		},
+		{
+			expr: &BinaryOperationExpr{ // false || true => true
+				Op: OR_OP,
+				L: &Primitive{
+					Typ:   BOOLEAN,
+					Value: false,
+				},
+				R: &Primitive{
+					Typ:   BOOLEAN,
+					Value: true,
+				},
+			},
+			expected: &Primitive{
+				Typ:   BOOLEAN,
+				Value: true,
+			},
+		},
+		{
+			expr: &BinaryOperationExpr{ // false || false => false
+				Op: OR_OP,
+				L: &Primitive{
+					Typ:   BOOLEAN,
+					Value: false,
+				},
+				R: &Primitive{
+					Typ:   BOOLEAN,
+					Value: false,
+				},
+			},
+			expected: &Primitive{
+				Typ:   BOOLEAN,
+				Value: false,
+			},
+		},
 	}
 
 	runTests(sets, t)
@@ -55,7 +55,7 @@ func getLogicalOrRetValue(ctx EvaluateCtx, l, r Evaluable) (*Primitive, error) {
 
 	if lhs.Typ == BOOLEAN && rhs.Typ == BOOLEAN {
 		ret.Typ = BOOLEAN
-		ret.Value = lVal && rVal
+		ret.Value = lVal || rVal
 		return ret, nil
 	} else if lVal {
 		return lhs, nil
@@ -0,0 +1,31 @@
+package expression
+
+import (
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/expression"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/primitive/bschema"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+)
+
+// ColumnExpression refers to a column of the tuple by its name.
+type ColumnExpression struct {
+	Name string
+}
+
+func NewColumnExpression(name string) *ColumnExpression {
+	return &ColumnExpression{Name: name}
+}
+
+// Evaluate returns the primitive of the column value, or NULL if the schema has no such column.
+func (c *ColumnExpression) Evaluate(ctx session.Context, evalCtx ast.EvaluateCtx, tuple btuple.Reader, schema bschema.Reader) (expression.Value, error) {
+	return TupleAccessor{tuple: tuple, schema: schema}.GetMember(c.Name), nil
+}
+
+func (c *ColumnExpression) AccessorMembers() []string {
+	return []string{c.Name}
+}
+
+func (c *ColumnExpression) String() string {
+	return c.Name
+}
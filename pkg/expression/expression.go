@@ -14,6 +14,7 @@ import (
 type Expression interface {
 	Evaluate(ctx session.Context, evalCtx ast.EvaluateCtx, tuple btuple.Reader, schema bschema.Reader) (expression.Value, error)
 	AccessorMembers() []string
+	String() string
 }
 
 type AbstractExpression struct {
@@ -26,6 +27,10 @@ type TupleAccessor struct {
 	schema bschema.Reader
 }
 
+func NewTupleAccessor(tuple btuple.Reader, schema bschema.Reader) *TupleAccessor {
+	return &TupleAccessor{tuple: tuple, schema: schema}
+}
+
 func (t TupleAccessor) GetMember(ident string) *ast.Primitive {
 	if idx := t.schema.Field(ident); idx >= 0 {
 		value := codec.DecodeValue(t.tuple.ValueAt(idx), t.schema.FieldAt(idx).Type())
@@ -35,6 +40,21 @@ func (t TupleAccessor) GetMember(ident string) *ast.Primitive {
 				Typ:   ast.STRING,
 				Value: value.GetString(),
 			}
+		case bsontype.Int32, bsontype.Int64:
+			return &ast.Primitive{
+				Typ:   ast.INT,
+				Value: int(value.GetInt64()),
+			}
+		case bsontype.Double:
+			return &ast.Primitive{
+				Typ:   ast.FLOAT,
+				Value: value.GetDouble(),
+			}
+		case bsontype.Boolean:
+			return &ast.Primitive{
+				Typ:   ast.BOOLEAN,
+				Value: value.GetBoolean(),
+			}
 			// TODO: to support more types
 		}
 	}
@@ -50,6 +70,10 @@ func (m *MemoExpression) AccessorMembers() []string {
 	return m.base.AccessorMembers()
 }
 
+func (m *MemoExpression) String() string {
+	return m.base.String()
+}
+
 func NewExpression(base ast.Evaluable) (Expression, *TupleAccessor) {
 	accessor := &TupleAccessor{}
 	return &MemoExpression{
@@ -82,6 +106,10 @@ func (a *AbstractExpression) AccessorMembers() []string {
 	return a.cachedAccessorMembers
 }
 
+func (a *AbstractExpression) String() string {
+	return a.base.String()
+}
+
 func (a *AbstractExpression) Evaluate(ctx session.Context, evalCtx ast.EvaluateCtx, tuple btuple.Reader, schema bschema.Reader) (expression.Value, error) {
 	value, err := a.base.Evaluate(evalCtx)
 	if err != nil {
@@ -2,7 +2,12 @@ package expression
 
 import (
 	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
 	"github.com/casbin-mesh/neo/pkg/parser"
+	"github.com/casbin-mesh/neo/pkg/primitive/bschema"
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/exp/slices"
 	"sort"
@@ -214,3 +219,30 @@ func TestAbstractExpression_Prune(t *testing.T) {
 	})
 
 }
+
+func TestTupleAccessor_GetMember(t *testing.T) {
+	schema := bschema.NewReaderWriter()
+	schema.Append(bsontype.String, []byte("sub"), nil)
+	schema.Append(bsontype.Int64, []byte("priority"), nil)
+	schema.Append(bsontype.Double, []byte("weight"), nil)
+	schema.Append(bsontype.Boolean, []byte("enabled"), nil)
+	tuple := btuple.NewModifier([]btuple.Elem{
+		codec.EncodeValue(value.NewStringValue("alice")),
+		codec.EncodeValue(value.NewInt64Value(-10)),
+		codec.EncodeValue(value.NewDoubleValue(0.5)),
+		codec.EncodeValue(value.NewBooleanValue(true)),
+	})
+	accessor := NewTupleAccessor(tuple, schema)
+	assert.Equal(t, &ast.Primitive{Typ: ast.STRING, Value: "alice"}, accessor.GetMember("sub"))
+	assert.Equal(t, &ast.Primitive{Typ: ast.INT, Value: -10}, accessor.GetMember("priority"))
+	assert.Equal(t, &ast.Primitive{Typ: ast.FLOAT, Value: 0.5}, accessor.GetMember("weight"))
+	assert.Equal(t, &ast.Primitive{Typ: ast.BOOLEAN, Value: true}, accessor.GetMember("enabled"))
+	assert.Equal(t, &ast.Primitive{Typ: ast.NULL}, accessor.GetMember("obj"))
+
+	// the typed members are compared by their values
+	ctx := ast.NewContext()
+	ctx.AddAccessor("p", accessor)
+	res, err := parser.MustParseFromString("p.priority < -5 && p.weight >= 0.5 && p.enabled").Evaluate(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, true, res.Value)
+}
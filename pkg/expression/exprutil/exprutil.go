@@ -0,0 +1,42 @@
+// Package exprutil provides the helpers shared by the compiler, the catalog and the planner
+// to read the matchers.
+package exprutil
+
+import (
+	"fmt"
+
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/parser"
+)
+
+// ParseMatcher parses the raw matcher, the syntax errors the parser panics on are returned.
+func ParseMatcher(raw string) (predicate ast.Evaluable, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return parser.MustParseFromString(raw), nil
+}
+
+// MemberOf returns the ancestor and member of a single-level accessor like p.sub.
+func MemberOf(node ast.Evaluable) (ancestor, member string, ok bool) {
+	accessor, ok := node.(*ast.Accessor)
+	if !ok {
+		return
+	}
+	a, ok := accessor.Ancestor.(*ast.Primitive)
+	if !ok || a.Typ != ast.IDENTIFIER {
+		return "", "", false
+	}
+	m, ok := accessor.Ident.(*ast.Primitive)
+	if !ok || m.Typ != ast.IDENTIFIER {
+		return "", "", false
+	}
+	ancestor, ok = a.Value.(string)
+	if !ok {
+		return
+	}
+	member, ok = m.Value.(string)
+	return
+}
@@ -0,0 +1,33 @@
+package exprutil
+
+import (
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseMatcher(t *testing.T) {
+	predicate, err := ParseMatcher("r.sub == p.sub && r.obj == p.obj")
+	assert.Nil(t, err)
+	assert.Equal(t, parser.MustParseFromString("r.sub == p.sub && r.obj == p.obj"), predicate)
+
+	_, err = ParseMatcher("r.sub == ")
+	assert.NotNil(t, err)
+}
+
+func TestMemberOf(t *testing.T) {
+	ancestor, member, ok := MemberOf(parser.MustParseFromString("p.sub"))
+	assert.True(t, ok)
+	assert.Equal(t, "p", ancestor)
+	assert.Equal(t, "sub", member)
+
+	for _, node := range []ast.Evaluable{
+		parser.MustParseFromString(`"p.sub"`),
+		parser.MustParseFromString("r.sub == p.sub"),
+		parser.MustParseFromString("r.attr.owner"),
+	} {
+		_, _, ok = MemberOf(node)
+		assert.False(t, ok, node.String())
+	}
+}
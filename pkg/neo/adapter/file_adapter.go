@@ -0,0 +1,240 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adapter loads and saves the policies of a model in the casbin policy CSV format:
+//
+//	p, alice, data1, read
+//	g, alice, admin
+//
+// The first column of a rule is its ptype, a line whose first column isn't a ptype of the model
+// is invalid, unless the files are read with a default ptype, see Options.
+package adapter
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/casbin-mesh/neo/pkg/neo"
+)
+
+const commentMark = "#"
+
+var ErrUnknownPType = errors.New("unknown ptype")
+
+// Options configures how the policy files are read.
+type Options struct {
+	// DefaultPType is the ptype of the lines whose first column isn't a ptype of the model,
+	// e.g. alice, data1, read. A subject named as a ptype is taken for the ptype then,
+	// so the files without ptypes are only read if it's set.
+	DefaultPType string
+}
+
+// line is a line of a policy file, rule is nil if the line is blank or a comment.
+type line struct {
+	raw  string
+	rule *neo.Rule
+}
+
+// LoadPolicy replaces the policies of the model with the rules in the file at path.
+func LoadPolicy(e *neo.Engine, model string, path string) error {
+	return LoadPolicyWithOptions(e, model, path, Options{})
+}
+
+// LoadPolicyWithOptions is like LoadPolicy, it reads the file with the options.
+func LoadPolicyWithOptions(e *neo.Engine, model string, path string, opts Options) error {
+	ptypes, err := ptypeSet(e, model)
+	if err != nil {
+		return err
+	}
+	lines, err := readLines(path, ptypes, opts)
+	if err != nil {
+		return err
+	}
+	rules := make([]neo.Rule, 0, len(lines))
+	for _, l := range lines {
+		if l.rule != nil {
+			rules = append(rules, *l.rule)
+		}
+	}
+	return e.ReplacePolicy(model, rules)
+}
+
+// SavePolicy writes the policies of the model to the file at path.
+// The existing file is kept as a template: comments, blank lines and the
+// rules still present are written back verbatim, removed rules are dropped
+// and new rules are appended.
+func SavePolicy(e *neo.Engine, model string, path string) error {
+	return SavePolicyWithOptions(e, model, path, Options{})
+}
+
+// SavePolicyWithOptions is like SavePolicy, it reads the existing file with the options.
+func SavePolicyWithOptions(e *neo.Engine, model string, path string, opts Options) error {
+	ptypes, err := ptypeSet(e, model)
+	if err != nil {
+		return err
+	}
+	lines, err := readLines(path, ptypes, opts)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var rules []neo.Rule
+	remaining := make(map[string]int)
+	for _, ptype := range ptypeList(ptypes) {
+		values, err := e.GetNamedPolicy(model, ptype)
+		if err != nil {
+			return err
+		}
+		for _, v := range values {
+			rule := neo.Rule{PType: ptype, Values: v}
+			rules = append(rules, rule)
+			remaining[ruleKey(rule)]++
+		}
+	}
+
+	// the rules of the file are compared as they are stored, e.g. with their default values
+	var fileRules []neo.Rule
+	for _, l := range lines {
+		if l.rule != nil {
+			fileRules = append(fileRules, *l.rule)
+		}
+	}
+	normalized, err := e.NormalizeRules(model, fileRules)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	for _, l := range lines {
+		if l.rule != nil {
+			key := ruleKey(neo.Rule{PType: l.rule.PType, Values: normalized[0]})
+			normalized = normalized[1:]
+			if remaining[key] == 0 {
+				continue
+			}
+			remaining[key]--
+		}
+		sb.WriteString(l.raw)
+	}
+	for _, rule := range rules {
+		key := ruleKey(rule)
+		if remaining[key] == 0 {
+			continue
+		}
+		remaining[key]--
+		if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "\n") {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(formatRule(rule))
+		sb.WriteString("\n")
+	}
+	return os.WriteFile(path, []byte(sb.String()), 0644)
+}
+
+// ptypeSet returns the ptypes of the model, with their definition order.
+func ptypeSet(e *neo.Engine, model string) (map[string]int, error) {
+	ptypes, err := e.PTypes(model)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]int, len(ptypes))
+	for i, ptype := range ptypes {
+		set[ptype] = i
+	}
+	return set, nil
+}
+
+func ptypeList(set map[string]int) []string {
+	list := make([]string, len(set))
+	for ptype, i := range set {
+		list[i] = ptype
+	}
+	return list
+}
+
+func readLines(path string, ptypes map[string]int, opts Options) ([]line, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lines []line
+	for i, raw := range strings.SplitAfter(string(content), "\n") {
+		if len(raw) == 0 {
+			continue
+		}
+		rule, err := parseLine(raw, ptypes, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, i+1, err)
+		}
+		lines = append(lines, line{raw: raw, rule: rule})
+	}
+	return lines, nil
+}
+
+// parseLine parses a line of a policy file, returns nil if the line is blank or a comment.
+func parseLine(raw string, ptypes map[string]int, opts Options) (*neo.Rule, error) {
+	text := strings.TrimSpace(raw)
+	if len(text) == 0 || strings.HasPrefix(text, commentMark) {
+		return nil, nil
+	}
+
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	fields, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+
+	ptype := strings.ToLower(fields[0])
+	if _, ok := ptypes[ptype]; ok && (len(fields) > 1 || opts.DefaultPType == "") {
+		return &neo.Rule{PType: ptype, Values: fields[1:]}, nil
+	}
+	if opts.DefaultPType == "" {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPType, fields[0])
+	}
+	return &neo.Rule{PType: opts.DefaultPType, Values: fields}, nil
+}
+
+// ruleKey identifies the rule as it is stored, the trailing empty values are not kept by the tables.
+func ruleKey(rule neo.Rule) string {
+	values := rule.Values
+	for len(values) > 0 && len(values[len(values)-1]) == 0 {
+		values = values[:len(values)-1]
+	}
+	return strings.ToLower(rule.PType) + "\x00" + strings.Join(values, "\x00")
+}
+
+// formatRule formats the rule as a line without the line break, values are quoted if necessary.
+func formatRule(rule neo.Rule) string {
+	fields := make([]string, 0, len(rule.Values)+1)
+	fields = append(fields, rule.PType)
+	for _, v := range rule.Values {
+		fields = append(fields, quote(v))
+	}
+	return strings.Join(fields, ", ")
+}
+
+func quote(s string) string {
+	if !strings.ContainsAny(s, ",\"\r\n") {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
@@ -0,0 +1,237 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/casbin-mesh/neo/pkg/neo"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	basicModelPath  = "../../../examples/assets/model/basic_model.conf"
+	basicPolicyPath = "../../../examples/assets/policy/basic_policy.csv"
+	rbacModelPath   = "../../../examples/assets/model/rbac_model.conf"
+)
+
+const rbacPolicyText = `# policies
+p, alice, data1, read
+p, bob, "data2", write
+p, data2_admin, data2, read
+p, data2_admin, data2, write
+p, "carol, the admin", "data3", read
+
+# roles
+g, alice, data2_admin
+`
+
+func openTestEngine(t *testing.T, path string, name string, modelPath string) *neo.Engine {
+	e, err := neo.Open(filepath.Join(path, "db"), nil)
+	assert.Nil(t, err)
+	assert.Nil(t, e.CreateModelFromFile(name, modelPath))
+	return e
+}
+
+func TestFileAdapter_RoundTrip(t *testing.T) {
+	p := "./__test_tmp__/round_trip"
+	e := openTestEngine(t, p, "basic", basicModelPath)
+	defer func() {
+		e.Close()
+		os.RemoveAll(p)
+	}()
+
+	expected, err := os.ReadFile(basicPolicyPath)
+	assert.Nil(t, err)
+	policyPath := filepath.Join(p, "basic_policy.csv")
+	assert.Nil(t, os.WriteFile(policyPath, expected, 0644))
+
+	// the rules of the file have no ptypes
+	assert.True(t, errors.Is(LoadPolicy(e, "basic", policyPath), ErrUnknownPType))
+	opts := Options{DefaultPType: "p"}
+	assert.Nil(t, LoadPolicyWithOptions(e, "basic", policyPath, opts))
+	allowed, err := e.Enforce("basic", "bob", "data4", "read")
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+
+	assert.True(t, errors.Is(SavePolicy(e, "basic", policyPath), ErrUnknownPType))
+	assert.Nil(t, SavePolicyWithOptions(e, "basic", policyPath, opts))
+	actual, err := os.ReadFile(policyPath)
+	assert.Nil(t, err)
+	assert.Equal(t, string(expected), string(actual))
+}
+
+// the rules relying on the default values are kept in place
+func TestFileAdapter_DefaultValues(t *testing.T) {
+	p := "./__test_tmp__/default_values"
+	e, err := neo.Open(filepath.Join(p, "db"), nil)
+	assert.Nil(t, err)
+	defer func() {
+		e.Close()
+		os.RemoveAll(p)
+	}()
+	assert.Nil(t, e.CreateModelFromString("eft", `
+[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act, eft
+
+[policy_effect]
+e = some(where (p.eft == allow)) && !some(where (p.eft == deny))
+
+[matchers]
+m = r.sub == p.sub && r.obj == p.obj && r.act == p.act
+`))
+
+	expected := "# c\np, alice, data1, read\np, bob, data2, write, deny\n"
+	policyPath := filepath.Join(p, "policy.csv")
+	assert.Nil(t, os.WriteFile(policyPath, []byte(expected), 0644))
+	assert.Nil(t, LoadPolicy(e, "eft", policyPath))
+	rules, err := e.GetNamedPolicy("eft", "p")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"alice", "data1", "read", "allow"}, {"bob", "data2", "write", "deny"}}, rules)
+
+	assert.Nil(t, SavePolicy(e, "eft", policyPath))
+	actual, err := os.ReadFile(policyPath)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, string(actual))
+
+	// the priorities are compared as integers
+	assert.Nil(t, e.CreateModelFromString("priority", `
+[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = priority, sub, obj, act, eft
+
+[policy_effect]
+e = priority(p.eft) || deny
+
+[matchers]
+m = r.sub == p.sub && r.obj == p.obj && r.act == p.act
+`))
+	expected = "p, 01, alice, data1, read\np, 2, bob, data2, write, deny\n"
+	assert.Nil(t, os.WriteFile(policyPath, []byte(expected), 0644))
+	assert.Nil(t, LoadPolicy(e, "priority", policyPath))
+	assert.Nil(t, SavePolicy(e, "priority", policyPath))
+	actual, err = os.ReadFile(policyPath)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, string(actual))
+}
+
+func TestFileAdapter_RBAC(t *testing.T) {
+	p := "./__test_tmp__/rbac"
+	e := openTestEngine(t, p, "rbac", rbacModelPath)
+	defer func() {
+		e.Close()
+		os.RemoveAll(p)
+	}()
+
+	policyPath := filepath.Join(p, "rbac_policy.csv")
+	assert.Nil(t, os.WriteFile(policyPath, []byte(rbacPolicyText), 0644))
+	assert.Nil(t, LoadPolicy(e, "rbac", policyPath))
+
+	policies, err := e.GetNamedPolicy("rbac", "p")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{
+		{"alice", "data1", "read"},
+		{"bob", "data2", "write"},
+		{"data2_admin", "data2", "read"},
+		{"data2_admin", "data2", "write"},
+		{"carol, the admin", "data3", "read"},
+	}, policies)
+
+	sets := []struct {
+		req      []string
+		expected bool
+	}{
+		{[]string{"alice", "data1", "read"}, true},
+		{[]string{"alice", "data2", "write"}, true},
+		{[]string{"bob", "data2", "write"}, true},
+		{[]string{"bob", "data2", "read"}, false},
+		{[]string{"carol, the admin", "data3", "read"}, true},
+	}
+	for _, set := range sets {
+		allowed, err := e.Enforce("rbac", set.req...)
+		assert.Nil(t, err)
+		assert.Equal(t, set.expected, allowed, set.req)
+	}
+
+	// unchanged policies are saved as they are
+	assert.Nil(t, SavePolicy(e, "rbac", policyPath))
+	actual, err := os.ReadFile(policyPath)
+	assert.Nil(t, err)
+	assert.Equal(t, rbacPolicyText, string(actual))
+
+	_, err = e.RemovePolicy("rbac", "bob", "data2", "write")
+	assert.Nil(t, err)
+	_, err = e.AddPolicy("rbac", "bob", "data3", `say "hi"`)
+	assert.Nil(t, err)
+	_, err = e.AddGroupingPolicy("rbac", "bob", "data2_admin")
+	assert.Nil(t, err)
+	assert.Nil(t, SavePolicy(e, "rbac", policyPath))
+	actual, err = os.ReadFile(policyPath)
+	assert.Nil(t, err)
+	assert.Equal(t, `# policies
+p, alice, data1, read
+p, data2_admin, data2, read
+p, data2_admin, data2, write
+p, "carol, the admin", "data3", read
+
+# roles
+g, alice, data2_admin
+p, bob, data3, "say ""hi"""
+g, bob, data2_admin
+`, string(actual))
+
+	// reloading replaces the policies
+	assert.Nil(t, os.WriteFile(policyPath, []byte("p, bob, data1, read\n"), 0644))
+	assert.Nil(t, LoadPolicy(e, "rbac", policyPath))
+	allowed, err := e.Enforce("rbac", "alice", "data1", "read")
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+	allowed, err = e.Enforce("rbac", "bob", "data1", "read")
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+	has, err := e.HasRoleForUser("rbac", "bob", "data2_admin")
+	assert.Nil(t, err)
+	assert.False(t, has)
+}
+
+func TestFileAdapter_Invalid(t *testing.T) {
+	p := "./__test_tmp__/invalid"
+	e := openTestEngine(t, p, "basic", basicModelPath)
+	defer func() {
+		e.Close()
+		os.RemoveAll(p)
+	}()
+
+	policyPath := filepath.Join(p, "invalid_policy.csv")
+	assert.Nil(t, os.WriteFile(policyPath, []byte("p, alice, data1, read, extra\n"), 0644))
+	assert.Equal(t, neo.ErrInvalidRequest, LoadPolicy(e, "basic", policyPath))
+
+	assert.Nil(t, os.WriteFile(policyPath, []byte("p, alice, \"data1, read\n"), 0644))
+	assert.NotNil(t, LoadPolicy(e, "basic", policyPath))
+
+	// the omitted trailing values are invalid as AddPolicy's
+	assert.Nil(t, os.WriteFile(policyPath, []byte("p, alice, data1\n"), 0644))
+	assert.Equal(t, neo.ErrInvalidRequest, LoadPolicy(e, "basic", policyPath))
+
+	assert.NotNil(t, LoadPolicy(e, "basic", filepath.Join(p, "not_exists.csv")))
+}
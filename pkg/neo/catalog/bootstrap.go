@@ -0,0 +1,129 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/casbin-mesh/neo/pkg/db/adapter"
+	"github.com/casbin-mesh/neo/pkg/expression/exprutil"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+)
+
+// Bootstrap loads the infos persisted by CreateDBInfo into the schema, and
+// restores the name to id mappings and the id counters of the meta from them,
+// the meta itself is not persisted. It returns the recovered databases.
+func (c *catalog) Bootstrap(ctx context.Context) ([]*model.DBInfo, error) {
+	columns := make(map[uint64]*model.ColumnInfo)
+	if err := c.scan(codec.ColumnInfoKeyPrefix(), func(buf []byte) {
+		info := codec.DecodeColumnInfo(buf, nil)
+		columns[info.ID] = info
+	}); err != nil {
+		return nil, err
+	}
+	indices := make(map[uint64]*model.IndexInfo)
+	if err := c.scan(codec.IndexInfoKeyPrefix(), func(buf []byte) {
+		info := codec.DecodeIndexInfo(buf, nil)
+		indices[info.ID] = info
+	}); err != nil {
+		return nil, err
+	}
+	tables := make(map[uint64]*model.TableInfo)
+	if err := c.scan(codec.TableInfoKeyPrefix(), func(buf []byte) {
+		info := codec.DecodeTableInfo(buf, nil)
+		tables[info.ID] = info
+	}); err != nil {
+		return nil, err
+	}
+	matchers := make(map[uint64]*model.MatcherInfo)
+	if err := c.scan(codec.MatcherInfoKeyPrefix(), func(buf []byte) {
+		info := codec.DecodeMatcherInfo(buf, nil)
+		matchers[info.ID] = info
+	}); err != nil {
+		return nil, err
+	}
+	var dbs []*model.DBInfo
+	if err := c.scan(codec.DBInfoKeyPrefix(), func(buf []byte) {
+		dbs = append(dbs, codec.DecodeBDInfo(buf))
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, db := range dbs {
+		if err := c.restoreDB(db, tables, columns, indices, matchers); err != nil {
+			return nil, err
+		}
+	}
+	return dbs, nil
+}
+
+// scan calls fn with a copy of every value with the prefix.
+func (c *catalog) scan(prefix []byte, fn func(buf []byte)) error {
+	iter := c.txn.NewIterator(adapter.DefaultIteratorOptions)
+	defer iter.Close()
+	for iter.Seek(prefix); iter.ValidForPrefix(prefix); iter.Next() {
+		buf, err := iter.Item().ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		fn(buf)
+	}
+	return nil
+}
+
+// restoreDB replaces the id-only infos of db with the decoded ones, and
+// restores the meta and schema of the db.
+func (c *catalog) restoreDB(db *model.DBInfo, tables map[uint64]*model.TableInfo, columns map[uint64]*model.ColumnInfo,
+	indices map[uint64]*model.IndexInfo, matchers map[uint64]*model.MatcherInfo) (err error) {
+	rw := c.GetMetaRW()
+	if err = rw.RestoreDb(db.Name.L, db.ID); err != nil {
+		return err
+	}
+
+	for i, ref := range db.TableInfo {
+		table, ok := tables[ref.ID]
+		if !ok {
+			return fmt.Errorf("%w: table %d of db %s", ErrCorrupted, ref.ID, db.Name.O)
+		}
+		if err = rw.RestoreTable(db.ID, table.Name.L, table.ID); err != nil {
+			return err
+		}
+		for j, ref := range table.Columns {
+			column, ok := columns[ref.ID]
+			if !ok {
+				return fmt.Errorf("%w: column %d of table %s", ErrCorrupted, ref.ID, table.Name.O)
+			}
+			if err = rw.RestoreColumn(table.ID, column.ColName.L, column.ID); err != nil {
+				return err
+			}
+			table.Columns[j] = column
+		}
+		for j, ref := range table.Indices {
+			index, ok := indices[ref.ID]
+			if !ok {
+				return fmt.Errorf("%w: index %d of table %s", ErrCorrupted, ref.ID, table.Name.O)
+			}
+			if err = rw.RestoreIndex(table.ID, index.Name.L, index.ID); err != nil {
+				return err
+			}
+			table.Indices[j] = index
+		}
+		db.TableInfo[i] = table
+	}
+
+	for i, ref := range db.MatcherInfo {
+		matcher, ok := matchers[ref.ID]
+		if !ok {
+			return fmt.Errorf("%w: matcher %d of db %s", ErrCorrupted, ref.ID, db.Name.O)
+		}
+		if matcher.Predicate, err = exprutil.ParseMatcher(matcher.Raw); err != nil {
+			return fmt.Errorf("%w: matcher %s of db %s: %v", ErrCorrupted, matcher.Name.O, db.Name.O, err)
+		}
+		if err = rw.RestoreMatcher(db.ID, matcher.Name.L, matcher.ID); err != nil {
+			return err
+		}
+		db.MatcherInfo[i] = matcher
+	}
+
+	return c.GetSchemaRW().Set(codec.DBInfoKey(db.ID), db)
+}
@@ -2,6 +2,7 @@ package catalog
 
 import (
 	"context"
+	"errors"
 	"github.com/casbin-mesh/neo/pkg/db"
 	"github.com/casbin-mesh/neo/pkg/neo/codec"
 	"github.com/casbin-mesh/neo/pkg/neo/meta"
@@ -13,8 +14,13 @@ type Catalog interface {
 	GetDBInfoByName(name string) (*model.DBInfo, error)
 	GetDBInfoByDBId(did uint64) (*model.DBInfo, error)
 	CreateDBInfo(ctx context.Context, info *model.DBInfo) (dbId uint64, err error)
+	// Bootstrap recovers the catalog persisted in the storage, it's called once the storage is opened.
+	Bootstrap(ctx context.Context) ([]*model.DBInfo, error)
 }
 
+// ErrCorrupted is returned by Bootstrap if a persisted info refers to a missing one.
+var ErrCorrupted = errors.New("catalog corrupted")
+
 type catalog struct {
 	meta   meta.ReaderWriter
 	schema schema.ReaderWriter
@@ -38,6 +44,7 @@ func (c *catalog) CreateDBInfo(ctx context.Context, info *model.DBInfo) (dbId ui
 	if dbId, err = rw.NewDb(info.Name.L); err != nil {
 		return
 	}
+	info.ID = dbId
 
 	for _, matcherInfo := range info.MatcherInfo {
 		if _, err = c.createMatcher(ctx, dbId, matcherInfo); err != nil {
@@ -71,11 +78,6 @@ func (c *catalog) createTable(ctx context.Context, did uint64, info *model.Table
 	}
 	info.ID = tableId
 
-	txn := c.GetTxn()
-	if err = txn.Set(codec.TableInfoKey(tableId), codec.EncodeTableInfo(info)); err != nil {
-		return 0, err
-	}
-
 	for _, column := range info.Columns {
 		if _, err = c.createColumn(ctx, tableId, column); err != nil {
 			return
@@ -90,6 +92,12 @@ func (c *catalog) createTable(ctx context.Context, did uint64, info *model.Table
 
 	//TODO(weny) :foreign keys
 
+	// the table info refers to the ids of its columns and indices
+	txn := c.GetTxn()
+	if err = txn.Set(codec.TableInfoKey(tableId), codec.EncodeTableInfo(info)); err != nil {
+		return 0, err
+	}
+
 	return
 }
 
@@ -16,6 +16,15 @@ func ColumnInfoKey(columnId uint64) []byte {
 	return buf
 }
 
+// ColumnInfoKeyPrefix is the prefix of all ColumnInfoKey
+// key: s_c
+func ColumnInfoKeyPrefix() []byte {
+	buf := make([]byte, 0, 3)
+	buf = append(buf, mSchemaPrefix...)
+	buf = append(buf, columnPrefixSep...)
+	return buf
+}
+
 func EncodeColumnInfo(info *model.ColumnInfo) []byte {
 	builder := flatbuffers.NewBuilder(1024)
 	LName := builder.CreateString(info.ColName.L)
@@ -15,6 +15,15 @@ func DBInfoKey(dbId uint64) []byte {
 	return buf
 }
 
+// DBInfoKeyPrefix is the prefix of all DBInfoKey
+// key: s_d
+func DBInfoKeyPrefix() []byte {
+	buf := make([]byte, 0, 3)
+	buf = append(buf, mSchemaPrefix...)
+	buf = append(buf, databasePrefixSep...)
+	return buf
+}
+
 func EncodeDBInfo(info *model.DBInfo) []byte {
 	builder := flatbuffers.NewBuilder(1024)
 	LName := builder.CreateString(info.Name.L)
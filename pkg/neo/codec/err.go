@@ -3,5 +3,6 @@ package codec
 import "errors"
 
 var (
-	ErrInvalidKey = errors.New("invalid key")
+	ErrInvalidKey      = errors.New("invalid key")
+	ErrInvalidCmpValue = errors.New("invalid mem-comparable value")
 )
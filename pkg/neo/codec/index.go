@@ -15,10 +15,14 @@
 package codec
 
 import (
+	"bytes"
+	"encoding/binary"
+
 	"github.com/casbin-mesh/neo/fb"
 	"github.com/casbin-mesh/neo/pkg/neo/model"
 	"github.com/casbin-mesh/neo/pkg/primitive"
 	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
 	flatbuffers "github.com/google/flatbuffers/go"
 )
 
@@ -31,7 +35,16 @@ func IndexInfoKey(matcherId uint64) []byte {
 	return buf
 }
 
-// PrimaryIndexEntryKey i{index_id}_{columns_value}}
+// IndexInfoKeyPrefix is the prefix of all IndexInfoKey
+// key: s_i
+func IndexInfoKeyPrefix() []byte {
+	buf := make([]byte, 0, 3)
+	buf = append(buf, mSchemaPrefix...)
+	buf = append(buf, indexPrefixSep...)
+	return buf
+}
+
+// PrimaryIndexEntryKey i{index_id}_{columns_value}
 func PrimaryIndexEntryKey(indexId uint64, columnValue []byte) []byte {
 	buf := make([]byte, 0, 10+len(columnValue))
 	buf = append(buf, indexPrefix...)
@@ -41,32 +54,63 @@ func PrimaryIndexEntryKey(indexId uint64, columnValue []byte) []byte {
 	return buf
 }
 
-// SecondaryIndexEntryKey i{index_id}_{leftmost_column_value}_{r_id}
+// SecondaryIndexEntryKey i{index_id}_{leftmost_column_value}{r_id}
 func SecondaryIndexEntryKey(indexId uint64, columnValue []byte, rId []byte) []byte {
-	buf := make([]byte, 0, 19+len(columnValue))
+	return CompositeIndexEntryKey(indexId, [][]byte{columnValue}, rId)
+}
+
+// CompositeIndexEntryKey i{index_id}_{column_value}...{column_value}{r_id}
+// the column values are mem-comparable, they need no separators.
+func CompositeIndexEntryKey(indexId uint64, columnValues [][]byte, rId []byte) []byte {
+	buf := IndexEntryPrefix(indexId, columnValues)
+	buf = append(buf, rId...)
+	return buf
+}
+
+// IndexEntryPrefix i{index_id}_{column_value}...{column_value}
+// is the prefix of the index entries whose leading columns equal to the column values.
+func IndexEntryPrefix(indexId uint64, columnValues [][]byte) []byte {
+	size := 10 + 8
+	for _, columnValue := range columnValues {
+		size += len(columnValue)
+	}
+	buf := make([]byte, 0, size)
 	buf = append(buf, indexPrefix...)
 	buf = appendUint64(buf, indexId)
 	buf = append(buf, Sep...)
-	buf = append(buf, columnValue...)
-	buf = append(buf, Sep...)
-	buf = append(buf, rId...)
+	for _, columnValue := range columnValues {
+		buf = append(buf, columnValue...)
+	}
 	return buf
 }
 
-func IndexEntryKey(indexInfo *model.IndexInfo, columns []*model.ColumnInfo, tuple btuple.Reader, rid primitive.ObjectID) []byte {
-	var (
-		leftmost []byte
-	)
-	index := indexInfo.Leftmost()
-	col := columns[index.Offset]
-	// retrieve actual value, then encode to mem-comparable format
-	leftmost = EncodeCmpValue(DecodeValue(tuple.ValueAt(index.Offset), col.Tp))
-	key := SecondaryIndexEntryKey(indexInfo.ID, leftmost, rid.Bytes())
+// indexColumnValues returns the mem-comparable values of the index columns of the tuple.
+func indexColumnValues(indexInfo *model.IndexInfo, columns []*model.ColumnInfo, tuple btuple.Reader) [][]byte {
+	values := make([][]byte, 0, len(indexInfo.Columns))
+	for _, index := range indexInfo.Columns {
+		col := columns[index.Offset]
+		// retrieve actual value, then encode to mem-comparable format
+		values = append(values, EncodeCmpValue(DecodeValue(tuple.ValueAt(index.Offset), col.Tp)))
+	}
+	return values
+}
 
-	return key
+// IndexEntryKey returns the key of the index entry of the tuple, the key of a unique index
+// is the primary index entry key of the column values, otherwise it's the secondary one.
+func IndexEntryKey(indexInfo *model.IndexInfo, columns []*model.ColumnInfo, tuple btuple.Reader, rid primitive.ObjectID) []byte {
+	if indexInfo.Unique {
+		return PrimaryIndexEntryKey(indexInfo.ID, bytes.Join(indexColumnValues(indexInfo, columns, tuple), nil))
+	}
+	return CompositeIndexEntryKey(indexInfo.ID, indexColumnValues(indexInfo, columns, tuple), rid.Bytes())
 }
 
+// IndexEntry returns the index entry of the tuple, the value of a unique index entry
+// is the row id, otherwise it's the tuple of the index columns.
 func IndexEntry(indexInfo *model.IndexInfo, columns []*model.ColumnInfo, tuple btuple.Reader, rid primitive.ObjectID) (key, value []byte) {
+	key = IndexEntryKey(indexInfo, columns, tuple, rid)
+	if indexInfo.Unique {
+		return key, rid.Bytes()
+	}
 
 	tupleBuilder := btuple.NewTupleBuilder(btuple.SmallValueType)
 
@@ -74,34 +118,66 @@ func IndexEntry(indexInfo *model.IndexInfo, columns []*model.ColumnInfo, tuple b
 		tupleBuilder.Append(tuple.ValueAt(index.Offset))
 	}
 
-	key = IndexEntryKey(indexInfo, columns, tuple, rid)
-
 	return key, tupleBuilder.Encode()
 }
 
-func IndexEntries(index *model.IndexInfo, tuple btuple.Reader, rid primitive.ObjectID, iter func(key, value []byte) error) (err error) {
-	for _, column := range index.Columns {
-		var key, value []byte
-		columnValue := (tuple).ValueAt(column.Offset)
-		if index.Unique {
-			key = PrimaryIndexEntryKey(index.ID, columnValue)
-			value = rid[:]
-		} else {
-			key = SecondaryIndexEntryKey(index.ID, columnValue, rid[:])
+func IndexEntries(index *model.IndexInfo, columns []*model.ColumnInfo, tuple btuple.Reader, rid primitive.ObjectID, iter func(key, value []byte) error) (err error) {
+	return iter(IndexEntry(index, columns, tuple, rid))
+}
+
+// DecodeIndexEntryRid returns the row id of an index entry, without decoding its values.
+func DecodeIndexEntryRid(indexInfo *model.IndexInfo, key, val []byte) (primitive.ObjectID, error) {
+	if !indexInfo.Unique {
+		return ParseTupleRecordKeyFromSecondaryIndex(key)
+	}
+	return ParseTupleRecordKeyFromPrimaryIndex(val)
+}
+
+// DecodeIndexEntry decodes the tuple of the index columns and the row id from an index entry.
+func DecodeIndexEntry(indexInfo *model.IndexInfo, key, val []byte) (tuple btuple.Modifier, rid primitive.ObjectID, err error) {
+	if !indexInfo.Unique {
+		if rid, err = ParseTupleRecordKeyFromSecondaryIndex(key); err != nil {
+			return
+		}
+		var reader btuple.Reader
+		if reader, err = btuple.NewReader(val); err != nil {
+			return
 		}
-		if err = iter(key, value); err != nil {
-			return err
+		return btuple.NewModifier(reader.Values()), rid, nil
+	}
+
+	if rid, err = ParseTupleRecordKeyFromPrimaryIndex(val); err != nil {
+		return
+	}
+	if len(key) < 10 {
+		return nil, rid, ErrInvalidKey
+	}
+	elems := make([]btuple.Elem, 0, len(indexInfo.Columns))
+	rest := key[10:]
+	for range indexInfo.Columns {
+		var v value.Value
+		if v, rest, err = DecodeCmpValue(rest); err != nil {
+			return
 		}
+		elems = append(elems, EncodeValue(v))
+	}
+	return btuple.NewModifier(elems), rid, nil
+}
+
+// ParseIndexId parses the index id from an index entry key or prefix.
+func ParseIndexId(b []byte) (uint64, error) {
+	if len(b) < 10 || b[0] != indexPrefix[0] || b[9] != Sep[0] {
+		return 0, ErrInvalidKey
 	}
-	return
+	return binary.BigEndian.Uint64(b[1:9]), nil
 }
 
-// ParseTupleRecordKeyFromSecondaryIndex parse r_id form i{index_id}_{index_column_value}_{r_id} form
+// ParseTupleRecordKeyFromSecondaryIndex parse r_id form i{index_id}_{index_column_value}{r_id} form
 func ParseTupleRecordKeyFromSecondaryIndex(b []byte) (primitive.ObjectID, error) {
 	if len(b) < 19 {
 		return primitive.ObjectID{}, ErrInvalidKey
 	}
-	if b[len(b)-9] != '_' {
+	if b[9] != '_' {
 		return primitive.ObjectID{}, ErrInvalidKey
 	}
 
@@ -1,10 +1,14 @@
 package codec
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"github.com/casbin-mesh/neo/pkg/neo/model"
 	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -20,7 +24,82 @@ func TestSecondaryIndexEntryKey(t *testing.T) {
 	id := uint64(1)
 	bid := [8]byte{}
 	binary.BigEndian.PutUint64(bid[:], id)
-	assert.Equal(t, []byte(fmt.Sprintf("i%s_hello_%s", bid, bid)), SecondaryIndexEntryKey(1, []byte("hello"), bid[:]))
+	assert.Equal(t, []byte(fmt.Sprintf("i%s_hello%s", bid, bid)), SecondaryIndexEntryKey(1, []byte("hello"), bid[:]))
+}
+
+func TestCompositeIndexEntryKey(t *testing.T) {
+	id := uint64(1)
+	bid := [8]byte{}
+	binary.BigEndian.PutUint64(bid[:], id)
+	alice, data1 := EncodeCmpValue(value.NewStringValue("alice")), EncodeCmpValue(value.NewStringValue("data1"))
+	key := CompositeIndexEntryKey(1, [][]byte{alice, data1}, bid[:])
+	assert.Equal(t, []byte(fmt.Sprintf("i%s_%s%s%s", bid, alice, data1, bid)), key)
+	assert.Equal(t, SecondaryIndexEntryKey(1, alice, bid[:]), CompositeIndexEntryKey(1, [][]byte{alice}, bid[:]))
+
+	// the prefixes of the leading columns
+	assert.True(t, bytes.HasPrefix(key, IndexEntryPrefix(1, nil)))
+	assert.True(t, bytes.HasPrefix(key, IndexEntryPrefix(1, [][]byte{alice})))
+	assert.True(t, bytes.HasPrefix(key, IndexEntryPrefix(1, [][]byte{alice, data1})))
+	assert.False(t, bytes.HasPrefix(key, IndexEntryPrefix(1, [][]byte{data1})))
+	assert.False(t, bytes.HasPrefix(key, IndexEntryPrefix(1, [][]byte{EncodeCmpValue(value.NewStringValue("ali"))})))
+
+	oid, err := ParseTupleRecordKeyFromSecondaryIndex(key)
+	assert.Nil(t, err)
+	assert.Equal(t, primitive.ObjectID(bid), oid)
+}
+
+func TestIndexEntryKey(t *testing.T) {
+	columns := []*model.ColumnInfo{{Tp: bsontype.String}, {Tp: bsontype.String}, {Tp: bsontype.String}}
+	tuple := btuple.NewModifier([]btuple.Elem{btuple.Elem("alice"), btuple.Elem("data1"), btuple.Elem("read")})
+	rid := primitive.NewObjectID()
+	alice, read := EncodeCmpValue(value.NewStringValue("alice")), EncodeCmpValue(value.NewStringValue("read"))
+
+	key, v := IndexEntry(mockIndexInfoData, columns, tuple, rid)
+	assert.Equal(t, CompositeIndexEntryKey(1, [][]byte{alice, read, read}, rid[:]), key)
+	reader, err := btuple.NewReader(v)
+	assert.Nil(t, err)
+	assert.Equal(t, []btuple.Elem{btuple.Elem("alice"), btuple.Elem("read"), btuple.Elem("read")}, reader.Values())
+}
+
+func TestDecodeIndexEntry(t *testing.T) {
+	columns := []*model.ColumnInfo{{Tp: bsontype.String}, {Tp: bsontype.String}, {Tp: bsontype.String}}
+	tuple := btuple.NewModifier([]btuple.Elem{btuple.Elem("alice"), btuple.Elem("data1"), btuple.Elem("read")})
+	rid := primitive.NewObjectID()
+
+	key, v := IndexEntry(mockIndexInfoData, columns, tuple, rid)
+	decoded, decodedRid, err := DecodeIndexEntry(mockIndexInfoData, key, v)
+	assert.Nil(t, err)
+	assert.Equal(t, rid, decodedRid)
+	assert.Equal(t, []btuple.Elem{btuple.Elem("alice"), btuple.Elem("read"), btuple.Elem("read")}, decoded.Values())
+	decodedRid, err = DecodeIndexEntryRid(mockIndexInfoData, key, v)
+	assert.Nil(t, err)
+	assert.Equal(t, rid, decodedRid)
+
+	// the key of a unique index entry is the same for all the row ids
+	unique := mockIndexInfoData.Clone()
+	unique.Unique = true
+	key, v = IndexEntry(unique, columns, tuple, rid)
+	alice, read := EncodeCmpValue(value.NewStringValue("alice")), EncodeCmpValue(value.NewStringValue("read"))
+	assert.Equal(t, PrimaryIndexEntryKey(1, bytes.Join([][]byte{alice, read, read}, nil)), key)
+	assert.Equal(t, rid[:], v)
+	assert.Equal(t, key, IndexEntryKey(unique, columns, tuple, primitive.NewObjectID()))
+
+	decoded, decodedRid, err = DecodeIndexEntry(unique, key, v)
+	assert.Nil(t, err)
+	assert.Equal(t, rid, decodedRid)
+	decodedRid, err = DecodeIndexEntryRid(unique, key, v)
+	assert.Nil(t, err)
+	assert.Equal(t, rid, decodedRid)
+	assert.Equal(t, []btuple.Elem{btuple.Elem("alice"), btuple.Elem("read"), btuple.Elem("read")}, decoded.Values())
+
+	id, err := ParseIndexId(key)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), id)
+
+	_, _, err = DecodeIndexEntry(unique, key[:len(key)-1], v)
+	assert.NotNil(t, err)
+	_, _, err = DecodeIndexEntry(unique, key, v[1:])
+	assert.Equal(t, ErrInvalidKey, err)
 }
 
 func TestParseTupleRecordKeyFromSecondaryIndex(t *testing.T) {
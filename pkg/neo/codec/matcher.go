@@ -29,29 +29,66 @@ func MatcherInfoKey(matcherId uint64) []byte {
 	return buf
 }
 
+// MatcherInfoKeyPrefix is the prefix of all MatcherInfoKey
+// key: s_m
+func MatcherInfoKeyPrefix() []byte {
+	buf := make([]byte, 0, 3)
+	buf = append(buf, mSchemaPrefix...)
+	buf = append(buf, matcherPrefixSep...)
+	return buf
+}
+
 func EncodeMatcherInfo(info *model.MatcherInfo) []byte {
 	builder := flatbuffers.NewBuilder(1024)
-	LName := builder.CreateString(info.Name.L)
-	OName := builder.CreateString(info.Name.O)
 	raw := builder.CreateString(info.Raw)
 
 	// name
-	fb.CIStrStart(builder)
-	fb.CIStrAddL(builder, LName)
-	fb.CIStrAddO(builder, OName)
-	name := fb.CIStrEnd(builder)
+	name := createCIStr(builder, info.Name)
+	// request
+	request := createCIStr(builder, info.Request)
+	// request fields
+	fields := make([]flatbuffers.UOffsetT, len(info.RequestFields))
+	for i, field := range info.RequestFields {
+		fields[i] = createCIStr(builder, field)
+	}
+	fb.MatcherInfoStartRequestFieldsVector(builder, len(fields))
+	for i := len(fields) - 1; i >= 0; i-- {
+		builder.PrependUOffsetT(fields[i])
+	}
+	requestFields := builder.EndVector(len(fields))
+	// policy
+	policy := createCIStr(builder, info.Policy)
 
 	fb.MatcherInfoStart(builder)
 	fb.MatcherInfoAddId(builder, info.ID)
 	fb.MatcherInfoAddName(builder, name)
 	fb.MatcherInfoAddRaw(builder, raw)
 	fb.MatcherInfoAddPolicyEffect(builder, byte(info.EffectPolicy))
+	fb.MatcherInfoAddRequest(builder, request)
+	fb.MatcherInfoAddRequestFields(builder, requestFields)
+	fb.MatcherInfoAddPolicy(builder, policy)
 	orc := fb.MatcherInfoEnd(builder)
 	builder.Finish(orc)
 
 	return builder.FinishedBytes()
 }
 
+func createCIStr(builder *flatbuffers.Builder, str model.CIStr) flatbuffers.UOffsetT {
+	LName := builder.CreateString(str.L)
+	OName := builder.CreateString(str.O)
+	fb.CIStrStart(builder)
+	fb.CIStrAddL(builder, LName)
+	fb.CIStrAddO(builder, OName)
+	return fb.CIStrEnd(builder)
+}
+
+func decodeCIStr(str *fb.CIStr) model.CIStr {
+	if str == nil {
+		return model.CIStr{}
+	}
+	return model.CIStr{O: string(str.O()), L: string(str.L())}
+}
+
 func DecodeMatcherInfo(buf []byte, dst *model.MatcherInfo) *model.MatcherInfo {
 	if dst == nil {
 		dst = &model.MatcherInfo{}
@@ -68,5 +105,17 @@ func DecodeMatcherInfo(buf []byte, dst *model.MatcherInfo) *model.MatcherInfo {
 	dst.Raw = string(fbInfo.Raw())
 	// policy_effect
 	dst.EffectPolicy = model.EffectPolicyType(fbInfo.PolicyEffect())
+	// request
+	dst.Request = decodeCIStr(fbInfo.Request(nil))
+	// request fields
+	fieldLen := fbInfo.RequestFieldsLength()
+	for i := 0; i < fieldLen; i++ {
+		field := new(fb.CIStr)
+		if fbInfo.RequestFields(field, i) {
+			dst.RequestFields = append(dst.RequestFields, decodeCIStr(field))
+		}
+	}
+	// policy
+	dst.Policy = decodeCIStr(fbInfo.Policy(nil))
 	return dst
 }
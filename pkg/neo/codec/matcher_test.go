@@ -15,6 +15,12 @@ var (
 		},
 		Raw:          "r.sub==p.sub && r.obj==p.obj",
 		EffectPolicy: 1,
+		Request:      model.CIStr{O: "r", L: "r"},
+		RequestFields: []model.CIStr{
+			{O: "sub", L: "sub"},
+			{O: "Obj", L: "obj"},
+		},
+		Policy: model.CIStr{O: "p", L: "p"},
 	}
 )
 
@@ -1,15 +1,180 @@
 package codec
 
 import (
+	"encoding/binary"
+	"math"
+	"time"
+
 	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
 	"github.com/casbin-mesh/neo/pkg/primitive/value"
 )
 
+// The flags prefix the mem-comparable values, the values of different
+// types are ordered by their flags, so NULL orders first.
+const (
+	nullFlag     byte = 0x00
+	stringFlag   byte = 0x01
+	binaryFlag   byte = 0x02
+	int32Flag    byte = 0x03
+	int64Flag    byte = 0x04
+	doubleFlag   byte = 0x05
+	booleanFlag  byte = 0x06
+	dateTimeFlag byte = 0x07
+)
+
+const (
+	encGroupSize = 8
+	encMarker    = byte(0xFF)
+	encPad       = byte(0x0)
+	signMask     = uint64(0x8000000000000000)
+)
+
+var pads = make([]byte, encGroupSize)
+
+// EncodeCmpValue encodes the value to the mem-comparable format, the encoded values
+// compare in bytes as the values do, and none of them is a prefix of another one,
+// so they can be concatenated into a key and be decoded back.
+// The values of the unsupported types are encoded as NULL.
 func EncodeCmpValue(v value.Value) []byte {
+	return AppendCmpValue(nil, v)
+}
+
+// AppendCmpValue appends the mem-comparable value to b.
+func AppendCmpValue(b []byte, v value.Value) []byte {
 	switch v.Type() {
-	case bsontype.String, bsontype.Binary:
-		return v.GetBytes()
-		//TODO: to support more types
+	case bsontype.String:
+		return encodeBytes(append(b, stringFlag), v.GetBytes())
+	case bsontype.Binary:
+		return encodeBytes(append(b, binaryFlag), v.GetBytes())
+	case bsontype.Int32:
+		return appendUint32(append(b, int32Flag), uint32(v.GetInt32())^uint32(signMask>>32))
+	case bsontype.Int64:
+		return encodeInt64(append(b, int64Flag), v.GetInt64())
+	case bsontype.DateTime:
+		return encodeInt64(append(b, dateTimeFlag), v.GetDateTime())
+	case bsontype.Double:
+		return appendUint64(append(b, doubleFlag), encodeDouble(v.GetDouble()))
+	case bsontype.Boolean:
+		if v.GetBoolean() {
+			return append(b, booleanFlag, 1)
+		}
+		return append(b, booleanFlag, 0)
+	}
+	return append(b, nullFlag)
+}
+
+// DecodeCmpValue decodes a mem-comparable value from the beginning of b, and returns the rest of b.
+func DecodeCmpValue(b []byte) (v value.Value, rest []byte, err error) {
+	if len(b) == 0 {
+		return value.Value{}, nil, ErrInvalidCmpValue
+	}
+	flag, b := b[0], b[1:]
+	switch flag {
+	case nullFlag:
+		return value.NewNullValue(), b, nil
+	case stringFlag, binaryFlag:
+		var data []byte
+		if data, rest, err = decodeBytes(b); err != nil {
+			return
+		}
+		if flag == stringFlag {
+			return value.NewStringValue(string(data)), rest, nil
+		}
+		return value.NewBinaryValue(data), rest, nil
+	case int32Flag:
+		if len(b) < 4 {
+			break
+		}
+		return value.NewInt32Value(int32(binary.BigEndian.Uint32(b) ^ uint32(signMask>>32))), b[4:], nil
+	case int64Flag, dateTimeFlag:
+		if len(b) < 8 {
+			break
+		}
+		i := int64(binary.BigEndian.Uint64(b) ^ signMask)
+		if flag == int64Flag {
+			return value.NewInt64Value(i), b[8:], nil
+		}
+		return value.NewDateTimeValue(time.UnixMilli(i)), b[8:], nil
+	case doubleFlag:
+		if len(b) < 8 {
+			break
+		}
+		return value.NewDoubleValue(decodeDouble(binary.BigEndian.Uint64(b))), b[8:], nil
+	case booleanFlag:
+		if len(b) < 1 || b[0] > 1 {
+			break
+		}
+		return value.NewBooleanValue(b[0] == 1), b[1:], nil
+	}
+	return value.Value{}, nil, ErrInvalidCmpValue
+}
+
+func encodeInt64(b []byte, i int64) []byte {
+	return appendUint64(b, uint64(i)^signMask)
+}
+
+// encodeDouble flips the sign bit of a positive number, and all the bits of a negative one.
+func encodeDouble(f float64) uint64 {
+	if f == 0 {
+		// -0 equals to 0
+		f = 0
+	}
+	u := math.Float64bits(f)
+	if f >= 0 {
+		return u | signMask
+	}
+	return ^u
+}
+
+func decodeDouble(u uint64) float64 {
+	if u&signMask > 0 {
+		return math.Float64frombits(u &^ signMask)
+	}
+	return math.Float64frombits(^u)
+}
+
+// encodeBytes splits data into the groups of 8 bytes, pads the last group with zeros,
+// and appends each group with a marker 0xFF - the number of the padded bytes:
+//
+//	[group1][marker1]...[groupN][markerN]
+//
+// e.g. "abc" is encoded as [a b c 0 0 0 0 0 250], a data of 8 bytes is followed by an empty group.
+func encodeBytes(b []byte, data []byte) []byte {
+	for idx := 0; idx <= len(data); idx += encGroupSize {
+		remain := len(data) - idx
+		padCount := 0
+		if remain >= encGroupSize {
+			b = append(b, data[idx:idx+encGroupSize]...)
+		} else {
+			padCount = encGroupSize - remain
+			b = append(b, data[idx:]...)
+			b = append(b, pads[:padCount]...)
+		}
+		b = append(b, encMarker-byte(padCount))
+	}
+	return b
+}
+
+func decodeBytes(b []byte) (data []byte, rest []byte, err error) {
+	data = make([]byte, 0, len(b))
+	for {
+		if len(b) < encGroupSize+1 {
+			return nil, nil, ErrInvalidCmpValue
+		}
+		group, marker := b[:encGroupSize], b[encGroupSize]
+		padCount := int(encMarker - marker)
+		if padCount > encGroupSize {
+			return nil, nil, ErrInvalidCmpValue
+		}
+		data = append(data, group[:encGroupSize-padCount]...)
+		b = b[encGroupSize+1:]
+		if padCount != 0 {
+			for _, pad := range group[encGroupSize-padCount:] {
+				if pad != encPad {
+					return nil, nil, ErrInvalidCmpValue
+				}
+			}
+			return data, b, nil
+		}
 	}
-	return nil
 }
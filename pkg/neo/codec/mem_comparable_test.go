@@ -0,0 +1,211 @@
+package codec
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
+	"github.com/stretchr/testify/assert"
+)
+
+// compareValues compares the values of the same type.
+func compareValues(a, b value.Value) int {
+	switch a.Type() {
+	case bsontype.String, bsontype.Binary:
+		return bytes.Compare(a.GetBytes(), b.GetBytes())
+	case bsontype.Int32:
+		return compareInt64(int64(a.GetInt32()), int64(b.GetInt32()))
+	case bsontype.Int64, bsontype.DateTime:
+		return compareInt64(a.GetInt64(), b.GetInt64())
+	case bsontype.Double:
+		switch {
+		case a.GetDouble() < b.GetDouble():
+			return -1
+		case a.GetDouble() > b.GetDouble():
+			return 1
+		}
+		return 0
+	case bsontype.Boolean:
+		return compareInt64(boolToInt64(a.GetBoolean()), boolToInt64(b.GetBoolean()))
+	}
+	return 0
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func boolToInt64(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func sign(i int) int {
+	switch {
+	case i < 0:
+		return -1
+	case i > 0:
+		return 1
+	}
+	return 0
+}
+
+func randomBytes(r *rand.Rand) []byte {
+	// the small alphabet makes common prefixes, zeros and separators likely
+	alphabet := []byte{0, 1, '_', 'a', 'b', 0xFF}
+	b := make([]byte, r.Intn(20))
+	for i := range b {
+		b[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	return b
+}
+
+// randomValues returns the random values of the type, including the edge cases.
+func randomValues(r *rand.Rand, t bsontype.Type, n int) []value.Value {
+	var values []value.Value
+	switch t {
+	case bsontype.String:
+		for _, s := range []string{"", "a", "a_", "a_b", "ab", "a\x00", "12345678", "123456789", strings.Repeat("\xff", 9)} {
+			values = append(values, value.NewStringValue(s))
+		}
+		for i := 0; i < n; i++ {
+			values = append(values, value.NewStringValue(string(randomBytes(r))))
+		}
+	case bsontype.Binary:
+		values = append(values, value.NewBinaryValue([]byte{}), value.NewBinaryValue([]byte{0, 0, 0, 0, 0, 0, 0, 0}))
+		for i := 0; i < n; i++ {
+			values = append(values, value.NewBinaryValue(randomBytes(r)))
+		}
+	case bsontype.Int32:
+		values = append(values, value.NewInt32Value(math.MinInt32), value.NewInt32Value(math.MaxInt32), value.NewInt32Value(0), value.NewInt32Value(-1))
+		for i := 0; i < n; i++ {
+			values = append(values, value.NewInt32Value(int32(r.Uint32())))
+		}
+	case bsontype.Int64:
+		values = append(values, value.NewInt64Value(math.MinInt64), value.NewInt64Value(math.MaxInt64), value.NewInt64Value(0), value.NewInt64Value(-1))
+		for i := 0; i < n; i++ {
+			values = append(values, value.NewInt64Value(int64(r.Uint64())))
+		}
+	case bsontype.Double:
+		for _, f := range []float64{math.Inf(-1), -math.MaxFloat64, -1, -math.SmallestNonzeroFloat64, 0, math.SmallestNonzeroFloat64, 1, math.MaxFloat64, math.Inf(1)} {
+			values = append(values, value.NewDoubleValue(f))
+		}
+		for i := 0; i < n; i++ {
+			values = append(values, value.NewDoubleValue(r.NormFloat64()*math.Pow(10, float64(r.Intn(40)-20))))
+		}
+	case bsontype.Boolean:
+		values = append(values, value.NewBooleanValue(false), value.NewBooleanValue(true))
+	case bsontype.DateTime:
+		values = append(values, value.NewDateTimeValue(time.UnixMilli(0)), value.NewDateTimeValue(time.UnixMilli(-1)))
+		for i := 0; i < n; i++ {
+			values = append(values, value.NewDateTimeValue(time.UnixMilli(r.Int63n(1<<50)-1<<49)))
+		}
+	}
+	return values
+}
+
+var cmpTypes = []bsontype.Type{bsontype.String, bsontype.Binary, bsontype.Int32, bsontype.Int64, bsontype.Double, bsontype.Boolean, bsontype.DateTime}
+
+func TestCmpValue_RoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, typ := range cmpTypes {
+		for _, v := range randomValues(r, typ, 200) {
+			decoded, rest, err := DecodeCmpValue(EncodeCmpValue(v))
+			assert.Nil(t, err)
+			assert.Empty(t, rest)
+			assert.Equal(t, typ, decoded.Type())
+			assert.Equal(t, 0, compareValues(v, decoded), v.String())
+		}
+	}
+
+	decoded, rest, err := DecodeCmpValue(EncodeCmpValue(value.NewNullValue()))
+	assert.Nil(t, err)
+	assert.Empty(t, rest)
+	assert.Equal(t, bsontype.Null, decoded.Type())
+
+	// -0 equals to 0
+	assert.Equal(t, EncodeCmpValue(value.NewDoubleValue(0)), EncodeCmpValue(value.NewDoubleValue(math.Copysign(0, -1))))
+}
+
+func TestCmpValue_Order(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for _, typ := range cmpTypes {
+		values := randomValues(r, typ, 100)
+		for _, a := range values {
+			for _, b := range values {
+				assert.Equal(t, sign(compareValues(a, b)), sign(bytes.Compare(EncodeCmpValue(a), EncodeCmpValue(b))), typ.String())
+			}
+		}
+	}
+
+	// NULL orders first
+	null := EncodeCmpValue(value.NewNullValue())
+	for _, typ := range cmpTypes {
+		for _, v := range randomValues(r, typ, 10) {
+			assert.Equal(t, -1, bytes.Compare(null, EncodeCmpValue(v)))
+		}
+	}
+}
+
+func TestCmpValue_Concatenated(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	type row [2]value.Value
+	var rows []row
+	strs, ints := randomValues(r, bsontype.String, 30), randomValues(r, bsontype.Int64, 30)
+	for i := 0; i < 200; i++ {
+		rows = append(rows, row{strs[r.Intn(len(strs))], ints[r.Intn(len(ints))]})
+	}
+	encode := func(row row) []byte {
+		return AppendCmpValue(EncodeCmpValue(row[0]), row[1])
+	}
+
+	// the concatenated values decode back, and order as the tuples of the values
+	for _, row := range rows {
+		first, rest, err := DecodeCmpValue(encode(row))
+		assert.Nil(t, err)
+		second, rest, err := DecodeCmpValue(rest)
+		assert.Nil(t, err)
+		assert.Empty(t, rest)
+		assert.Equal(t, 0, compareValues(row[0], first))
+		assert.Equal(t, 0, compareValues(row[1], second))
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return bytes.Compare(encode(rows[i]), encode(rows[j])) < 0
+	})
+	for i := 1; i < len(rows); i++ {
+		c := compareValues(rows[i-1][0], rows[i][0])
+		if c == 0 {
+			c = compareValues(rows[i-1][1], rows[i][1])
+		}
+		assert.LessOrEqual(t, c, 0)
+	}
+}
+
+func TestDecodeCmpValue_Invalid(t *testing.T) {
+	for _, b := range [][]byte{
+		nil,
+		{0xEE},
+		{stringFlag, 'a', 0, 0},
+		{stringFlag, 'a', 'b', 'c', 0, 0, 0, 0, 1, 0xFA},
+		{stringFlag, 'a', 0, 0, 0, 0, 0, 0, 0, 0xF0},
+		{int64Flag, 0, 0, 0},
+		{booleanFlag, 2},
+	} {
+		_, _, err := DecodeCmpValue(b)
+		assert.Equal(t, ErrInvalidCmpValue, err, b)
+	}
+}
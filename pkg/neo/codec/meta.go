@@ -40,6 +40,15 @@ func MetaKey(namespace string) []byte {
 	return buf
 }
 
+// MetaKeyPrefix is the prefix of all MetaKey
+// key: m_n
+func MetaKeyPrefix() []byte {
+	buf := make([]byte, 0, len(mMetaPrefix)+len(namespacePrefixSep))
+	buf = append(buf, mMetaPrefix...)
+	buf = append(buf, namespacePrefixSep...)
+	return buf
+}
+
 //ColumnKey
 // key: m_t{tid}_c{columnName}
 func ColumnKey(tid uint64, columnName string) []byte {
@@ -29,6 +29,15 @@ func TableInfoKey(tableId uint64) []byte {
 	return buf
 }
 
+// TableInfoKeyPrefix is the prefix of all TableInfoKey
+// key: s_t
+func TableInfoKeyPrefix() []byte {
+	buf := make([]byte, 0, 3)
+	buf = append(buf, mSchemaPrefix...)
+	buf = append(buf, tablePrefixSep...)
+	return buf
+}
+
 func EncodeTableInfo(info *model.TableInfo) []byte {
 	builder := flatbuffers.NewBuilder(1024)
 	LName := builder.CreateString(info.Name.L)
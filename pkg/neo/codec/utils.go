@@ -21,3 +21,9 @@ func appendUint64(buf []byte, v uint64) []byte {
 	binary.BigEndian.PutUint64(data[:], v)
 	return append(buf, data[:]...)
 }
+
+func appendUint32(buf []byte, v uint32) []byte {
+	var data [4]byte
+	binary.BigEndian.PutUint32(data[:], v)
+	return append(buf, data[:]...)
+}
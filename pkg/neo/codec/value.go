@@ -1,14 +1,39 @@
 package codec
 
 import (
+	"encoding/binary"
+	"math"
+	"time"
+
 	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
 	"github.com/casbin-mesh/neo/pkg/primitive/value"
 )
 
+// EncodeValue encodes the value to an element of a tuple,
+// the numbers are encoded in little-endian as BSON's.
 func EncodeValue(v value.Value) []byte {
 	switch v.Type() {
 	case bsontype.String, bsontype.Binary:
 		return v.GetBytes()
+	case bsontype.Int32:
+		b := make([]byte, 4)
+		binary.LittleEndian.PutUint32(b, uint32(v.GetInt32()))
+		return b
+	case bsontype.Int64, bsontype.DateTime:
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint64(b, uint64(v.GetInt64()))
+		return b
+	case bsontype.Double:
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint64(b, math.Float64bits(v.GetDouble()))
+		return b
+	case bsontype.Boolean:
+		if v.GetBoolean() {
+			return []byte{1}
+		}
+		return []byte{0}
+	case bsontype.Null:
+		return []byte{}
 		//TODO: to support more types
 	}
 	return nil
@@ -22,12 +47,35 @@ func EncodeValues(vs value.Values) [][]byte {
 	return ret
 }
 
+// DecodeValue decodes an element of a tuple of the type, a malformed element decodes to NULL.
 func DecodeValue(bytes []byte, p bsontype.Type) value.Value {
 	switch p {
 	case bsontype.String:
 		v := value.NewStringValue(string(bytes))
 		return v
+	case bsontype.Binary:
+		return value.NewBinaryValue(bytes)
+	case bsontype.Int32:
+		if len(bytes) == 4 {
+			return value.NewInt32Value(int32(binary.LittleEndian.Uint32(bytes)))
+		}
+	case bsontype.Int64:
+		if len(bytes) == 8 {
+			return value.NewInt64Value(int64(binary.LittleEndian.Uint64(bytes)))
+		}
+	case bsontype.DateTime:
+		if len(bytes) == 8 {
+			return value.NewDateTimeValue(time.UnixMilli(int64(binary.LittleEndian.Uint64(bytes))))
+		}
+	case bsontype.Double:
+		if len(bytes) == 8 {
+			return value.NewDoubleValue(math.Float64frombits(binary.LittleEndian.Uint64(bytes)))
+		}
+	case bsontype.Boolean:
+		if len(bytes) == 1 {
+			return value.NewBooleanValue(bytes[0] != 0)
+		}
 		//TODO: to support more types
 	}
-	return value.Value{}
+	return value.NewNullValue()
 }
@@ -0,0 +1,27 @@
+package codec
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValue_RoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, typ := range cmpTypes {
+		for _, v := range randomValues(r, typ, 20) {
+			decoded := DecodeValue(EncodeValue(v), typ)
+			assert.Equal(t, typ, decoded.Type())
+			assert.Equal(t, 0, compareValues(v, decoded), v.String())
+		}
+	}
+
+	// a malformed element decodes to NULL
+	v := DecodeValue([]byte{1, 2}, bsontype.Int64)
+	assert.Equal(t, bsontype.Null, v.Type())
+	v = value.NewNullValue()
+	assert.Equal(t, []byte{}, EncodeValue(v))
+}
@@ -0,0 +1,396 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package neo
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/casbin-mesh/neo/pkg/expression"
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/expression/builtin"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/neo/txn"
+	"github.com/casbin-mesh/neo/pkg/neo/utils"
+	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
+)
+
+const (
+	defaultMatcher = "m"
+	defaultPType   = "p"
+)
+
+// CreateModel creates a model(database) described by info.
+func (e *Engine) CreateModel(info *model.DBInfo) error {
+	ctx := context.TODO()
+	err := e.update(ctx, func(sc session.Context) error {
+		_, err := execute(ctx, sc, plan.NewCreateDBPlan(info))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	e.setupRoles(info)
+	return nil
+}
+
+// CreateModelFromFile compiles the model.conf located at path, and creates it as a model named name.
+func (e *Engine) CreateModelFromFile(name string, path string) error {
+	info, err := utils.CompileModelFromFile(name, path)
+	if err != nil {
+		return err
+	}
+	return e.CreateModel(info)
+}
+
+// CreateModelFromString compiles the model text, and creates it as a model named name.
+func (e *Engine) CreateModelFromString(name string, text string) error {
+	info, err := utils.CompileModelFromString(name, text)
+	if err != nil {
+		return err
+	}
+	return e.CreateModel(info)
+}
+
+// Enforce decides whether a request is allowed by the model.
+func (e *Engine) Enforce(model string, req ...string) (allowed bool, err error) {
+	return e.EnforceContext(context.TODO(), model, req...)
+}
+
+// EnforceContext decides whether a request is allowed by the model, the enforcement stops
+// with an ErrCanceled once ctx is done.
+func (e *Engine) EnforceContext(ctx context.Context, model string, req ...string) (allowed bool, err error) {
+	err = e.view(ctx, func(sc session.Context) error {
+		p, err := e.enforcePlan(ctx, sc, model, req)
+		if err != nil {
+			return err
+		}
+		results, err := executeTuples(ctx, sc, p)
+		if err != nil {
+			return err
+		}
+		allowed = isAllowed(results)
+		return nil
+	})
+	return
+}
+
+// Explain returns the plan tree the request would be enforced by, without running it.
+func (e *Engine) Explain(model string, req ...string) (node *executor.PlanNode, err error) {
+	ctx := context.TODO()
+	err = e.view(ctx, func(sc session.Context) error {
+		p, err := e.enforcePlan(ctx, sc, model, req)
+		if err != nil {
+			return err
+		}
+		node = executor.Explain(sc, p)
+		return nil
+	})
+	return
+}
+
+// ExplainAnalyze enforces the request, and returns the plan tree with the rows, the Next calls
+// and the wall time of every node.
+func (e *Engine) ExplainAnalyze(model string, req ...string) (node *executor.PlanNode, allowed bool, err error) {
+	ctx := context.TODO()
+	err = e.view(ctx, func(sc session.Context) error {
+		p, err := e.enforcePlan(ctx, sc, model, req)
+		if err != nil {
+			return err
+		}
+		var results []btuple.Modifier
+		if node, results, _, err = executor.ExplainAnalyze(ctx, sc, p); err != nil {
+			return err
+		}
+		allowed = isAllowed(results)
+		return nil
+	})
+	return
+}
+
+// enforcePlan plans the access to the policy table for the request, and the enforcement on its rows.
+func (e *Engine) enforcePlan(ctx context.Context, sc session.Context, model string, req []string) (plan.AbstractPlan, error) {
+	dbInfo, err := sc.GetCatalog().GetDBInfoByName(model)
+	if err != nil {
+		return nil, err
+	}
+	matcher, err := dbInfo.MatcherByLName(defaultMatcher)
+	if err != nil {
+		return nil, err
+	}
+	tableInfo, err := dbInfo.TableByLName(matcher.Policy.L)
+	if err != nil {
+		return nil, err
+	}
+	if len(req) != len(matcher.RequestFields) {
+		return nil, ErrInvalidRequest
+	}
+
+	elems := make([]btuple.Elem, 0, len(req))
+	for _, s := range req {
+		elems = append(elems, btuple.Elem(s))
+	}
+	request := btuple.NewModifier(elems)
+	access, filter, err := e.planner.PlanAccess(ctx, sc, dbInfo.ID, tableInfo, matcher, request)
+	if err != nil {
+		return nil, err
+	}
+	evalCtx := newEvalCtx()
+	e.addRoleFunctions(evalCtx, dbInfo.Name.L)
+	return plan.NewFilteredEnforcePlan([]plan.AbstractPlan{access}, matcher, filter, request, evalCtx, dbInfo.ID), nil
+}
+
+func isAllowed(results []btuple.Modifier) bool {
+	return len(results) == 1 && results[0].ValueAt(0)[0] == 1
+}
+
+// AddPolicy adds a rule to the default policy table of the model,
+// returns false if the rule already exists.
+func (e *Engine) AddPolicy(model string, rule ...string) (bool, error) {
+	return e.AddNamedPolicy(model, defaultPType, rule...)
+}
+
+// AddNamedPolicy adds a rule to the ptype table of the model,
+// returns false if the rule already exists.
+func (e *Engine) AddNamedPolicy(model string, ptype string, rule ...string) (added bool, err error) {
+	ctx := context.TODO()
+	var tableId uint64
+	err = e.updateTxn(ctx, func(t *txn.Txn) error {
+		sc, ok := t.Session(), false
+		dbInfo, tableInfo, err := lookupTable(sc, model, ptype)
+		if err != nil {
+			return err
+		}
+		if rule, ok = normalizeRule(tableInfo, rule); !ok {
+			return ErrInvalidRequest
+		}
+		values, ok := ruleValues(tableInfo, rule)
+		if !ok {
+			return ErrInvalidRequest
+		}
+
+		lookup, _ := ruleLookup(dbInfo, tableInfo, values)
+		ids, err := execute(ctx, sc, lookup)
+		if err != nil || len(ids) > 0 {
+			return err
+		}
+
+		if _, err = execute(ctx, sc, plan.NewRawInsertPlan([]value.Values{values}, dbInfo.ID, tableInfo.ID)); err != nil {
+			return err
+		}
+		added, tableId = true, tableInfo.ID
+		t.OnCommit(func(commitTs uint64) {
+			e.applyRoleRules(model, ptype, [][]string{rule}, true, commitTs)
+		})
+		return nil
+	})
+	if added && err == nil {
+		e.planner.Modify(tableId, 1)
+	}
+	return
+}
+
+// RemovePolicy removes a rule from the default policy table of the model,
+// returns false if the rule does not exist.
+func (e *Engine) RemovePolicy(model string, rule ...string) (bool, error) {
+	return e.RemoveNamedPolicy(model, defaultPType, rule...)
+}
+
+// RemoveNamedPolicy removes a rule from the ptype table of the model,
+// returns false if the rule does not exist.
+func (e *Engine) RemoveNamedPolicy(model string, ptype string, rule ...string) (removed bool, err error) {
+	ctx := context.TODO()
+	var tableId uint64
+	err = e.updateTxn(ctx, func(t *txn.Txn) error {
+		sc, ok := t.Session(), false
+		dbInfo, tableInfo, err := lookupTable(sc, model, ptype)
+		if err != nil {
+			return err
+		}
+		if rule, ok = normalizeRule(tableInfo, rule); !ok {
+			return ErrInvalidRequest
+		}
+		values, ok := ruleValues(tableInfo, rule)
+		if !ok {
+			return ErrInvalidRequest
+		}
+
+		lookup, rows := ruleLookup(dbInfo, tableInfo, values)
+		ids, err := execute(ctx, sc, lookup)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		if _, err = execute(ctx, sc, plan.NewDeletePlan([]plan.AbstractPlan{rows}, tableInfo.ID, dbInfo.ID)); err != nil {
+			return err
+		}
+		removed, tableId = true, tableInfo.ID
+		// the links of the deleted row are removed
+		rules := tupleStrings(tableInfo, []btuple.Modifier{btuple.NewModifierFromBytes(codec.EncodeValues(values))})
+		t.OnCommit(func(commitTs uint64) {
+			e.applyRoleRules(model, ptype, rules, false, commitTs)
+		})
+		return nil
+	})
+	if removed && err == nil {
+		e.planner.Modify(tableId, 1)
+	}
+	return
+}
+
+func lookupTable(sc session.Context, model, table string) (*model.DBInfo, *model.TableInfo, error) {
+	dbInfo, err := sc.GetCatalog().GetDBInfoByName(model)
+	if err != nil {
+		return nil, nil, err
+	}
+	tableInfo, err := dbInfo.TableByLName(table)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dbInfo, tableInfo, nil
+}
+
+func execute(ctx context.Context, sc session.Context, p plan.AbstractPlan) ([]primitive.ObjectID, error) {
+	_, ids, err := executePlan(ctx, sc, p)
+	return ids, err
+}
+
+func executeTuples(ctx context.Context, sc session.Context, p plan.AbstractPlan) ([]btuple.Modifier, error) {
+	tuples, _, err := executePlan(ctx, sc, p)
+	return tuples, err
+}
+
+func executePlan(ctx context.Context, sc session.Context, p plan.AbstractPlan) ([]btuple.Modifier, []primitive.ObjectID, error) {
+	builder := executor.NewExecutorBuilder(sc)
+	exec, err := builder.Build(p), builder.Error()
+	if err != nil {
+		return nil, nil, err
+	}
+	return executor.Execute(exec, ctx)
+}
+
+func newEvalCtx() *ast.Context {
+	ctx := ast.NewContext()
+	for name, fn := range builtin.BuildinFnSet {
+		ctx.AddFunctionWithCtx(name, fn)
+	}
+	return ctx
+}
+
+// normalizeRule returns the values the rule is stored as, one for every column of the table. The empty
+// values and the omitted trailing columns take their default values, e.g., eft is allow, as an insert does.
+// It returns false if the rule is empty, too long, or omits a column without a default value.
+func normalizeRule(tableInfo *model.TableInfo, rule []string) ([]string, bool) {
+	if len(rule) == 0 || len(rule) > len(tableInfo.Columns) {
+		return nil, false
+	}
+	values := make([]string, len(tableInfo.Columns))
+	for i, column := range tableInfo.Columns {
+		defaultValue := string(column.GetDefaultValue())
+		switch {
+		case i < len(rule) && (rule[i] != "" || defaultValue == ""):
+			values[i] = rule[i]
+		case i < len(rule) || defaultValue != "":
+			values[i] = defaultValue
+		default:
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+// ruleValues parses the values of the rule by the types of their columns,
+// it returns false if a value isn't of the type of its column, e.g. a priority isn't an integer.
+func ruleValues(tableInfo *model.TableInfo, rule []string) (value.Values, bool) {
+	values := make(value.Values, 0, len(rule))
+	for i, s := range rule {
+		switch tableInfo.Columns[i].Tp {
+		case bsontype.Int64:
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, false
+			}
+			values = append(values, value.NewInt64Value(n))
+		default:
+			values = append(values, value.NewStringValue(s))
+		}
+	}
+	return values, true
+}
+
+// formatElem formats the element of the column as a value of a rule.
+func formatElem(column *model.ColumnInfo, elem []byte) string {
+	if column.Tp == bsontype.Int64 {
+		if v := codec.DecodeValue(elem, bsontype.Int64); v.Type() == bsontype.Int64 {
+			return strconv.FormatInt(v.GetInt64(), 10)
+		}
+	}
+	return string(elem)
+}
+
+// ruleLookup returns the plans look up the rule in the table, the rule has a value for every column.
+// The first one yields the row id of the rule by its key of the primary index, and the second one its row.
+// Both scan the table if it has no primary index.
+func ruleLookup(dbInfo *model.DBInfo, tableInfo *model.TableInfo, values value.Values) (ids, rows plan.AbstractPlan) {
+	for i, index := range tableInfo.Indices {
+		if !index.Primary {
+			continue
+		}
+		tuple := btuple.NewModifierFromBytes(codec.EncodeValues(values))
+		// the key of a unique index entry has no row id, the scan looks it up by Get
+		key := codec.IndexEntryKey(index, tableInfo.Columns, tuple, primitive.ObjectID{})
+		scan := plan.NewIndexScanPlan(model.NewIndexSchemaReader(tableInfo, i), key, nil, nil, dbInfo.ID, tableInfo.ID)
+		return scan, plan.NewTableRowIdScan(tableInfo, dbInfo.ID, tableInfo.ID, scan)
+	}
+	predicate, evalCtx := newRulePredicate(tableInfo, values)
+	scan := plan.NewSeqScanPlan(tableInfo, predicate, evalCtx, dbInfo.ID, tableInfo.ID)
+	return plan.NewLimitPlan([]plan.AbstractPlan{scan}, 1), scan
+}
+
+// newRulePredicate generates a predicate matches the tuples equal to the rule, the rule has a value for every column.
+func newRulePredicate(tableInfo *model.TableInfo, values value.Values) (expression.Expression, ast.EvaluateCtx) {
+	var root ast.Evaluable
+	for i, v := range values {
+		literal := &ast.Primitive{Typ: ast.STRING, Value: v.GetString()}
+		if v.Type() == bsontype.Int64 {
+			literal = &ast.Primitive{Typ: ast.INT, Value: int(v.GetInt64())}
+		}
+		eq := &ast.BinaryOperationExpr{
+			Op: ast.EQ_OP,
+			L: &ast.Accessor{
+				Typ:      ast.MEMBER_ACCESSOR,
+				Ancestor: &ast.Primitive{Typ: ast.IDENTIFIER, Value: tableInfo.Name.L},
+				Ident:    &ast.Primitive{Typ: ast.IDENTIFIER, Value: tableInfo.Columns[i].ColName.L},
+			},
+			R: literal,
+		}
+		if root == nil {
+			root = eq
+		} else {
+			root = expression.ConnectSubtree(root, eq)
+		}
+	}
+	predicate, accessor := expression.NewExpression(root)
+	evalCtx := ast.NewContext()
+	evalCtx.AddAccessor(tableInfo.Name.L, accessor)
+	return predicate, evalCtx
+}
@@ -0,0 +1,185 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/bschema"
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
+)
+
+// aggregateState is the state of an aggregate of a group.
+type aggregateState struct {
+	count    int64
+	distinct map[string]struct{}
+	// elem is the minimum or maximum value, cmp is its mem-comparable form
+	elem btuple.Elem
+	cmp  []byte
+}
+
+type aggregateGroup struct {
+	key    []btuple.Elem
+	states []aggregateState
+}
+
+type aggregationExecutor struct {
+	baseExecutor
+	aggregationPlan plan.AggregationPlan
+	childExecutor   Executor
+	inputSchema     bschema.Reader
+	// groups are in the order they are first seen
+	groups   []*aggregateGroup
+	prepared bool
+	cursor   int
+	cancel   canceler
+}
+
+func (a *aggregationExecutor) Init() {
+	a.childExecutor.Init()
+	a.groups, a.prepared, a.cursor = nil, false, 0
+}
+
+func (a *aggregationExecutor) newGroup(key []btuple.Elem) *aggregateGroup {
+	group := &aggregateGroup{key: key, states: make([]aggregateState, len(a.aggregationPlan.Aggregates()))}
+	for i, aggregate := range a.aggregationPlan.Aggregates() {
+		if aggregate.Type == plan.AggregateCountDistinct {
+			group.states[i].distinct = make(map[string]struct{})
+		}
+	}
+	return group
+}
+
+// cmpValue returns the mem-comparable form of the value at offset, or nil if it's NULL.
+func (a *aggregationExecutor) cmpValue(tuple btuple.Modifier, offset int) []byte {
+	v := codec.DecodeValue(valueAt(tuple, offset), a.inputSchema.FieldAt(offset).Type())
+	if v.Type() == bsontype.Null {
+		return nil
+	}
+	return codec.EncodeCmpValue(v)
+}
+
+func (a *aggregationExecutor) build(ctx context.Context) error {
+	groups := make(map[string]*aggregateGroup)
+	groupBy, aggregates, offsets := a.aggregationPlan.GroupBy(), a.aggregationPlan.Aggregates(), a.aggregationPlan.AggregateOffsets()
+	for {
+		var (
+			tuple btuple.Modifier
+			rid   primitive.ObjectID
+		)
+		if err := a.cancel.check(ctx); err != nil {
+			return err
+		}
+		next, err := a.childExecutor.Next(ctx, &tuple, &rid)
+		if err != nil {
+			return err
+		}
+		if !next {
+			break
+		}
+
+		// the mem-comparable values are self-delimiting, their concatenation identifies the group
+		var hashKey []byte
+		for _, offset := range groupBy {
+			hashKey = append(hashKey, codec.EncodeCmpValue(codec.DecodeValue(valueAt(tuple, offset), a.inputSchema.FieldAt(offset).Type()))...)
+		}
+		group, ok := groups[string(hashKey)]
+		if !ok {
+			key := make([]btuple.Elem, 0, len(groupBy))
+			for _, offset := range groupBy {
+				key = append(key, valueAt(tuple, offset))
+			}
+			group = a.newGroup(key)
+			groups[string(hashKey)] = group
+			a.groups = append(a.groups, group)
+		}
+
+		for i, aggregate := range aggregates {
+			state := &group.states[i]
+			if offsets[i] < 0 {
+				state.count++
+				continue
+			}
+			cmp := a.cmpValue(tuple, offsets[i])
+			if cmp == nil {
+				continue
+			}
+			switch aggregate.Type {
+			case plan.AggregateCount:
+				state.count++
+			case plan.AggregateCountDistinct:
+				state.distinct[string(cmp)] = struct{}{}
+			case plan.AggregateMin, plan.AggregateMax:
+				c := bytes.Compare(cmp, state.cmp)
+				if state.cmp == nil || (aggregate.Type == plan.AggregateMin && c < 0) || (aggregate.Type == plan.AggregateMax && c > 0) {
+					state.elem, state.cmp = tuple.ValueAt(offsets[i]), cmp
+				}
+			}
+		}
+	}
+	// the aggregates of no tuples
+	if len(groupBy) == 0 && len(a.groups) == 0 {
+		a.groups = append(a.groups, a.newGroup(nil))
+	}
+	return nil
+}
+
+func (a *aggregationExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid *primitive.ObjectID) (next bool, err error) {
+	if !a.prepared {
+		if err = a.build(ctx); err != nil {
+			return false, err
+		}
+		a.prepared = true
+	}
+	if a.cursor >= len(a.groups) {
+		return false, nil
+	}
+	group := a.groups[a.cursor]
+	a.cursor++
+
+	elems := make([]btuple.Elem, 0, len(group.key)+len(group.states))
+	elems = append(elems, group.key...)
+	for i, aggregate := range a.aggregationPlan.Aggregates() {
+		state := group.states[i]
+		switch aggregate.Type {
+		case plan.AggregateCount:
+			elems = append(elems, codec.EncodeValue(value.NewInt64Value(state.count)))
+		case plan.AggregateCountDistinct:
+			elems = append(elems, codec.EncodeValue(value.NewInt64Value(int64(len(state.distinct)))))
+		default:
+			if state.cmp == nil {
+				elems = append(elems, codec.EncodeValue(value.NewNullValue()))
+			} else {
+				elems = append(elems, state.elem)
+			}
+		}
+	}
+	*tuple = btuple.NewModifier(elems)
+	*rid = primitive.ObjectID{}
+	return true, nil
+}
+
+func (a *aggregationExecutor) Close() error {
+	return a.childExecutor.Close()
+}
+
+func NewAggregationExecutor(ctx session.Context, aggregationPlan plan.AggregationPlan, child Executor) (Executor, error) {
+	return &aggregationExecutor{
+		baseExecutor:    newBaseExecutor(ctx),
+		aggregationPlan: aggregationPlan,
+		childExecutor:   child,
+		inputSchema:     plan.InputSchema(aggregationPlan.GetChildAt(0)),
+	}, nil
+}
+
+// valueAt returns the value at offset, or an empty one if the tuple is shorter.
+func valueAt(tuple btuple.Modifier, offset int) btuple.Elem {
+	if offset < len(tuple.Values()) {
+		return tuple.ValueAt(offset)
+	}
+	return nil
+}
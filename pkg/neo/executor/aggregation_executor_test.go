@@ -0,0 +1,141 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/utils"
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestAggregationExecutor(t *testing.T) {
+	p := "./__test_tmp__/aggregation_exec"
+	mockDb := OpenMockDB(t, p)
+	defer func() {
+		mockDb.Close()
+		os.RemoveAll(p)
+	}()
+
+	info, err := utils.CompileModelFromString("aggregation", `[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = priority, sub, obj, act
+
+[policy_effect]
+e = priority(p.eft) || deny
+
+[matchers]
+m = r.sub == p.sub && r.obj == p.obj && r.act == p.act`)
+	assert.Nil(t, err)
+	table, empty := info.TableInfo[0], info.TableInfo[0].Clone()
+	table.Columns[0].Tp = bsontype.Int64
+	empty.ID, empty.Name.L = table.ID+100, "empty"
+	info.TableInfo = append(info.TableInfo, empty)
+	rule := func(priority int64, sub, obj, act string) value.Values {
+		return value.Values{value.NewInt64Value(priority), value.NewStringValue(sub), value.NewStringValue(obj), value.NewStringValue(act)}
+	}
+	sc := mockDb.NewTxnAt(1, true)
+	mockDb.CreateDB(t, sc, info)
+	_, _, err = mockDb.InsertTuples(t, sc, info.ID, table.ID, []value.Values{
+		rule(3, "alice", "data1", "read"),
+		rule(-1, "bob", "data2", "write"),
+		rule(1, "alice", "data2", "write"),
+		rule(7, "bob", "data2", "read"),
+		rule(2, "bob", "data3", "write"),
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, sc.CommitTxn(context.TODO(), 2))
+	assert.Nil(t, mockDb.WaitForMark(context.TODO(), 2))
+
+	sc = mockDb.NewTxnAt(3, false)
+	defer sc.RollbackTxn(context.TODO())
+	aggregate := func(table string, groupBy []string, aggregates ...plan.Aggregate) ([]btuple.Modifier, error) {
+		tableInfo := info.TableInfo[0]
+		if table == "empty" {
+			tableInfo = empty
+		}
+		p, err := plan.NewAggregationPlan(plan.NewSeqScanPlan(tableInfo, nil, nil, info.ID, tableInfo.ID), groupBy, aggregates)
+		if err != nil {
+			return nil, err
+		}
+		builder := executorBuilder{ctx: sc}
+		exec, err := builder.Build(p), builder.Error()
+		if err != nil {
+			return nil, err
+		}
+		result, _, err := Execute(exec, context.TODO())
+		return result, err
+	}
+	int64Elem := func(v int64) btuple.Elem {
+		return codec.EncodeValue(value.NewInt64Value(v))
+	}
+
+	t.Run("group by", func(t *testing.T) {
+		result, err := aggregate("p", []string{"sub"},
+			plan.Aggregate{Type: plan.AggregateCount},
+			plan.Aggregate{Type: plan.AggregateCountDistinct, Column: "obj"},
+			plan.Aggregate{Type: plan.AggregateMin, Column: "priority"},
+			plan.Aggregate{Type: plan.AggregateMax, Column: "priority"},
+		)
+		assert.Nil(t, err)
+		// the groups are in the order they are first seen
+		assert.Len(t, result, 2)
+		assert.Equal(t, []btuple.Elem{btuple.Elem("alice"), int64Elem(2), int64Elem(2), int64Elem(1), int64Elem(3)}, result[0].Values())
+		assert.Equal(t, []btuple.Elem{btuple.Elem("bob"), int64Elem(3), int64Elem(2), int64Elem(-1), int64Elem(7)}, result[1].Values())
+	})
+
+	t.Run("group by columns", func(t *testing.T) {
+		result, err := aggregate("p", []string{"obj", "act"}, plan.Aggregate{Type: plan.AggregateCount, Column: "sub"})
+		assert.Nil(t, err)
+		assert.Len(t, result, 4)
+		assert.Equal(t, []btuple.Elem{btuple.Elem("data2"), btuple.Elem("write"), int64Elem(2)}, result[1].Values())
+	})
+
+	t.Run("without group by", func(t *testing.T) {
+		result, err := aggregate("p", nil, plan.Aggregate{Type: plan.AggregateCount}, plan.Aggregate{Type: plan.AggregateMin, Column: "sub"})
+		assert.Nil(t, err)
+		assert.Len(t, result, 1)
+		assert.Equal(t, []btuple.Elem{int64Elem(5), btuple.Elem("alice")}, result[0].Values())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		// a single tuple of no rows
+		result, err := aggregate("empty", nil, plan.Aggregate{Type: plan.AggregateCount}, plan.Aggregate{Type: plan.AggregateMax, Column: "sub"})
+		assert.Nil(t, err)
+		assert.Len(t, result, 1)
+		assert.Equal(t, []btuple.Elem{int64Elem(0), codec.EncodeValue(value.NewNullValue())}, result[0].Values())
+
+		result, err = aggregate("empty", []string{"sub"}, plan.Aggregate{Type: plan.AggregateCount})
+		assert.Nil(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("schema", func(t *testing.T) {
+		p, err := plan.NewAggregationPlan(plan.NewSeqScanPlan(table, nil, nil, info.ID, table.ID), []string{"sub"}, []plan.Aggregate{
+			{Type: plan.AggregateCountDistinct, Column: "obj"},
+			{Type: plan.AggregateMax, Column: "priority"},
+		})
+		assert.Nil(t, err)
+		schema := p.OutputSchema()
+		assert.Equal(t, 3, schema.FieldsLen())
+		assert.Equal(t, 2, schema.Field("max(priority)"))
+		assert.Equal(t, bsontype.Int64, schema.FieldAt(1).Type())
+		assert.Equal(t, "count(distinct obj)", string(schema.FieldAt(1).Name()))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := aggregate("p", []string{"eft"}, plan.Aggregate{Type: plan.AggregateCount})
+		assert.True(t, errors.Is(err, plan.ErrUnknownColumn))
+		_, err = aggregate("p", nil, plan.Aggregate{Type: plan.AggregateMin})
+		assert.True(t, errors.Is(err, plan.ErrInvalidAggregate))
+		_, err = aggregate("p", nil, plan.Aggregate{Column: "sub"})
+		assert.True(t, errors.Is(err, plan.ErrInvalidAggregate))
+	})
+}
@@ -0,0 +1,239 @@
+package executor
+
+import (
+	"context"
+	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+)
+
+// DefaultBatchSize is the number of rows of a batch by default.
+const DefaultBatchSize = 1024
+
+// Batch holds up to its capacity rows in column vectors, the i-th row is made of the
+// i-th values of the columns. A column missed by a shorter row holds a nil value.
+type Batch struct {
+	Columns  [][]btuple.Elem
+	Rids     []primitive.ObjectID
+	capacity int
+}
+
+func NewBatch(capacity int) *Batch {
+	if capacity <= 0 {
+		capacity = DefaultBatchSize
+	}
+	return &Batch{Rids: make([]primitive.ObjectID, 0, capacity), capacity: capacity}
+}
+
+func (b *Batch) Len() int {
+	return len(b.Rids)
+}
+
+func (b *Batch) Cap() int {
+	return b.capacity
+}
+
+func (b *Batch) Full() bool {
+	return len(b.Rids) >= b.capacity
+}
+
+// Reset empties the batch, the vectors are reused by the next rows.
+func (b *Batch) Reset() {
+	b.Truncate(0)
+}
+
+// Truncate keeps the first n rows.
+func (b *Batch) Truncate(n int) {
+	for i := range b.Columns {
+		b.Columns[i] = b.Columns[i][:n]
+	}
+	b.Rids = b.Rids[:n]
+}
+
+// Append appends a row, the columns are widened to the tuple.
+func (b *Batch) Append(tuple btuple.Reader, rid primitive.ObjectID) {
+	n := len(b.Rids)
+	values := tuple.Values()
+	for len(b.Columns) < len(values) {
+		b.Columns = append(b.Columns, make([]btuple.Elem, n, b.capacity))
+	}
+	for i := range b.Columns {
+		if i < len(values) {
+			b.Columns[i] = append(b.Columns[i], values[i])
+		} else {
+			b.Columns[i] = append(b.Columns[i], nil)
+		}
+	}
+	b.Rids = append(b.Rids, rid)
+}
+
+// Retain keeps the rows that keep returns true for, in their order.
+func (b *Batch) Retain(keep func(i int) (bool, error)) error {
+	n := 0
+	for i := range b.Rids {
+		ok, err := keep(i)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if n != i {
+			for _, column := range b.Columns {
+				column[n] = column[i]
+			}
+			b.Rids[n] = b.Rids[i]
+		}
+		n++
+	}
+	b.Truncate(n)
+	return nil
+}
+
+// Row returns the i-th row, it's valid until the batch is modified.
+func (b *Batch) Row(i int) btuple.Reader {
+	return &batchRow{batch: b, row: i}
+}
+
+// Tuple returns a copy of the i-th row, the missing values of a shorter row are left out.
+func (b *Batch) Tuple(i int) btuple.Modifier {
+	end := len(b.Columns)
+	for end > 0 && b.Columns[end-1][i] == nil {
+		end--
+	}
+	elems := make([]btuple.Elem, end)
+	for j := range elems {
+		elems[j] = b.Columns[j][i]
+	}
+	return btuple.NewModifier(elems)
+}
+
+type batchRow struct {
+	batch *Batch
+	row   int
+}
+
+func (r *batchRow) ValueAt(pos int) btuple.Elem {
+	return r.batch.Columns[pos][r.row]
+}
+
+func (r *batchRow) Occupied(pos int) bool {
+	return pos >= 0 && pos < len(r.batch.Columns) && r.batch.Columns[pos][r.row] != nil
+}
+
+func (r *batchRow) Values() []btuple.Elem {
+	return r.batch.Tuple(r.row).Values()
+}
+
+// BatchExecutor is the vectorized counterpart of Executor, it yields a batch of rows per call.
+type BatchExecutor interface {
+	Init()
+	// NextBatch resets the batch and fills it with the next rows, up to its capacity.
+	// It returns false if there are no more rows, the batch is never empty otherwise.
+	NextBatch(ctx context.Context, batch *Batch) (bool, error)
+	Close() error
+}
+
+// batchAdapter yields the tuples of an Executor in batches.
+type batchAdapter struct {
+	child Executor
+}
+
+func (a *batchAdapter) Init() {
+	a.child.Init()
+}
+
+func (a *batchAdapter) NextBatch(ctx context.Context, batch *Batch) (bool, error) {
+	batch.Reset()
+	for !batch.Full() {
+		var (
+			tuple btuple.Modifier
+			rid   primitive.ObjectID
+		)
+		next, err := a.child.Next(ctx, &tuple, &rid)
+		if err != nil {
+			return false, err
+		}
+		if !next {
+			break
+		}
+		// the same as Execute skips
+		if tuple == nil && rid.IsEmpty() {
+			continue
+		}
+		if tuple == nil {
+			tuple = btuple.NewModifier(nil)
+		}
+		batch.Append(tuple, rid)
+	}
+	return batch.Len() > 0, nil
+}
+
+func (a *batchAdapter) Close() error {
+	return a.child.Close()
+}
+
+// NewBatchAdapter returns a BatchExecutor yields the tuples of child in batches.
+func NewBatchAdapter(child Executor) BatchExecutor {
+	return &batchAdapter{child: child}
+}
+
+// tupleAdapter yields the rows of a BatchExecutor one at a time.
+type tupleAdapter struct {
+	child BatchExecutor
+	batch *Batch
+	row   int
+}
+
+func (a *tupleAdapter) Init() {
+	a.child.Init()
+	a.batch.Reset()
+	a.row = 0
+}
+
+func (a *tupleAdapter) Next(ctx context.Context, tuple *btuple.Modifier, rid *primitive.ObjectID) (bool, error) {
+	if a.row >= a.batch.Len() {
+		next, err := a.child.NextBatch(ctx, a.batch)
+		if !next || err != nil {
+			return false, err
+		}
+		a.row = 0
+	}
+	*tuple, *rid = a.batch.Tuple(a.row), a.batch.Rids[a.row]
+	a.row++
+	return true, nil
+}
+
+func (a *tupleAdapter) Close() error {
+	return a.child.Close()
+}
+
+// NewTupleAdapter returns an Executor yields the rows of child one at a time, read in batches of size.
+func NewTupleAdapter(child BatchExecutor, size int) Executor {
+	return &tupleAdapter{child: child, batch: NewBatch(size)}
+}
+
+// ExecuteBatch runs the executor, and returns its rows as Execute does.
+func ExecuteBatch(executor BatchExecutor, ctx context.Context, size int) (result []btuple.Modifier, ids []primitive.ObjectID, err error) {
+	if err = canceledError(ctx); err != nil {
+		return
+	}
+	executor.Init()
+	batch := NewBatch(size)
+	for {
+		var next bool
+		if next, err = executor.NextBatch(ctx, batch); err != nil {
+			executor.Close()
+			err = asCanceled(err)
+			return
+		}
+		if !next {
+			break
+		}
+		for i := 0; i < batch.Len(); i++ {
+			result = append(result, batch.Tuple(i))
+		}
+		ids = append(ids, batch.Rids...)
+	}
+	err = executor.Close()
+	return
+}
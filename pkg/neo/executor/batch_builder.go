@@ -0,0 +1,56 @@
+package executor
+
+import (
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+)
+
+// batchExecutorBuilder builds the batch executors of the plans, the plans without a batch
+// executor are run by their executors through a batch adapter.
+type batchExecutorBuilder struct {
+	executorBuilder
+}
+
+func NewBatchExecutorBuilder(ctx session.Context) *batchExecutorBuilder {
+	return &batchExecutorBuilder{
+		executorBuilder: executorBuilder{ctx: ctx},
+	}
+}
+
+func (b *batchExecutorBuilder) Build(p plan.AbstractPlan) BatchExecutor {
+	return b.buildBatch(p)
+}
+
+func (b *batchExecutorBuilder) buildBatch(p plan.AbstractPlan) BatchExecutor {
+	switch v := p.(type) {
+	// an index scan plan is a seq scan plan as well
+	case plan.IndexScanPlan:
+		exec, err := NewBatchIndexScanExecutor(b.ctx, v)
+		if b.catchErr(err) {
+			return nil
+		}
+		return exec
+	case plan.SeqScanPlan:
+		exec, err := NewBatchSeqScanExecutor(b.ctx, v)
+		if b.catchErr(err) {
+			return nil
+		}
+		return exec
+	case plan.LimitPlan:
+		if !v.HasChildren() {
+			b.catchErr(ErrMissChildPlan)
+			return nil
+		}
+		childExec, err := b.buildBatch(v.GetChildAt(0)), b.err
+		if err != nil {
+			return nil
+		}
+		return NewBatchLimitExecutor(b.ctx, v, childExec)
+	default:
+		exec, err := b.build(p), b.err
+		if err != nil {
+			return nil
+		}
+		return NewBatchAdapter(exec)
+	}
+}
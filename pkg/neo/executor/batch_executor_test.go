@@ -0,0 +1,223 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"github.com/casbin-mesh/neo/pkg/expression"
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/neo/utils"
+	"github.com/casbin-mesh/neo/pkg/parser"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+// openBatchTestDB returns the db with a basic model of rows policies, the subjects
+// take turns among ten users, and the session reads them.
+func openBatchTestDB(tb testing.TB, p string, rows int) (*mockDB, session.Context, *model.DBInfo) {
+	mockDb := OpenMockDB(tb, p)
+	info, err := utils.CompileModelFromString("batch", basicModelText)
+	assert.Nil(tb, err)
+	table := info.TableInfo[0]
+	sc := mockDb.NewTxnAt(1, true)
+	mockDb.CreateDB(tb, sc, info)
+	assert.Nil(tb, sc.CommitTxn(context.TODO(), 2))
+	assert.Nil(tb, mockDb.WaitForMark(context.TODO(), 2))
+
+	ts := uint64(2)
+	for start := 0; start < rows; start += 1000 {
+		var rules []value.Values
+		for i := start; i < start+1000 && i < rows; i++ {
+			rules = append(rules, newStringValues(fmt.Sprintf("user%d", i%10), fmt.Sprintf("data%d", i), "read"))
+		}
+		sc = mockDb.NewTxnAt(ts, true)
+		_, _, err = mockDb.InsertTuples(tb, sc, info.ID, table.ID, rules)
+		assert.Nil(tb, err)
+		assert.Nil(tb, sc.CommitTxn(context.TODO(), ts+1))
+		assert.Nil(tb, mockDb.WaitForMark(context.TODO(), ts+1))
+		ts++
+	}
+	return mockDb, mockDb.NewTxnAt(ts, false), info
+}
+
+func TestBatchExecutor(t *testing.T) {
+	p := "./__test_tmp__/batch_exec"
+	mockDb, sc, info := openBatchTestDB(t, p, 100)
+	defer func() {
+		sc.RollbackTxn(context.TODO())
+		mockDb.Close()
+		os.RemoveAll(p)
+	}()
+	table := info.TableInfo[0]
+	subIndex := table.Indices[0]
+	assert.Equal(t, "sub", subIndex.Columns[0].ColName.L)
+
+	// the batch executors yield the same rows as the tuple ones
+	assertSame := func(t *testing.T, p plan.AbstractPlan, rows int) {
+		builder := NewExecutorBuilder(sc)
+		exec, err := builder.Build(p), builder.Error()
+		assert.Nil(t, err)
+		expected, expectedIds, err := Execute(exec, context.TODO())
+		assert.Nil(t, err)
+		assert.Len(t, expected, rows)
+
+		for _, size := range []int{1, 7, DefaultBatchSize} {
+			batchBuilder := NewBatchExecutorBuilder(sc)
+			batchExec, err := batchBuilder.Build(p), batchBuilder.Error()
+			assert.Nil(t, err)
+			result, ids, err := ExecuteBatch(batchExec, context.TODO(), size)
+			assert.Nil(t, err)
+			assert.Equal(t, expected, result)
+			assert.Equal(t, expectedIds, ids)
+
+			batchExec, err = batchBuilder.Build(p), batchBuilder.Error()
+			assert.Nil(t, err)
+			result, ids, err = Execute(NewTupleAdapter(batchExec, size), context.TODO())
+			assert.Nil(t, err)
+			assert.Equal(t, expected, result)
+			assert.Equal(t, expectedIds, ids)
+		}
+	}
+	predicate := func(text string) (expression.Expression, ast.EvaluateCtx) {
+		expr, accessor := expression.NewExpression(parser.MustParseFromString(text))
+		ctx := ast.NewContext()
+		ctx.AddAccessor("p", accessor)
+		return expr, ctx
+	}
+	subPrefix := func(sub string) []byte {
+		return codec.IndexEntryPrefix(subIndex.ID, [][]byte{codec.EncodeCmpValue(value.NewStringValue(sub))})
+	}
+
+	t.Run("seq scan", func(t *testing.T) {
+		assertSame(t, plan.NewSeqScanPlan(table, nil, nil, info.ID, table.ID), 100)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		expr, ctx := predicate(`p.sub == "user3" && p.act == "read"`)
+		assertSame(t, plan.NewSeqScanPlan(table, expr, ctx, info.ID, table.ID), 10)
+		expr, ctx = predicate(`p.sub == "nobody"`)
+		assertSame(t, plan.NewSeqScanPlan(table, expr, ctx, info.ID, table.ID), 0)
+	})
+
+	t.Run("index scan", func(t *testing.T) {
+		schema := model.NewIndexSchemaReader(table, 0)
+		assertSame(t, plan.NewIndexScanPlan(schema, subPrefix("user3"), nil, nil, info.ID, table.ID), 10)
+		expr, ctx := predicate(`p.sub == "user3"`)
+		assertSame(t, plan.NewIndexScanPlan(schema, codec.IndexEntryPrefix(subIndex.ID, nil), expr, ctx, info.ID, table.ID), 10)
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		scan := plan.NewSeqScanPlan(table, nil, nil, info.ID, table.ID)
+		for _, limit := range []int{0, 1, 9, 50, 100, 200} {
+			assertSame(t, plan.NewLimitPlan([]plan.AbstractPlan{scan}, limit), minInt(limit, 100))
+		}
+	})
+
+	t.Run("adapter", func(t *testing.T) {
+		// the projection runs through a batch adapter
+		columns, err := plan.NewColumnsProjectionPlan(plan.NewSeqScanPlan(table, nil, nil, info.ID, table.ID), "obj")
+		assert.Nil(t, err)
+		assertSame(t, plan.NewLimitPlan([]plan.AbstractPlan{columns}, 30), 30)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		builder := NewBatchExecutorBuilder(sc)
+		exec, err := builder.Build(plan.NewLimitPlan(nil, 10)), builder.Error()
+		assert.Nil(t, exec)
+		assert.Equal(t, ErrMissChildPlan, err)
+	})
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+const benchmarkRows = 10000
+
+// benchmarkScan runs the seq scans of the table of benchmarkRows policies, without and with a predicate.
+func benchmarkScan(b *testing.B, run func(sc session.Context, p plan.AbstractPlan) int) {
+	p := "./__test_tmp__/batch_bench"
+	mockDb, sc, info := openBatchTestDB(b, p, benchmarkRows)
+	defer func() {
+		sc.RollbackTxn(context.TODO())
+		mockDb.Close()
+		os.RemoveAll(p)
+	}()
+	table := info.TableInfo[0]
+	expr, accessor := expression.NewExpression(parser.MustParseFromString(`p.sub == "user3"`))
+	ctx := ast.NewContext()
+	ctx.AddAccessor("p", accessor)
+
+	for _, c := range []struct {
+		name string
+		plan plan.AbstractPlan
+		rows int
+	}{
+		{"scan", plan.NewSeqScanPlan(table, nil, nil, info.ID, table.ID), benchmarkRows},
+		{"filter", plan.NewSeqScanPlan(table, expr, ctx, info.ID, table.ID), benchmarkRows / 10},
+	} {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			start := time.Now()
+			for i := 0; i < b.N; i++ {
+				if rows := run(sc, c.plan); rows != c.rows {
+					b.Fatalf("got %d rows", rows)
+				}
+			}
+			b.ReportMetric(float64(time.Since(start).Nanoseconds())/float64(b.N*benchmarkRows), "ns/row")
+		})
+	}
+}
+
+func BenchmarkExecute(b *testing.B) {
+	benchmarkScan(b, func(sc session.Context, p plan.AbstractPlan) int {
+		exec := NewExecutorBuilder(sc).Build(p)
+		result, _, err := Execute(exec, context.TODO())
+		if err != nil {
+			b.Fatal(err)
+		}
+		return len(result)
+	})
+}
+
+func BenchmarkExecuteBatch(b *testing.B) {
+	benchmarkScan(b, func(sc session.Context, p plan.AbstractPlan) int {
+		exec := NewBatchExecutorBuilder(sc).Build(p)
+		result, _, err := ExecuteBatch(exec, context.TODO(), DefaultBatchSize)
+		if err != nil {
+			b.Fatal(err)
+		}
+		return len(result)
+	})
+}
+
+// BenchmarkNextBatch reads the rows in batches without copying them out.
+func BenchmarkNextBatch(b *testing.B) {
+	batch := NewBatch(DefaultBatchSize)
+	benchmarkScan(b, func(sc session.Context, p plan.AbstractPlan) (rows int) {
+		exec := NewBatchExecutorBuilder(sc).Build(p)
+		exec.Init()
+		for {
+			next, err := exec.NextBatch(context.TODO(), batch)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if !next {
+				break
+			}
+			rows += batch.Len()
+		}
+		exec.Close()
+		return
+	})
+}
@@ -0,0 +1,68 @@
+package executor
+
+import (
+	"context"
+	"github.com/casbin-mesh/neo/pkg/expression"
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	executorExpr "github.com/casbin-mesh/neo/pkg/neo/executor/expression"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/primitive/bschema"
+)
+
+type batchFilterExecutor struct {
+	baseExecutor
+	child     BatchExecutor
+	predicate expression.Expression
+	evalCtx   ast.EvaluateCtx
+	schema    bschema.Reader
+}
+
+func (f *batchFilterExecutor) Init() {
+	f.child.Init()
+}
+
+func (f *batchFilterExecutor) keep(batch *Batch) func(i int) (bool, error) {
+	// the row is reused by the rows of the batch
+	row := &batchRow{batch: batch}
+	return func(i int) (bool, error) {
+		row.row = i
+		res, err := f.predicate.Evaluate(f.GetSessionCtx(), f.evalCtx, row, f.schema)
+		if err != nil {
+			return false, err
+		}
+		return executorExpr.TryGetBool(res)
+	}
+}
+
+// NextBatch yields the rows of the child batches the predicate holds for,
+// the child batches are read until one of them keeps a row.
+func (f *batchFilterExecutor) NextBatch(ctx context.Context, batch *Batch) (bool, error) {
+	for {
+		next, err := f.child.NextBatch(ctx, batch)
+		if !next || err != nil {
+			return false, err
+		}
+		if err = batch.Retain(f.keep(batch)); err != nil {
+			return false, err
+		}
+		if batch.Len() > 0 {
+			return true, nil
+		}
+	}
+}
+
+func (f *batchFilterExecutor) Close() error {
+	return f.child.Close()
+}
+
+// NewBatchFilterExecutor returns the executor yields the rows of child the predicate holds for,
+// the rows are evaluated by the schema.
+func NewBatchFilterExecutor(ctx session.Context, child BatchExecutor, predicate expression.Expression, evalCtx ast.EvaluateCtx, schema bschema.Reader) BatchExecutor {
+	return &batchFilterExecutor{
+		baseExecutor: newBaseExecutor(ctx),
+		child:        child,
+		predicate:    predicate,
+		evalCtx:      evalCtx,
+		schema:       schema,
+	}
+}
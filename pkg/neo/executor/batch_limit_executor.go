@@ -0,0 +1,47 @@
+package executor
+
+import (
+	"context"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+)
+
+type batchLimitExecutor struct {
+	baseExecutor
+	limitPlan plan.LimitPlan
+	child     BatchExecutor
+	count     int
+}
+
+func (l *batchLimitExecutor) Init() {
+	l.child.Init()
+	l.count = 0
+}
+
+func (l *batchLimitExecutor) NextBatch(ctx context.Context, batch *Batch) (bool, error) {
+	if l.count >= l.limitPlan.Limit() {
+		batch.Reset()
+		return false, nil
+	}
+	next, err := l.child.NextBatch(ctx, batch)
+	if !next || err != nil {
+		return false, err
+	}
+	if rest := l.limitPlan.Limit() - l.count; batch.Len() > rest {
+		batch.Truncate(rest)
+	}
+	l.count += batch.Len()
+	return true, nil
+}
+
+func (l *batchLimitExecutor) Close() error {
+	return l.child.Close()
+}
+
+func NewBatchLimitExecutor(ctx session.Context, limitPlan plan.LimitPlan, child BatchExecutor) BatchExecutor {
+	return &batchLimitExecutor{
+		baseExecutor: newBaseExecutor(ctx),
+		limitPlan:    limitPlan,
+		child:        child,
+	}
+}
@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"context"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/primitive"
+)
+
+type batchSeqScanExecutor struct {
+	*seqScanExecutor
+}
+
+func (s *batchSeqScanExecutor) NextBatch(ctx context.Context, batch *Batch) (bool, error) {
+	batch.Reset()
+	if err := canceledError(ctx); err != nil {
+		return false, err
+	}
+	for !batch.Full() {
+		var rid primitive.ObjectID
+		tuple, err := s.nextRow(&rid)
+		if err != nil {
+			return false, err
+		}
+		if tuple == nil {
+			break
+		}
+		batch.Append(tuple, rid)
+	}
+	return batch.Len() > 0, nil
+}
+
+// NewBatchSeqScanExecutor returns the batch version of the seq scan executor,
+// the predicate of the plan is evaluated by a batch filter.
+func NewBatchSeqScanExecutor(ctx session.Context, scanPlan plan.SeqScanPlan) (BatchExecutor, error) {
+	exec, err := NewSeqScanExecutor(ctx, scanPlan)
+	if err != nil {
+		return nil, err
+	}
+	var scan BatchExecutor = &batchSeqScanExecutor{seqScanExecutor: exec.(*seqScanExecutor)}
+	if scanPlan.Predicate() != nil {
+		scan = NewBatchFilterExecutor(ctx, scan, scanPlan.Predicate(), scanPlan.GetEvalCtx(), scanPlan.OutputSchema())
+	}
+	return scan, nil
+}
+
+type batchIndexScanExecutor struct {
+	*indexScanExecutor
+}
+
+func (i *batchIndexScanExecutor) NextBatch(ctx context.Context, batch *Batch) (bool, error) {
+	batch.Reset()
+	if err := canceledError(ctx); err != nil {
+		return false, err
+	}
+	for !batch.Full() {
+		key, val, err := i.nextEntry()
+		if err != nil {
+			return false, err
+		}
+		if key == nil {
+			break
+		}
+		tuple, rid, err := codec.DecodeIndexEntry(i.indexInfo, key, val)
+		if err != nil {
+			return false, err
+		}
+		batch.Append(tuple, rid)
+	}
+	return batch.Len() > 0, nil
+}
+
+// NewBatchIndexScanExecutor returns the batch version of the index scan executor,
+// the predicate of the plan is evaluated by a batch filter.
+func NewBatchIndexScanExecutor(ctx session.Context, scanPlan plan.IndexScanPlan) (BatchExecutor, error) {
+	exec, err := NewIndexScanExecutor(ctx, scanPlan)
+	if err != nil {
+		return nil, err
+	}
+	var scan BatchExecutor = &batchIndexScanExecutor{indexScanExecutor: exec.(*indexScanExecutor)}
+	if scanPlan.Predicate() != nil {
+		scan = NewBatchFilterExecutor(ctx, scan, scanPlan.Predicate(), scanPlan.GetEvalCtx(), scanPlan.OutputSchema())
+	}
+	return scan, nil
+}
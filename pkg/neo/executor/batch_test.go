@@ -0,0 +1,119 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newTestTuple(values ...string) btuple.Modifier {
+	elems := make([]btuple.Elem, 0, len(values))
+	for _, v := range values {
+		elems = append(elems, btuple.Elem(v))
+	}
+	return btuple.NewModifier(elems)
+}
+
+func TestBatch(t *testing.T) {
+	batch := NewBatch(3)
+	assert.Equal(t, 3, batch.Cap())
+	rids := []primitive.ObjectID{primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()}
+	batch.Append(newTestTuple("alice", "data1"), rids[0])
+	// the columns are widened by a longer row
+	batch.Append(newTestTuple("bob", "data2", "write"), rids[1])
+	batch.Append(newTestTuple("carol", "", "read"), rids[2])
+	assert.True(t, batch.Full())
+	assert.Equal(t, 3, len(batch.Columns))
+	assert.Equal(t, []btuple.Elem{btuple.Elem("alice"), btuple.Elem("bob"), btuple.Elem("carol")}, batch.Columns[0])
+
+	// the missing values of a shorter row are left out
+	assert.Equal(t, newTestTuple("alice", "data1"), batch.Tuple(0))
+	assert.Equal(t, newTestTuple("carol", "", "read"), batch.Tuple(2))
+	row := batch.Row(0)
+	assert.Equal(t, btuple.Elem("data1"), row.ValueAt(1))
+	assert.True(t, row.Occupied(1))
+	assert.False(t, row.Occupied(2))
+
+	assert.Nil(t, batch.Retain(func(i int) (bool, error) {
+		return i != 1, nil
+	}))
+	assert.Equal(t, 2, batch.Len())
+	assert.Equal(t, []primitive.ObjectID{rids[0], rids[2]}, batch.Rids)
+	assert.Equal(t, newTestTuple("carol", "", "read"), batch.Tuple(1))
+
+	errFoo := errors.New("foo")
+	assert.Equal(t, errFoo, batch.Retain(func(i int) (bool, error) {
+		return false, errFoo
+	}))
+
+	batch.Reset()
+	assert.Equal(t, 0, batch.Len())
+	assert.False(t, batch.Full())
+	assert.Equal(t, DefaultBatchSize, NewBatch(0).Cap())
+}
+
+type mockTuplesExecutor struct {
+	tuples []btuple.Modifier
+	rids   []primitive.ObjectID
+	cursor int
+	closed bool
+}
+
+func (m *mockTuplesExecutor) Init() {
+	m.cursor = 0
+}
+
+func (m *mockTuplesExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid *primitive.ObjectID) (bool, error) {
+	if m.cursor >= len(m.tuples) {
+		return false, nil
+	}
+	*tuple, *rid = m.tuples[m.cursor], m.rids[m.cursor]
+	m.cursor++
+	return true, nil
+}
+
+func (m *mockTuplesExecutor) Close() error {
+	m.closed = true
+	return nil
+}
+
+func TestBatchAdapter(t *testing.T) {
+	child := &mockTuplesExecutor{}
+	for i := 0; i < 10; i++ {
+		child.tuples = append(child.tuples, newTestTuple("alice", string(rune('a'+i))))
+		child.rids = append(child.rids, primitive.NewObjectID())
+	}
+
+	// the tuples are read in batches and back one at a time
+	for _, size := range []int{1, 3, 10, 64} {
+		exec := NewBatchAdapter(child)
+		result, ids, err := ExecuteBatch(exec, context.TODO(), size)
+		assert.Nil(t, err)
+		assert.Equal(t, child.tuples, result)
+		assert.Equal(t, child.rids, ids)
+		assert.True(t, child.closed)
+
+		result, ids, err = Execute(NewTupleAdapter(NewBatchAdapter(child), size), context.TODO())
+		assert.Nil(t, err)
+		assert.Equal(t, child.tuples, result)
+		assert.Equal(t, child.rids, ids)
+	}
+
+	batch := NewBatch(4)
+	exec := NewBatchAdapter(child)
+	exec.Init()
+	var lens []int
+	for {
+		next, err := exec.NextBatch(context.TODO(), batch)
+		assert.Nil(t, err)
+		if !next {
+			break
+		}
+		lens = append(lens, batch.Len())
+	}
+	assert.Equal(t, []int{4, 4, 2}, lens)
+	assert.Equal(t, 0, batch.Len())
+}
@@ -10,6 +10,8 @@ import (
 type executorBuilder struct {
 	ctx session.Context
 	err error
+	// instrument wraps the executor of every plan if it's set, e.g., by EXPLAIN ANALYZE
+	instrument func(p plan.AbstractPlan, exec Executor) Executor
 }
 
 func (b *executorBuilder) ResetError() {
@@ -31,6 +33,14 @@ func (b *executorBuilder) Build(p plan.AbstractPlan) Executor {
 }
 
 func (b *executorBuilder) build(p plan.AbstractPlan) Executor {
+	exec := b.buildPlan(p)
+	if exec != nil && b.instrument != nil {
+		return b.instrument(p, exec)
+	}
+	return exec
+}
+
+func (b *executorBuilder) buildPlan(p plan.AbstractPlan) Executor {
 	switch v := p.(type) {
 	case *plan.TableRowIdScan:
 		return b.buildTableRowIdScanPlan(v)
@@ -40,6 +50,8 @@ func (b *executorBuilder) build(p plan.AbstractPlan) Executor {
 		return b.buildUpdatePlan(v)
 	case plan.IndexScanPlan:
 		return b.buildIndexScanPlan(v)
+	case plan.IndexRangeScanPlan:
+		return b.buildIndexRangeScanPlan(v)
 	case plan.SeqScanPlan:
 		return b.buildSeqScanPlan(v)
 	case plan.DeletePlan:
@@ -52,6 +64,19 @@ func (b *executorBuilder) build(p plan.AbstractPlan) Executor {
 		return b.buildMultiIndexScan(v)
 	case plan.ConstPlan:
 		return b.buildConstPlan(v)
+	case plan.EnforcePlan:
+		return b.buildEnforcePlan(v)
+	case plan.ProjectionPlan:
+		return b.buildProjectionPlan(v)
+	case plan.AggregationPlan:
+		return b.buildAggregationPlan(v)
+	case plan.HashJoinPlan:
+		return b.buildHashJoinPlan(v)
+	case plan.IndexNestedLoopJoinPlan:
+		return b.buildIndexNestedLoopJoinPlan(v)
+	// the other plans of a table may satisfy it
+	case plan.IndexUnionPlan:
+		return b.buildIndexUnionPlan(v)
 	default:
 		b.err = fmt.Errorf("unknown Plan %T", p)
 		return nil
@@ -157,20 +182,160 @@ func (b *executorBuilder) buildIndexScanPlan(v plan.IndexScanPlan) Executor {
 	return exec
 }
 
+func (b *executorBuilder) buildIndexRangeScanPlan(v plan.IndexRangeScanPlan) Executor {
+	exec, err := NewIndexRangeScanExecutor(b.ctx, v)
+	if b.catchErr(err) {
+		return nil
+	}
+	return exec
+}
+
 func (b *executorBuilder) buildMultiIndexScan(v plan.MultiIndexScan) Executor {
 	if len(v.GetChildren()) != 2 {
 		b.catchErr(ErrMissChildPlan)
 		return nil
 	}
-	leftExec, err := b.build(v.GetChildAt(0)), b.err
+	parallel, ok := v.(plan.ParallelMultiIndexScan)
+	// the children of a parallel scan are read concurrently on the snapshots of the session if it has them
+	var snapshots []session.Context
+	if ok {
+		left, lok := b.ctx.Snapshot()
+		right, rok := b.ctx.Snapshot()
+		if lok && rok {
+			snapshots = []session.Context{left, right}
+		}
+	}
+	leftExec, err := b.buildOn(snapshots, 0, v.GetChildAt(0)), b.err
 	if err != nil {
 		return nil
 	}
-	rightExec, err := b.build(v.GetChildAt(1)), b.err
+	rightExec, err := b.buildOn(snapshots, 1, v.GetChildAt(1)), b.err
+	if err != nil {
+		return nil
+	}
+	var exec Executor
+	if ok {
+		exec, err = NewParallelMultiIndexScanExecutor(b.ctx, parallel, leftExec, rightExec, snapshots...)
+	} else {
+		exec, err = NewMultiIndexScanExecutor(b.ctx, v, leftExec, rightExec)
+	}
+	if b.catchErr(err) {
+		return nil
+	}
+	return exec
+}
+
+// buildOn builds the plan on the i-th session of the sessions, or on the session of the builder if there are none.
+func (b *executorBuilder) buildOn(sessions []session.Context, i int, p plan.AbstractPlan) Executor {
+	if len(sessions) == 0 {
+		return b.build(p)
+	}
+	parent := b.ctx
+	b.ctx = sessions[i]
+	defer func() {
+		b.ctx = parent
+	}()
+	return b.build(p)
+}
+
+func (b *executorBuilder) buildIndexUnionPlan(v plan.IndexUnionPlan) Executor {
+	if !v.HasChildren() {
+		b.catchErr(ErrMissChildPlan)
+		return nil
+	}
+	children := make([]Executor, 0, len(v.GetChildren()))
+	for _, child := range v.GetChildren() {
+		exec, err := b.build(child), b.err
+		if err != nil {
+			return nil
+		}
+		children = append(children, exec)
+	}
+	exec, err := NewIndexUnionExecutor(b.ctx, v, children)
+	if b.catchErr(err) {
+		return nil
+	}
+	return exec
+}
+
+func (b *executorBuilder) buildEnforcePlan(p plan.EnforcePlan) Executor {
+	if !p.HasChildren() {
+		b.catchErr(ErrMissChildPlan)
+		return nil
+	}
+	childExec, err := b.build(p.GetChildAt(0)), b.err
+	if err != nil {
+		return nil
+	}
+	exec, err := NewEnforceExecutor(b.ctx, p, childExec)
+	if b.catchErr(err) {
+		return nil
+	}
+	return exec
+}
+
+func (b *executorBuilder) buildProjectionPlan(p plan.ProjectionPlan) Executor {
+	if !p.HasChildren() {
+		b.catchErr(ErrMissChildPlan)
+		return nil
+	}
+	childExec, err := b.build(p.GetChildAt(0)), b.err
+	if err != nil {
+		return nil
+	}
+	exec, err := NewProjectionExecutor(b.ctx, p, childExec)
+	if b.catchErr(err) {
+		return nil
+	}
+	return exec
+}
+
+func (b *executorBuilder) buildAggregationPlan(p plan.AggregationPlan) Executor {
+	if !p.HasChildren() {
+		b.catchErr(ErrMissChildPlan)
+		return nil
+	}
+	childExec, err := b.build(p.GetChildAt(0)), b.err
+	if err != nil {
+		return nil
+	}
+	exec, err := NewAggregationExecutor(b.ctx, p, childExec)
+	if b.catchErr(err) {
+		return nil
+	}
+	return exec
+}
+
+func (b *executorBuilder) buildHashJoinPlan(p plan.HashJoinPlan) Executor {
+	if len(p.GetChildren()) != 2 {
+		b.catchErr(ErrMissChildPlan)
+		return nil
+	}
+	left, err := b.build(p.GetChildAt(0)), b.err
+	if err != nil {
+		return nil
+	}
+	right, err := b.build(p.GetChildAt(1)), b.err
+	if err != nil {
+		return nil
+	}
+	exec, err := NewHashJoinExecutor(b.ctx, p, left, right)
+	if b.catchErr(err) {
+		return nil
+	}
+	return exec
+}
+
+func (b *executorBuilder) buildIndexNestedLoopJoinPlan(p plan.IndexNestedLoopJoinPlan) Executor {
+	if !p.HasChildren() {
+		b.catchErr(ErrMissChildPlan)
+		return nil
+	}
+	outer, err := b.build(p.GetChildAt(0)), b.err
 	if err != nil {
 		return nil
 	}
-	exec, err := NewMultiIndexScanExecutor(b.ctx, v, leftExec, rightExec)
+	exec, err := NewIndexNestedLoopJoinExecutor(b.ctx, p, outer)
 	if b.catchErr(err) {
 		return nil
 	}
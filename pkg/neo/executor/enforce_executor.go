@@ -0,0 +1,232 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/casbin-mesh/neo/pkg/expression"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	executorExpr "github.com/casbin-mesh/neo/pkg/neo/executor/expression"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/bschema"
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+)
+
+var (
+	ErrUnsupportedEffectPolicy = errors.New("unsupported effect policy")
+	ErrInvalidRequest          = errors.New("request doesn't match the request definition")
+	ErrInvalidPriority         = errors.New("priority isn't an integer")
+)
+
+type effect uint8
+
+const (
+	indeterminate effect = iota
+	allow
+	deny
+)
+
+// enforceExecutor yields a single tuple: {1} if the request is allowed, otherwise {0};
+// and the row id of the policy that decided the result, it's empty if there is no such one.
+type enforceExecutor struct {
+	baseExecutor
+	enforcePlan   plan.EnforcePlan
+	childExecutor Executor
+	tableInfo     *model.TableInfo
+	predicate     expression.Expression
+	eftIdx        int
+	priorityIdx   int
+	done          bool
+	cancel        canceler
+}
+
+func (e *enforceExecutor) Init() {
+	e.childExecutor.Init()
+	e.done = false
+}
+
+func (e *enforceExecutor) Close() error {
+	return e.childExecutor.Close()
+}
+
+func (e *enforceExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid *primitive.ObjectID) (bool, error) {
+	if e.done {
+		return false, nil
+	}
+	e.done = true
+
+	allowed, matched, err := e.enforce(ctx)
+	if err != nil {
+		return false, err
+	}
+	if allowed {
+		*tuple = btuple.NewModifier([]btuple.Elem{{1}})
+	} else {
+		*tuple = btuple.NewModifier([]btuple.Elem{{0}})
+	}
+	*rid = matched
+	return true, nil
+}
+
+func (e *enforceExecutor) enforce(ctx context.Context) (allowed bool, matched primitive.ObjectID, err error) {
+	var (
+		policy   btuple.Modifier
+		policyId primitive.ObjectID
+		next     bool
+		found    bool
+		priority = math.MaxInt
+	)
+	effectPolicy := e.enforcePlan.Matcher().EffectPolicy
+	for {
+		if err = e.cancel.check(ctx); err != nil {
+			return
+		}
+		if next, err = e.childExecutor.Next(ctx, &policy, &policyId); err != nil || !next {
+			break
+		}
+		var ok bool
+		if ok, err = e.match(policy); err != nil {
+			return
+		} else if !ok {
+			continue
+		}
+
+		eft := e.effectOf(policy)
+		switch effectPolicy {
+		case model.AllowOverride:
+			if eft == allow {
+				return true, policyId, nil
+			}
+		case model.DenyOverride:
+			if eft == deny {
+				return false, policyId, nil
+			}
+		case model.AllowAndDeny:
+			if eft == deny {
+				return false, policyId, nil
+			}
+			if eft == allow && !found {
+				found, matched = true, policyId
+			}
+		case model.Priority:
+			if eft == indeterminate {
+				continue
+			}
+			// without priorities, the policies are prioritized by their order
+			if e.priorityIdx < 0 {
+				return eft == allow, policyId, nil
+			}
+			var p int
+			if p, err = e.priorityOf(policy); err != nil {
+				return
+			}
+			if p < priority {
+				priority, allowed, matched = p, eft == allow, policyId
+			}
+		}
+	}
+	if err != nil {
+		return
+	}
+
+	switch effectPolicy {
+	case model.AllowOverride:
+		return false, primitive.ObjectID{}, nil
+	case model.DenyOverride:
+		return true, primitive.ObjectID{}, nil
+	case model.AllowAndDeny:
+		return found, matched, nil
+	default:
+		return allowed, matched, nil
+	}
+}
+
+// priorityOf returns the priority of the policy, the tables compiled before the priorities
+// are stored as integers hold them as strings.
+func (e *enforceExecutor) priorityOf(policy btuple.Reader) (int, error) {
+	elem := policy.ValueAt(e.priorityIdx)
+	if e.tableInfo.Columns[e.priorityIdx].Tp == bsontype.String {
+		return strconv.Atoi(string(elem))
+	}
+	v := codec.DecodeValue(elem, e.tableInfo.Columns[e.priorityIdx].Tp)
+	if v.Type() != bsontype.Int32 && v.Type() != bsontype.Int64 {
+		return 0, ErrInvalidPriority
+	}
+	return int(v.GetInt64()), nil
+}
+
+func (e *enforceExecutor) match(policy btuple.Reader) (bool, error) {
+	if e.predicate == nil {
+		return true, nil
+	}
+	res, err := e.predicate.Evaluate(e.GetSessionCtx(), e.enforcePlan.GetEvalCtx(), policy, e.tableInfo)
+	if err != nil {
+		return false, err
+	}
+	return executorExpr.TryGetBool(res)
+}
+
+func (e *enforceExecutor) effectOf(policy btuple.Reader) effect {
+	if e.eftIdx < 0 {
+		return allow
+	}
+	value := codec.DecodeValue(policy.ValueAt(e.eftIdx), bsontype.String)
+	switch value.GetString() {
+	case model.AllowEffect:
+		return allow
+	case model.DenyEffect:
+		return deny
+	default:
+		return indeterminate
+	}
+}
+
+func NewEnforceExecutor(ctx session.Context, enforcePlan plan.EnforcePlan, child Executor) (Executor, error) {
+	matcher := enforcePlan.Matcher()
+	if matcher.EffectPolicy > model.Priority {
+		return nil, ErrUnsupportedEffectPolicy
+	}
+	request := enforcePlan.Request()
+	if len(request.Values()) != len(matcher.RequestFields) {
+		return nil, ErrInvalidRequest
+	}
+
+	dbInfo, err := ctx.GetCatalog().GetDBInfoByDBId(enforcePlan.DBOid())
+	if err != nil {
+		return nil, err
+	}
+	tableInfo, err := dbInfo.TableByLName(matcher.Policy.L)
+	if err != nil {
+		return nil, err
+	}
+
+	requestSchema := bschema.NewReaderWriter()
+	for _, field := range matcher.RequestFields {
+		requestSchema.Append(bsontype.String, []byte(field.L), nil)
+	}
+	var predicate expression.Expression
+	if enforcePlan.Predicate() != nil {
+		// the ast caches evaluated states, every executor requires its own copy.
+		var accessor *expression.TupleAccessor
+		predicate, accessor = expression.NewExpression(enforcePlan.Predicate().Clone())
+		evalCtx := enforcePlan.GetEvalCtx()
+		evalCtx.AddAccessor(matcher.Request.L, expression.NewTupleAccessor(request, requestSchema))
+		evalCtx.AddAccessor(matcher.Policy.L, accessor)
+	}
+
+	return &enforceExecutor{
+		baseExecutor:  newBaseExecutor(ctx),
+		enforcePlan:   enforcePlan,
+		childExecutor: child,
+		tableInfo:     tableInfo,
+		predicate:     predicate,
+		eftIdx:        tableInfo.Field(model.EffectColumnName),
+		priorityIdx:   tableInfo.Field(model.PriorityColumnName),
+	}, nil
+}
@@ -0,0 +1,195 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/neo/utils"
+	"github.com/casbin-mesh/neo/pkg/parser"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
+	"github.com/stretchr/testify/assert"
+)
+
+const enforceModelText = `[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act, eft
+
+[policy_effect]
+e = %s
+
+[matchers]
+m = r.sub == p.sub && r.obj == p.obj && r.act == p.act`
+
+func newStringValues(s ...string) value.Values {
+	values := make(value.Values, 0, len(s))
+	for _, v := range s {
+		values = append(values, value.NewStringValue(v))
+	}
+	return values
+}
+
+type enforceSet struct {
+	req      []string
+	expected bool
+	// matched is the index of the policy decided the result, -1 if there is no such one.
+	matched int
+}
+
+func runEnforceSets(t *testing.T, name, modelText string, policies []value.Values, sets []enforceSet) {
+	runEnforcePlans(t, name, modelText, policies, sets, func(info *model.DBInfo, child plan.AbstractPlan, request btuple.Reader) plan.EnforcePlan {
+		return plan.NewEnforcePlan([]plan.AbstractPlan{child}, info.MatcherInfo[0], request, ast.NewContext(), info.ID)
+	})
+}
+
+// runEnforcePlans runs the sets with the enforce plans built by newPlan over a sequential scan.
+func runEnforcePlans(t *testing.T, name, modelText string, policies []value.Values, sets []enforceSet, newPlan func(info *model.DBInfo, child plan.AbstractPlan, request btuple.Reader) plan.EnforcePlan) {
+	p := "./__test_tmp__/enforce_exec_" + name
+	mockDb := OpenMockDB(t, p)
+	defer func() {
+		mockDb.Close()
+		os.RemoveAll(p)
+	}()
+
+	info, err := utils.CompileModelFromString(name, modelText)
+	assert.Nil(t, err)
+	sc := mockDb.NewTxnAt(1, true)
+	mockDb.CreateDB(t, sc, info)
+	_, ids, err := mockDb.InsertTuples(t, sc, info.ID, info.TableInfo[0].ID, policies)
+	assert.Nil(t, err)
+	assert.Nil(t, sc.CommitTxn(context.TODO(), 2))
+	assert.Nil(t, mockDb.WaitForMark(context.TODO(), 2))
+
+	sc = mockDb.NewTxnAt(3, false)
+	for _, set := range sets {
+		elems := make([]btuple.Elem, 0, len(set.req))
+		for _, s := range set.req {
+			elems = append(elems, btuple.Elem(s))
+		}
+		builder := executorBuilder{ctx: sc}
+		seqScan := plan.NewSeqScanPlan(info.TableInfo[0], nil, nil, info.ID, info.TableInfo[0].ID)
+		exec, err := builder.Build(newPlan(info, seqScan, btuple.NewModifier(elems))), builder.Error()
+		assert.Nil(t, err)
+
+		result, rids, err := Execute(exec, context.TODO())
+		assert.Nil(t, err)
+		assert.Len(t, result, 1)
+		assert.Equal(t, set.expected, result[0].ValueAt(0)[0] == 1, set.req)
+		if set.matched >= 0 {
+			assert.Equal(t, ids[set.matched], rids[0], set.req)
+		} else {
+			assert.True(t, rids[0].IsEmpty(), set.req)
+		}
+	}
+}
+
+func TestEnforceExecutor_AllowOverride(t *testing.T) {
+	runEnforceSets(t, "allow_override", basicModelText, mockDBDataSet, []enforceSet{
+		{[]string{"alice", "data1", "read"}, true, 0},
+		{[]string{"bob", "data2", "write"}, true, 2},
+		{[]string{"alice", "data1", "write"}, false, -1},
+		{[]string{"bob", "data1", "read"}, false, -1},
+	})
+}
+
+func TestEnforceExecutor_DenyOverride(t *testing.T) {
+	runEnforceSets(t, "deny_override", fmtModel("!some(where (p.eft == deny))"), []value.Values{
+		newStringValues("alice", "data1", "read", "allow"),
+		newStringValues("alice", "data1", "write", "deny"),
+	}, []enforceSet{
+		{[]string{"alice", "data1", "read"}, true, -1},
+		{[]string{"alice", "data1", "write"}, false, 1},
+		{[]string{"bob", "data1", "write"}, true, -1},
+	})
+}
+
+func TestEnforceExecutor_AllowAndDeny(t *testing.T) {
+	runEnforceSets(t, "allow_and_deny", fmtModel("some(where (p.eft == allow)) && !some(where (p.eft == deny))"), []value.Values{
+		newStringValues("alice", "data1", "read", "allow"),
+		newStringValues("alice", "data1", "write", "allow"),
+		newStringValues("alice", "data1", "write", "deny"),
+	}, []enforceSet{
+		{[]string{"alice", "data1", "read"}, true, 0},
+		{[]string{"alice", "data1", "write"}, false, 2},
+		{[]string{"bob", "data1", "read"}, false, -1},
+	})
+}
+
+func TestEnforceExecutor_Priority(t *testing.T) {
+	priorityModel := `[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = priority, sub, obj, act, eft
+
+[policy_effect]
+e = priority(p.eft) || deny
+
+[matchers]
+m = r.sub == p.sub && r.obj == p.obj && r.act == p.act`
+	rule := func(priority int64, values ...string) value.Values {
+		return append(value.Values{value.NewInt64Value(priority)}, newStringValues(values...)...)
+	}
+	runEnforceSets(t, "priority", priorityModel, []value.Values{
+		rule(10, "alice", "data1", "read", "allow"),
+		rule(1, "alice", "data1", "read", "deny"),
+		rule(2, "alice", "data1", "write", "allow"),
+		rule(3, "alice", "data1", "write", "deny"),
+	}, []enforceSet{
+		{[]string{"alice", "data1", "read"}, false, 1},
+		{[]string{"alice", "data1", "write"}, true, 2},
+		{[]string{"bob", "data1", "write"}, false, -1},
+	})
+}
+
+func TestEnforceExecutor_Filtered(t *testing.T) {
+	// the filter replaces the predicate of the matcher
+	runEnforcePlans(t, "filtered", basicModelText, mockDBDataSet, []enforceSet{
+		{[]string{"alice", "data1", "read"}, true, 0},
+		{[]string{"bob", "data1", "read"}, true, 0},
+		{[]string{"bob", "data1", "write"}, true, 1},
+		{[]string{"alice", "data1", "delete"}, false, -1},
+	}, func(info *model.DBInfo, child plan.AbstractPlan, request btuple.Reader) plan.EnforcePlan {
+		filter := parser.MustParseFromString("r.act == p.act")
+		return plan.NewFilteredEnforcePlan([]plan.AbstractPlan{child}, info.MatcherInfo[0], filter, request, ast.NewContext(), info.ID)
+	})
+
+	// a nil filter matches every policy
+	runEnforcePlans(t, "filtered_nil", basicModelText, mockDBDataSet, []enforceSet{
+		{[]string{"bob", "data1", "delete"}, true, 0},
+	}, func(info *model.DBInfo, child plan.AbstractPlan, request btuple.Reader) plan.EnforcePlan {
+		return plan.NewFilteredEnforcePlan([]plan.AbstractPlan{child}, info.MatcherInfo[0], nil, request, ast.NewContext(), info.ID)
+	})
+}
+
+func TestEnforceExecutor_InvalidRequest(t *testing.T) {
+	p := "./__test_tmp__/enforce_exec_invalid"
+	mockDb := OpenMockDB(t, p)
+	defer func() {
+		mockDb.Close()
+		os.RemoveAll(p)
+	}()
+
+	info, err := utils.CompileModelFromString("invalid", basicModelText)
+	assert.Nil(t, err)
+	sc := mockDb.NewTxnAt(1, true)
+	mockDb.CreateDB(t, sc, info)
+
+	builder := executorBuilder{ctx: sc}
+	builder.Build(plan.NewEnforcePlan([]plan.AbstractPlan{
+		plan.NewSeqScanPlan(info.TableInfo[0], nil, nil, info.ID, info.TableInfo[0].ID),
+	}, info.MatcherInfo[0], btuple.NewModifier([]btuple.Elem{btuple.Elem("alice")}), ast.NewContext(), info.ID))
+	assert.Equal(t, ErrInvalidRequest, builder.Error())
+	sc.RollbackTxn(context.TODO())
+}
+
+func fmtModel(effect string) string {
+	return fmt.Sprintf(enforceModelText, effect)
+}
@@ -2,6 +2,7 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"github.com/casbin-mesh/neo/pkg/db"
 	"github.com/casbin-mesh/neo/pkg/neo/session"
 	"github.com/casbin-mesh/neo/pkg/primitive"
@@ -14,6 +15,45 @@ type Executor interface {
 	Close() error
 }
 
+// ErrCanceled is returned when the context of an execution is canceled or its deadline is exceeded.
+type ErrCanceled struct {
+	// Err is the error of the context, context.Canceled or context.DeadlineExceeded.
+	Err error
+}
+
+func (e *ErrCanceled) Error() string {
+	return "execution canceled: " + e.Err.Error()
+}
+
+func (e *ErrCanceled) Unwrap() error {
+	return e.Err
+}
+
+// canceledError returns the error of ctx as an ErrCanceled, or nil if ctx isn't done.
+func canceledError(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return &ErrCanceled{Err: err}
+	}
+	return nil
+}
+
+// checkInterval is the number of the rows a loop handles between two checks of its context.
+const checkInterval = 256
+
+// canceler checks the context at the first of every checkInterval calls, which keeps ctx.Err off the path of a row.
+type canceler struct {
+	n int
+}
+
+func (c *canceler) check(ctx context.Context) error {
+	n := c.n
+	c.n++
+	if n%checkInterval != 0 {
+		return nil
+	}
+	return canceledError(ctx)
+}
+
 func (b *baseExecutor) Close() error {
 	return nil
 }
@@ -34,7 +74,22 @@ func newBaseExecutor(ctx session.Context) baseExecutor {
 	return baseExecutor{ctx: ctx}
 }
 
+// Execute runs the executor until it yields no more rows, an ErrCanceled is returned if ctx is done.
 func Execute(executor Executor, ctx context.Context) (result []btuple.Modifier, ids []primitive.ObjectID, err error) {
+	err = ForEach(executor, ctx, func(tuple btuple.Modifier, rid primitive.ObjectID) error {
+		result = append(result, tuple)
+		ids = append(ids, rid)
+		return nil
+	})
+	return
+}
+
+// ForEach runs the executor as Execute does, but passes the rows to fn instead of keeping them,
+// it stops at the first error of fn and returns it.
+func ForEach(executor Executor, ctx context.Context, fn func(tuple btuple.Modifier, rid primitive.ObjectID) error) (err error) {
+	if err = canceledError(ctx); err != nil {
+		return
+	}
 	executor.Init()
 	var (
 		next bool
@@ -45,17 +100,31 @@ func Execute(executor Executor, ctx context.Context) (result []btuple.Modifier,
 			rid   primitive.ObjectID
 		)
 		if next, err = executor.Next(ctx, &tuple, &rid); err != nil {
-			return
+			// releases the iterators of the executors, the error of Next is reported
+			executor.Close()
+			return asCanceled(err)
 		}
 		if !next {
 			break
 		}
 		if tuple != nil || !rid.IsEmpty() {
-			result = append(result, tuple)
-			ids = append(ids, rid)
+			if err = fn(tuple, rid); err != nil {
+				executor.Close()
+				return err
+			}
 		}
+	}
+	return executor.Close()
+}
 
+// asCanceled turns the error of a context, e.g., returned by a goroutine of an executor, into an ErrCanceled.
+func asCanceled(err error) error {
+	var canceled *ErrCanceled
+	if errors.As(err, &canceled) {
+		return err
 	}
-	err = executor.Close()
-	return
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return &ErrCanceled{Err: err}
+	}
+	return err
 }
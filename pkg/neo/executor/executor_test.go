@@ -0,0 +1,150 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/casbin-mesh/neo/pkg/expression"
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/parser"
+	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+// cancelingExecutor cancels the context once its child yielded after rows.
+type cancelingExecutor struct {
+	Executor
+	after  int
+	rows   int
+	cancel context.CancelFunc
+}
+
+func (c *cancelingExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid *primitive.ObjectID) (bool, error) {
+	next, err := c.Executor.Next(ctx, tuple, rid)
+	if c.rows++; c.rows == c.after {
+		c.cancel()
+	}
+	return next, err
+}
+
+func TestExecute_Canceled(t *testing.T) {
+	p := "./__test_tmp__/execute_canceled"
+	mockDb, sc, info := openBatchTestDB(t, p, 1000)
+	defer func() {
+		sc.RollbackTxn(context.TODO())
+		mockDb.Close()
+		os.RemoveAll(p)
+	}()
+	table := info.TableInfo[0]
+	nobody := func() (expression.Expression, ast.EvaluateCtx) {
+		expr, accessor := expression.NewExpression(parser.MustParseFromString(`p.sub == "nobody"`))
+		ctx := ast.NewContext()
+		ctx.AddAccessor("p", accessor)
+		return expr, ctx
+	}
+	build := func(p plan.AbstractPlan) Executor {
+		builder := NewExecutorBuilder(sc)
+		exec, err := builder.Build(p), builder.Error()
+		assert.Nil(t, err)
+		return exec
+	}
+	seqScan := plan.NewSeqScanPlan(table, nil, nil, info.ID, table.ID)
+	expr, evalCtx := nobody()
+	filteredSeqScan := plan.NewSeqScanPlan(table, expr, evalCtx, info.ID, table.ID)
+	expr, evalCtx = nobody()
+	filteredIndexScan := plan.NewIndexScanPlan(model.NewIndexSchemaReader(table, 0), codec.IndexEntryPrefix(table.Indices[0].ID, nil), expr, evalCtx, info.ID, table.ID)
+
+	// the filtered rows are skipped in a loop
+	for _, p := range []plan.AbstractPlan{filteredSeqScan, filteredIndexScan} {
+		result, _, err := Execute(build(p), context.TODO())
+		assert.Nil(t, err)
+		assert.Len(t, result, 0)
+	}
+
+	// canceled before the execution
+	canceled, cancel := context.WithCancel(context.TODO())
+	cancel()
+	for _, p := range []plan.AbstractPlan{seqScan, filteredSeqScan, filteredIndexScan} {
+		_, _, err := Execute(build(p), canceled)
+		assert.Equal(t, &ErrCanceled{Err: context.Canceled}, err)
+		assert.True(t, errors.Is(err, context.Canceled))
+		batchBuilder := NewBatchExecutorBuilder(sc)
+		_, _, err = ExecuteBatch(batchBuilder.Build(p), canceled, DefaultBatchSize)
+		assert.Equal(t, &ErrCanceled{Err: context.Canceled}, err)
+	}
+
+	// canceled while a filtered scan skips its rows, it stops in a Next call
+	for _, p := range []plan.AbstractPlan{filteredSeqScan, filteredIndexScan} {
+		exec := build(p)
+		exec.Init()
+		var (
+			tuple btuple.Modifier
+			rid   primitive.ObjectID
+		)
+		next, err := exec.Next(canceled, &tuple, &rid)
+		assert.False(t, next)
+		assert.Equal(t, &ErrCanceled{Err: context.Canceled}, err)
+		assert.Nil(t, exec.Close())
+	}
+
+	// canceled in the middle of a scan, the rows yielded before are returned
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	result, _, err := Execute(&cancelingExecutor{Executor: build(seqScan), after: 10, cancel: cancel}, ctx)
+	assert.Equal(t, &ErrCanceled{Err: context.Canceled}, err)
+	assert.GreaterOrEqual(t, len(result), 10)
+	assert.Less(t, len(result), 10+checkInterval)
+
+	// canceled while the hash table is built, the right child doesn't check the context itself
+	right := &mockTuplesExecutor{}
+	for i := 0; i < 1000; i++ {
+		right.tuples = append(right.tuples, newTestTuple(fmt.Sprintf("user%d", i%10), fmt.Sprintf("data%d", i), "read"))
+		right.rids = append(right.rids, primitive.NewObjectID())
+	}
+	joinPlan, err := plan.NewHashJoinPlan(seqScan, seqScan, []string{"obj"}, []string{"obj"})
+	assert.Nil(t, err)
+	ctx, cancel = context.WithCancel(context.TODO())
+	defer cancel()
+	exec, err := NewHashJoinExecutor(sc, joinPlan, build(seqScan), &cancelingExecutor{Executor: right, after: 10, cancel: cancel})
+	assert.Nil(t, err)
+	result, _, err = Execute(exec, ctx)
+	assert.Equal(t, &ErrCanceled{Err: context.Canceled}, err)
+	assert.Len(t, result, 0)
+	assert.Less(t, right.cursor, 10+checkInterval+1)
+	assert.True(t, right.closed)
+}
+
+func TestForEach(t *testing.T) {
+	child := &mockTuplesExecutor{}
+	for i := 0; i < 10; i++ {
+		child.tuples = append(child.tuples, newTestTuple("alice", fmt.Sprintf("data%d", i)))
+		child.rids = append(child.rids, primitive.NewObjectID())
+	}
+	var ids []primitive.ObjectID
+	err := ForEach(child, context.TODO(), func(tuple btuple.Modifier, rid primitive.ObjectID) error {
+		ids = append(ids, rid)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, child.rids, ids)
+	assert.True(t, child.closed)
+
+	// stops at the error of fn
+	errStop := errors.New("stop")
+	child.closed, ids = false, nil
+	err = ForEach(child, context.TODO(), func(tuple btuple.Modifier, rid primitive.ObjectID) error {
+		if ids = append(ids, rid); len(ids) == 3 {
+			return errStop
+		}
+		return nil
+	})
+	assert.Equal(t, errStop, err)
+	assert.Len(t, ids, 3)
+	assert.True(t, child.closed)
+}
@@ -0,0 +1,331 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/bschema"
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExecStats are the statistics of an executor run by EXPLAIN ANALYZE.
+type ExecStats struct {
+	// Rows is the number of the rows the executor yielded.
+	Rows int
+	// NextCalls is the number of the calls to its Next, including the last one yields no row.
+	NextCalls int
+	// Time is the wall time spent in its Init, Next and Close, including the time of its children.
+	Time time.Duration
+}
+
+func (s ExecStats) String() string {
+	return fmt.Sprintf("rows=%d nexts=%d time=%s", s.Rows, s.NextCalls, s.Time)
+}
+
+// PlanNode is a node of an explained plan tree.
+type PlanNode struct {
+	Plan plan.AbstractPlan
+	// Name is the kind of the plan, e.g. IndexScan.
+	Name string
+	// Details are the properties of the plan, e.g. table=p, with the names resolved by the catalog.
+	Details  []string
+	Children []*PlanNode
+	// Stats are set by ExplainAnalyze, they are nil if the executor of the plan didn't run.
+	Stats *ExecStats
+}
+
+// String returns the tree, a node a line, the children are indented under their parent.
+func (n *PlanNode) String() string {
+	var sb strings.Builder
+	n.format(&sb, 0)
+	return sb.String()
+}
+
+func (n *PlanNode) format(sb *strings.Builder, depth int) {
+	if depth > 0 {
+		sb.WriteString(strings.Repeat("  ", depth-1))
+		sb.WriteString("-> ")
+	}
+	sb.WriteString(n.Name)
+	for _, detail := range n.Details {
+		sb.WriteByte(' ')
+		sb.WriteString(detail)
+	}
+	if n.Stats != nil {
+		sb.WriteString(" (")
+		sb.WriteString(n.Stats.String())
+		sb.WriteByte(')')
+	}
+	sb.WriteByte('\n')
+	for _, child := range n.Children {
+		child.format(sb, depth+1)
+	}
+}
+
+// Explain returns the plan tree of p, the tables and indexes are named by the catalog of the session.
+func Explain(sc session.Context, p plan.AbstractPlan) *PlanNode {
+	return explain(sc, p, nil)
+}
+
+// ExplainAnalyze runs p, and returns its plan tree with the statistics of the executors and the result of p.
+// The executors are measured by the wrappers of them, which may hide the executors from their parents,
+// e.g., a parallel multi-index scan decodes the entries of its child index scan as the child does.
+func ExplainAnalyze(ctx context.Context, sc session.Context, p plan.AbstractPlan) (*PlanNode, []btuple.Modifier, []primitive.ObjectID, error) {
+	stats := make(map[plan.AbstractPlan]*ExecStats)
+	builder := NewExecutorBuilder(sc)
+	builder.instrument = func(p plan.AbstractPlan, exec Executor) Executor {
+		s := &ExecStats{}
+		stats[p] = s
+		return &analyzeExecutor{Executor: exec, stats: s}
+	}
+	exec, err := builder.Build(p), builder.Error()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	tuples, ids, err := Execute(exec, ctx)
+	return explain(sc, p, stats), tuples, ids, err
+}
+
+// analyzeExecutor measures the executor it wraps.
+type analyzeExecutor struct {
+	Executor
+	stats *ExecStats
+}
+
+func (a *analyzeExecutor) Init() {
+	start := time.Now()
+	a.Executor.Init()
+	a.stats.Time += time.Since(start)
+}
+
+func (a *analyzeExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid *primitive.ObjectID) (next bool, err error) {
+	start := time.Now()
+	next, err = a.Executor.Next(ctx, tuple, rid)
+	a.stats.Time += time.Since(start)
+	a.stats.NextCalls++
+	if next {
+		a.stats.Rows++
+	}
+	return
+}
+
+func (a *analyzeExecutor) Close() error {
+	start := time.Now()
+	err := a.Executor.Close()
+	a.stats.Time += time.Since(start)
+	return err
+}
+
+func explain(sc session.Context, p plan.AbstractPlan, stats map[plan.AbstractPlan]*ExecStats) *PlanNode {
+	n := describe(sc, p)
+	n.Plan, n.Stats = p, stats[p]
+	for _, child := range p.GetChildren() {
+		n.Children = append(n.Children, explain(sc, child, stats))
+	}
+	return n
+}
+
+// describe names the plan, the cases follow the ones of the executor builder.
+func describe(sc session.Context, p plan.AbstractPlan) *PlanNode {
+	n := &PlanNode{}
+	detail := func(key string, value interface{}) {
+		n.Details = append(n.Details, fmt.Sprintf("%s=%v", key, value))
+	}
+	predicate := func(expr fmt.Stringer) {
+		detail("predicate", strconv.Quote(expr.String()))
+	}
+	switch v := p.(type) {
+	case *plan.TableRowIdScan:
+		n.Name = "TableRowIdScan"
+		detail("table", tableName(sc, v.DBOid(), v.TableOid()))
+	case plan.InsertPlan:
+		n.Name = "Insert"
+		detail("table", tableName(sc, v.DBOid(), v.TableOid()))
+		if v.RawValuesSize() > 0 {
+			detail("values", v.RawValuesSize())
+		}
+	case plan.UpdatePlan:
+		n.Name = "Update"
+		detail("table", tableName(sc, v.DBOid(), v.TableOid()))
+	case plan.IndexScanPlan:
+		n.Name = "IndexScan"
+		detail("table", tableName(sc, v.DBOid(), v.TableOid()))
+		id, _ := codec.ParseIndexId(v.Prefix())
+		detail("index", indexName(sc, v.DBOid(), v.TableOid(), id))
+		detail("prefix", formatKey(v.Prefix()))
+		if v.Predicate() != nil {
+			predicate(v.Predicate())
+		}
+	case plan.IndexRangeScanPlan:
+		n.Name = "IndexRangeScan"
+		detail("table", tableName(sc, v.DBOid(), v.TableOid()))
+		detail("index", indexName(sc, v.DBOid(), v.TableOid(), v.IndexOid()))
+		if r := v.Range(); r.Start != nil && r.StartInclusive {
+			detail("from", formatKey(r.Start))
+		} else if r.Start != nil {
+			detail("after", formatKey(r.Start))
+		}
+		if r := v.Range(); r.End != nil && r.EndInclusive {
+			detail("to", formatKey(r.End))
+		} else if r.End != nil {
+			detail("before", formatKey(r.End))
+		}
+		if v.Reverse() {
+			detail("reverse", true)
+		}
+		if v.Predicate() != nil {
+			predicate(v.Predicate())
+		}
+	case plan.SeqScanPlan:
+		n.Name = "SeqScan"
+		detail("table", tableName(sc, v.DBOid(), v.TableOid()))
+		if v.Predicate() != nil {
+			predicate(v.Predicate())
+		}
+	case plan.DeletePlan:
+		n.Name = "Delete"
+		detail("table", tableName(sc, v.DbOid(), v.TableOid()))
+	case plan.LimitPlan:
+		n.Name = "Limit"
+		detail("limit", v.Limit())
+	case plan.SchemaPlan:
+		n.Name = "CreateDB"
+		detail("db", v.GetDBInfo().Name.O)
+	case plan.ParallelMultiIndexScan:
+		n.Name = "ParallelMultiIndexScan"
+		detail("table", tableName(sc, v.DBOid(), v.TableOid()))
+		if v.Workers() > 0 {
+			detail("workers", v.Workers())
+		}
+	case plan.MultiIndexScan:
+		n.Name = "MultiIndexScan"
+		detail("table", tableName(sc, v.DBOid(), v.TableOid()))
+	case plan.ConstPlan:
+		n.Name = "Const"
+		if v.Predicate() != nil {
+			predicate(v.Predicate())
+		}
+	case plan.EnforcePlan:
+		n.Name = "Enforce"
+		detail("model", dbName(sc, v.DBOid()))
+		if v.Predicate() != nil {
+			predicate(v.Predicate())
+		}
+	case plan.ProjectionPlan:
+		n.Name = "Projection"
+		detail("columns", schemaFields(v.OutputSchema(), nil))
+	case plan.AggregationPlan:
+		n.Name = "Aggregation"
+		if len(v.GroupBy()) > 0 {
+			detail("group_by", schemaFields(v.GetChildAt(0).OutputSchema(), v.GroupBy()))
+		}
+		names := make([]string, 0, len(v.Aggregates()))
+		for _, aggregate := range v.Aggregates() {
+			names = append(names, aggregate.Name())
+		}
+		detail("aggregates", strings.Join(names, ","))
+	case plan.HashJoinPlan:
+		n.Name = "HashJoin"
+		detail("left_keys", schemaFields(v.LeftOutputSchema(), v.LeftKeys()))
+		detail("right_keys", schemaFields(v.RightOutputSchema(), v.RightKeys()))
+	case plan.IndexNestedLoopJoinPlan:
+		n.Name = "IndexNestedLoopJoin"
+		detail("table", tableName(sc, v.DBOid(), v.TableOid()))
+		detail("index", indexName(sc, v.DBOid(), v.TableOid(), v.IndexOid()))
+		detail("outer_keys", schemaFields(v.OuterOutputSchema(), v.OuterKeys()))
+	case plan.IndexUnionPlan:
+		n.Name = "IndexUnion"
+		detail("table", tableName(sc, v.DBOid(), v.TableOid()))
+	default:
+		n.Name = fmt.Sprintf("%T", p)
+	}
+	return n
+}
+
+func dbName(sc session.Context, dbOid uint64) string {
+	dbInfo, err := sc.GetCatalog().GetDBInfoByDBId(dbOid)
+	if err != nil {
+		return strconv.FormatUint(dbOid, 10)
+	}
+	return dbInfo.Name.O
+}
+
+func tableInfo(sc session.Context, dbOid, tableOid uint64) *model.TableInfo {
+	dbInfo, err := sc.GetCatalog().GetDBInfoByDBId(dbOid)
+	if err != nil {
+		return nil
+	}
+	table, err := dbInfo.TableById(tableOid)
+	if err != nil {
+		return nil
+	}
+	return table
+}
+
+// tableName returns the name of the table, or its id if the catalog has no such table.
+func tableName(sc session.Context, dbOid, tableOid uint64) string {
+	if table := tableInfo(sc, dbOid, tableOid); table != nil {
+		return table.Name.O
+	}
+	return strconv.FormatUint(tableOid, 10)
+}
+
+// indexName returns the name of the index, or its id if the catalog has no such index.
+func indexName(sc session.Context, dbOid, tableOid, indexOid uint64) string {
+	if table := tableInfo(sc, dbOid, tableOid); table != nil {
+		for _, index := range table.Indices {
+			if index.ID == indexOid {
+				return index.Name.O
+			}
+		}
+	}
+	return strconv.FormatUint(indexOid, 10)
+}
+
+// schemaFields returns the names of the fields at the offsets joined by commas, or of all the fields if offsets is nil.
+func schemaFields(schema bschema.Reader, offsets []int) string {
+	if schema == nil {
+		return ""
+	}
+	if offsets == nil {
+		for i := 0; i < schema.FieldsLen(); i++ {
+			offsets = append(offsets, i)
+		}
+	}
+	names := make([]string, 0, len(offsets))
+	for _, offset := range offsets {
+		names = append(names, string(schema.FieldAt(offset).Name()))
+	}
+	return strings.Join(names, ",")
+}
+
+// formatKey decodes the values of the key of an index entry, e.g. ("alice","data1").
+func formatKey(key []byte) string {
+	if _, err := codec.ParseIndexId(key); err != nil {
+		return strconv.Quote(string(key))
+	}
+	var values []string
+	for rest := key[10:]; len(rest) > 0; {
+		v, r, err := codec.DecodeCmpValue(rest)
+		if err != nil {
+			// the row id of a secondary index entry follows its values
+			values = append(values, fmt.Sprintf("0x%x", rest))
+			break
+		}
+		if v.Type() == bsontype.String {
+			values = append(values, strconv.Quote(v.GetString()))
+		} else {
+			values = append(values, formatValue(v))
+		}
+		rest = r
+	}
+	return "(" + strings.Join(values, ",") + ")"
+}
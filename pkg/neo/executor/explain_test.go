@@ -0,0 +1,64 @@
+package executor
+
+import (
+	"context"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	p := "./__test_tmp__/explain"
+	mockDb, sc, info := openBatchTestDB(t, p, 100)
+	defer func() {
+		sc.RollbackTxn(context.TODO())
+		mockDb.Close()
+		os.RemoveAll(p)
+	}()
+	table := info.TableInfo[0]
+	scan := func(offset int, v string) plan.AbstractPlan {
+		prefix := codec.IndexEntryPrefix(table.Indices[offset].ID, [][]byte{codec.EncodeCmpValue(value.NewStringValue(v))})
+		return plan.NewIndexScanPlan(model.NewIndexSchemaReader(table, offset), prefix, nil, nil, info.ID, table.ID)
+	}
+	newPlan := func() plan.AbstractPlan {
+		return plan.NewTableRowIdScan(table, info.ID, table.ID, plan.NewIndexUnionPlan(
+			[]plan.AbstractPlan{scan(0, "user1"), scan(1, "data10")}, info.ID, table.ID,
+		))
+	}
+
+	node := Explain(sc, newPlan())
+	lines := strings.Split(strings.TrimSuffix(node.String(), "\n"), "\n")
+	assert.Len(t, lines, 4)
+	assert.Equal(t, "TableRowIdScan table="+table.Name.O, lines[0])
+	assert.Equal(t, "-> IndexUnion table="+table.Name.O, lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "  -> IndexScan table="+table.Name.O+" index="+table.Indices[0].Name.O))
+	assert.True(t, strings.HasSuffix(lines[2], `prefix=("user1")`))
+	assert.True(t, strings.HasSuffix(lines[3], `prefix=("data10")`))
+	assert.Nil(t, node.Stats)
+
+	node, tuples, ids, err := ExplainAnalyze(context.TODO(), sc, newPlan())
+	assert.Nil(t, err)
+	assert.Len(t, tuples, 11)
+	assert.Len(t, ids, 11)
+	for _, n := range []*PlanNode{node, node.Children[0]} {
+		assert.Equal(t, 11, n.Stats.Rows)
+		assert.Equal(t, 12, n.Stats.NextCalls)
+	}
+	user1, data10 := node.Children[0].Children[0].Stats, node.Children[0].Children[1].Stats
+	assert.Equal(t, 10, user1.Rows)
+	assert.Equal(t, 1, data10.Rows)
+	assert.Contains(t, node.String(), "(rows=11 nexts=12 time=")
+
+	// the result is the same as the one of an executor built without the statistics
+	builder := NewExecutorBuilder(sc)
+	exec, err := builder.Build(newPlan()), builder.Error()
+	assert.Nil(t, err)
+	_, expectedIds, err := Execute(exec, context.TODO())
+	assert.Nil(t, err)
+	assert.Equal(t, expectedIds, ids)
+}
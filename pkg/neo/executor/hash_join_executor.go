@@ -0,0 +1,136 @@
+package executor
+
+import (
+	"context"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/bschema"
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+)
+
+type hashJoinExecutor struct {
+	baseExecutor
+	hashJoinPlan plan.HashJoinPlan
+	left         Executor
+	right        Executor
+	// rightOffsets are the offsets of the right columns kept by the merged schema
+	rightOffsets []int
+	prepared     bool
+	hashMap      map[string][]btuple.Modifier
+	// the left tuple being probed, and its matches not yielded yet
+	outer    btuple.Modifier
+	outerRid primitive.ObjectID
+	matches  []btuple.Modifier
+	cancel   canceler
+}
+
+// mergedOffsets returns the offsets of the fields of right appended by utils.MergeSchema to left.
+func mergedOffsets(left, right bschema.Reader) (offsets []int) {
+	for i := 0; i < right.FieldsLen(); i++ {
+		if left.Field(string(right.FieldAt(i).Name())) < 0 {
+			offsets = append(offsets, i)
+		}
+	}
+	return
+}
+
+// mergeTuple returns the tuple of the merged schema, the values of outer followed by the ones of inner at offsets.
+func mergeTuple(outer btuple.Modifier, outerSchema bschema.Reader, inner btuple.Modifier, offsets []int) btuple.Modifier {
+	elems := make([]btuple.Elem, 0, outerSchema.FieldsLen()+len(offsets))
+	for i := 0; i < outerSchema.FieldsLen(); i++ {
+		elems = append(elems, valueAt(outer, i))
+	}
+	for _, offset := range offsets {
+		elems = append(elems, valueAt(inner, offset))
+	}
+	return btuple.NewModifier(elems)
+}
+
+// joinKey returns the mem-comparable values of the key columns of the tuple,
+// ok is false if any of them is NULL, which never equals another value.
+func joinKey(tuple btuple.Modifier, schema bschema.Reader, keys []int) (key []byte, ok bool) {
+	for _, offset := range keys {
+		v := codec.DecodeValue(valueAt(tuple, offset), schema.FieldAt(offset).Type())
+		if v.Type() == bsontype.Null {
+			return nil, false
+		}
+		key = append(key, codec.EncodeCmpValue(v)...)
+	}
+	return key, true
+}
+
+func (h *hashJoinExecutor) Init() {
+	h.left.Init()
+	h.right.Init()
+	h.hashMap, h.prepared, h.matches = make(map[string][]btuple.Modifier), false, nil
+}
+
+func (h *hashJoinExecutor) build(ctx context.Context) error {
+	schema, keys := h.hashJoinPlan.RightOutputSchema(), h.hashJoinPlan.RightKeys()
+	for {
+		var (
+			tuple btuple.Modifier
+			rid   primitive.ObjectID
+		)
+		if err := h.cancel.check(ctx); err != nil {
+			return err
+		}
+		next, err := h.right.Next(ctx, &tuple, &rid)
+		if err != nil {
+			return err
+		}
+		if !next {
+			return nil
+		}
+		if key, ok := joinKey(tuple, schema, keys); ok {
+			h.hashMap[string(key)] = append(h.hashMap[string(key)], tuple)
+		}
+	}
+}
+
+// Next yields the merged tuples of the matched pairs, in the order of the left tuples,
+// the row id is the one of the left tuple.
+func (h *hashJoinExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid *primitive.ObjectID) (next bool, err error) {
+	if !h.prepared {
+		if err = h.build(ctx); err != nil {
+			return false, err
+		}
+		h.prepared = true
+	}
+	for len(h.matches) == 0 {
+		if err = h.cancel.check(ctx); err != nil {
+			return false, err
+		}
+		if next, err = h.left.Next(ctx, &h.outer, &h.outerRid); !next || err != nil {
+			return
+		}
+		if key, ok := joinKey(h.outer, h.hashJoinPlan.LeftOutputSchema(), h.hashJoinPlan.LeftKeys()); ok {
+			h.matches = h.hashMap[string(key)]
+		}
+	}
+	*tuple = mergeTuple(h.outer, h.hashJoinPlan.LeftOutputSchema(), h.matches[0], h.rightOffsets)
+	*rid = h.outerRid
+	h.matches = h.matches[1:]
+	return true, nil
+}
+
+func (h *hashJoinExecutor) Close() error {
+	err := h.left.Close()
+	if rErr := h.right.Close(); err == nil {
+		err = rErr
+	}
+	return err
+}
+
+func NewHashJoinExecutor(ctx session.Context, hashJoinPlan plan.HashJoinPlan, left, right Executor) (Executor, error) {
+	return &hashJoinExecutor{
+		baseExecutor: newBaseExecutor(ctx),
+		hashJoinPlan: hashJoinPlan,
+		left:         left,
+		right:        right,
+		rightOffsets: mergedOffsets(hashJoinPlan.LeftOutputSchema(), hashJoinPlan.RightOutputSchema()),
+	}, nil
+}
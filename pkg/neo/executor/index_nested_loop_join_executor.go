@@ -0,0 +1,160 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"github.com/casbin-mesh/neo/pkg/db"
+	"github.com/casbin-mesh/neo/pkg/db/adapter"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+)
+
+type indexNestedLoopJoinExecutor struct {
+	baseExecutor
+	joinPlan  plan.IndexNestedLoopJoinPlan
+	indexInfo *model.IndexInfo
+	outer     Executor
+	iter      db.Iterator
+	// innerOffsets are the offsets of the inner columns kept by the merged schema
+	innerOffsets []int
+	// point is true if the outer keys cover a unique index, whose entries are looked up by Get.
+	point bool
+	// the outer tuple being probed, and the prefix of its index entries
+	outerTuple btuple.Modifier
+	outerRid   primitive.ObjectID
+	prefix     []byte
+	probing    bool
+	cancel     canceler
+}
+
+func (i *indexNestedLoopJoinExecutor) Init() {
+	i.outer.Init()
+	i.probing = false
+	if !i.point {
+		if i.iter != nil {
+			i.iter.Close()
+		}
+		i.iter = i.GetTxn().NewIterator(adapter.DefaultIteratorOptions)
+	}
+}
+
+// nextEntry returns the next index entry under the prefix, key is nil if there is no more.
+func (i *indexNestedLoopJoinExecutor) nextEntry() (key, val []byte, err error) {
+	if i.point {
+		i.probing = false
+		var item db.Item
+		if item, err = i.GetTxn().Get(i.prefix); err != nil {
+			if err == db.ErrKeyNotFound {
+				err = nil
+			}
+			return
+		}
+		if val, err = item.ValueCopy(nil); err != nil {
+			return
+		}
+		return i.prefix, val, nil
+	}
+
+	if !i.iter.ValidForPrefix(i.prefix) {
+		i.probing = false
+		return
+	}
+	key = i.iter.Item().KeyCopy(nil)
+	if val, err = i.iter.Item().ValueCopy(nil); err != nil {
+		return nil, nil, err
+	}
+	i.iter.Next()
+	return
+}
+
+// Next yields the merged tuples of the outer tuples and their inner rows, in the order of the
+// outer tuples, the row id is the one of the outer tuple.
+func (i *indexNestedLoopJoinExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid *primitive.ObjectID) (next bool, err error) {
+	for {
+		if err = i.cancel.check(ctx); err != nil {
+			return false, err
+		}
+		if !i.probing {
+			if next, err = i.outer.Next(ctx, &i.outerTuple, &i.outerRid); !next || err != nil {
+				return
+			}
+			key, ok := joinKey(i.outerTuple, i.joinPlan.OuterOutputSchema(), i.joinPlan.OuterKeys())
+			if !ok {
+				continue
+			}
+			i.prefix = codec.IndexEntryPrefix(i.indexInfo.ID, [][]byte{key})
+			i.GetSessionCtx().TrackPrefixRead(i.prefix)
+			if !i.point {
+				i.iter.Seek(i.prefix)
+			}
+			i.probing = true
+		}
+
+		key, val, err := i.nextEntry()
+		if err != nil {
+			return false, err
+		}
+		if key == nil {
+			continue
+		}
+		_, innerRid, err := codec.DecodeIndexEntry(i.indexInfo, key, val)
+		if err != nil {
+			return false, err
+		}
+		item, err := i.GetTxn().Get(codec.TupleRecordKey(i.joinPlan.TableOid(), innerRid))
+		if err == db.ErrKeyNotFound {
+			continue
+		} else if err != nil {
+			return false, err
+		}
+		rawVal, err := item.ValueCopy(nil)
+		if err != nil {
+			return false, err
+		}
+		inner, err := btuple.NewReader(rawVal)
+		if err != nil {
+			return false, err
+		}
+		*tuple = mergeTuple(i.outerTuple, i.joinPlan.OuterOutputSchema(), btuple.NewModifier(inner.Values()), i.innerOffsets)
+		*rid = i.outerRid
+		return true, nil
+	}
+}
+
+func (i *indexNestedLoopJoinExecutor) Close() error {
+	if i.iter != nil {
+		i.iter.Close()
+		i.iter = nil
+	}
+	return i.outer.Close()
+}
+
+func NewIndexNestedLoopJoinExecutor(ctx session.Context, joinPlan plan.IndexNestedLoopJoinPlan, outer Executor) (Executor, error) {
+	dbInfo, err := ctx.GetCatalog().GetDBInfoByDBId(joinPlan.DBOid())
+	if err != nil {
+		return nil, err
+	}
+	tableInfo, err := dbInfo.TableById(joinPlan.TableOid())
+	if err != nil {
+		return nil, err
+	}
+	indexInfo, err := indexOfPrefix(tableInfo, codec.IndexEntryPrefix(joinPlan.IndexOid(), nil))
+	if err != nil {
+		return nil, err
+	}
+	if len(joinPlan.OuterKeys()) > len(indexInfo.Columns) {
+		return nil, fmt.Errorf("%w: %d keys for the %d columns of index %s", plan.ErrInvalidJoin, len(joinPlan.OuterKeys()), len(indexInfo.Columns), indexInfo.Name.O)
+	}
+	return &indexNestedLoopJoinExecutor{
+		baseExecutor: newBaseExecutor(ctx),
+		joinPlan:     joinPlan,
+		indexInfo:    indexInfo,
+		outer:        outer,
+		innerOffsets: mergedOffsets(joinPlan.OuterOutputSchema(), joinPlan.InnerOutputSchema()),
+		point:        indexInfo.Unique && len(joinPlan.OuterKeys()) == len(indexInfo.Columns),
+	}, nil
+}
@@ -0,0 +1,170 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"github.com/casbin-mesh/neo/pkg/db"
+	"github.com/casbin-mesh/neo/pkg/db/adapter"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/expression"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+)
+
+type indexRangeScanExecutor struct {
+	baseExecutor
+	rangeScanPlan plan.IndexRangeScanPlan
+	tableInfo     *model.TableInfo
+	indexInfo     *model.IndexInfo
+	// prefix is the prefix of all the entries of the index
+	prefix []byte
+	iter   db.Iterator
+	cancel canceler
+}
+
+func (i *indexRangeScanExecutor) Init() {
+	i.GetSessionCtx().TrackPrefixRead(i.prefix)
+	opts := adapter.DefaultIteratorOptions
+	opts.Reverse = i.rangeScanPlan.Reverse()
+	if i.iter != nil {
+		i.iter.Close()
+	}
+	i.iter = i.GetTxn().NewIterator(opts)
+	i.iter.Seek(i.seekKey())
+}
+
+// seekKey returns the key the iterator starts from, a reverse iterator seeks the last key not after it.
+func (i *indexRangeScanExecutor) seekKey() []byte {
+	r := i.rangeScanPlan.Range()
+	if !i.rangeScanPlan.Reverse() {
+		if r.Start != nil {
+			return r.Start
+		}
+		return i.prefix
+	}
+	switch {
+	case r.End == nil:
+		return prefixEnd(i.prefix)
+	case r.EndInclusive:
+		return prefixEnd(r.End)
+	default:
+		return r.End
+	}
+}
+
+func (i *indexRangeScanExecutor) afterStart(key []byte) bool {
+	r := i.rangeScanPlan.Range()
+	if r.Start == nil {
+		return true
+	}
+	if bytes.HasPrefix(key, r.Start) {
+		return r.StartInclusive
+	}
+	return bytes.Compare(key, r.Start) > 0
+}
+
+func (i *indexRangeScanExecutor) beforeEnd(key []byte) bool {
+	r := i.rangeScanPlan.Range()
+	if r.End == nil {
+		return true
+	}
+	if bytes.HasPrefix(key, r.End) {
+		return r.EndInclusive
+	}
+	return bytes.Compare(key, r.End) < 0
+}
+
+func (i *indexRangeScanExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid *primitive.ObjectID) (next bool, err error) {
+	reverse := i.rangeScanPlan.Reverse()
+	for ; i.iter.ValidForPrefix(i.prefix); i.iter.Next() {
+		if err = i.cancel.check(ctx); err != nil {
+			return false, err
+		}
+		key := i.iter.Item().KeyCopy(nil)
+		// the keys out of the range at the seek key are skipped, the ones at the other side end the scan
+		if !i.afterStart(key) {
+			if reverse {
+				return false, nil
+			}
+			continue
+		}
+		if !i.beforeEnd(key) {
+			if !reverse {
+				return false, nil
+			}
+			continue
+		}
+
+		val, err := i.iter.Item().ValueCopy(nil)
+		if err != nil {
+			return false, err
+		}
+		if *tuple, *rid, err = codec.DecodeIndexEntry(i.indexInfo, key, val); err != nil {
+			return false, err
+		}
+
+		predicate := i.rangeScanPlan.Predicate()
+		if predicate == nil {
+			i.iter.Next()
+			return true, nil
+		}
+		res, err := predicate.Evaluate(i.GetSessionCtx(), i.rangeScanPlan.GetEvalCtx(), *tuple, i.rangeScanPlan.OutputSchema())
+		if err != nil {
+			return false, err
+		}
+		if value, err := expression.TryGetBool(res); err != nil {
+			return false, err
+		} else if value {
+			i.iter.Next()
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (i *indexRangeScanExecutor) Close() error {
+	if i.iter != nil {
+		i.iter.Close()
+		i.iter = nil
+	}
+	return nil
+}
+
+// prefixEnd returns the smallest key after all the keys prefixed by prefix.
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	for j := len(end) - 1; j >= 0; j-- {
+		if end[j] < 0xFF {
+			end[j]++
+			return end[:j+1]
+		}
+	}
+	// every key is prefixed by a prefix of 0xFFs only, none is after them
+	return nil
+}
+
+func NewIndexRangeScanExecutor(ctx session.Context, scanPlan plan.IndexRangeScanPlan) (Executor, error) {
+	dbInfo, err := ctx.GetCatalog().GetDBInfoByDBId(scanPlan.DBOid())
+	if err != nil {
+		return nil, err
+	}
+	tableInfo, err := dbInfo.TableById(scanPlan.TableOid())
+	if err != nil {
+		return nil, err
+	}
+	prefix := codec.IndexEntryPrefix(scanPlan.IndexOid(), nil)
+	indexInfo, err := indexOfPrefix(tableInfo, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return &indexRangeScanExecutor{
+		baseExecutor:  newBaseExecutor(ctx),
+		rangeScanPlan: scanPlan,
+		tableInfo:     tableInfo,
+		indexInfo:     indexInfo,
+		prefix:        prefix,
+	}, nil
+}
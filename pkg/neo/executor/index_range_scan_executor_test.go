@@ -0,0 +1,130 @@
+package executor
+
+import (
+	"context"
+	"github.com/casbin-mesh/neo/pkg/expression"
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/neo/utils"
+	"github.com/casbin-mesh/neo/pkg/parser"
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestIndexRangeScanExecutor(t *testing.T) {
+	p := "./__test_tmp__/index_range_scan_exec"
+	mockDb := OpenMockDB(t, p)
+	defer func() {
+		mockDb.Close()
+		os.RemoveAll(p)
+	}()
+
+	info, err := utils.CompileModelFromString("range", `[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = priority, sub, obj, act
+
+[policy_effect]
+e = priority(p.eft) || deny
+
+[matchers]
+m = r.sub == p.sub && r.obj == p.obj && r.act == p.act`)
+	assert.Nil(t, err)
+	table := info.TableInfo[0]
+	table.Columns[0].Tp = bsontype.Int64
+	table.Indices = append(table.Indices, &model.IndexInfo{
+		Name:    model.CIStr{O: "priority_index", L: "priority_index"},
+		Table:   table.Name,
+		Columns: []*model.IndexColumn{{ColName: table.Columns[0].ColName, Offset: 0}},
+	})
+	offset := len(table.Indices) - 1
+
+	// two rules of each priority, the negative ones are ordered before the others
+	var rules []value.Values
+	for i := int64(-4); i < 16; i++ {
+		for _, sub := range []string{"alice", "bob"} {
+			rules = append(rules, value.Values{value.NewInt64Value(i), value.NewStringValue(sub), value.NewStringValue("data"), value.NewStringValue("read")})
+		}
+	}
+	sc := mockDb.NewTxnAt(1, true)
+	mockDb.CreateDB(t, sc, info)
+	_, _, err = mockDb.InsertTuples(t, sc, info.ID, table.ID, rules)
+	assert.Nil(t, err)
+	assert.Nil(t, sc.CommitTxn(context.TODO(), 2))
+	assert.Nil(t, mockDb.WaitForMark(context.TODO(), 2))
+
+	index := table.Indices[offset]
+	key := func(priority int64) []byte {
+		return codec.IndexEntryPrefix(index.ID, [][]byte{codec.EncodeCmpValue(value.NewInt64Value(priority))})
+	}
+	rangeScan := func(r plan.IndexRange, reverse bool, predicate expression.Expression, ctx ast.EvaluateCtx) (priorities []int64) {
+		sc := mockDb.NewTxnAt(3, false)
+		defer sc.RollbackTxn(context.TODO())
+		builder := executorBuilder{ctx: sc}
+		exec, err := builder.Build(plan.NewIndexRangeScanPlan(
+			model.NewIndexSchemaReader(table, offset), index.ID, r, reverse, predicate, ctx, info.ID, table.ID,
+		)), builder.Error()
+		assert.Nil(t, err)
+		result, ids, err := Execute(exec, context.TODO())
+		assert.Nil(t, err)
+		assert.Len(t, ids, len(result))
+		for _, tuple := range result {
+			v := codec.DecodeValue(tuple.ValueAt(0), bsontype.Int64)
+			priorities = append(priorities, v.GetInt64())
+		}
+		return
+	}
+
+	sets := []struct {
+		r        plan.IndexRange
+		reverse  bool
+		expected []int64
+	}{
+		// p.priority < 2
+		{plan.IndexRange{End: key(2)}, false, []int64{-4, -4, -3, -3, -2, -2, -1, -1, 0, 0, 1, 1}},
+		// p.priority <= -3
+		{plan.IndexRange{End: key(-3), EndInclusive: true}, false, []int64{-4, -4, -3, -3}},
+		// p.priority >= 13
+		{plan.IndexRange{Start: key(13), StartInclusive: true}, false, []int64{13, 13, 14, 14, 15, 15}},
+		// p.priority > 13
+		{plan.IndexRange{Start: key(13)}, false, []int64{14, 14, 15, 15}},
+		// 0 < p.priority <= 2
+		{plan.IndexRange{Start: key(0), End: key(2), EndInclusive: true}, false, []int64{1, 1, 2, 2}},
+		// 0 <= p.priority < 2
+		{plan.IndexRange{Start: key(0), StartInclusive: true, End: key(2)}, false, []int64{0, 0, 1, 1}},
+		// the empty ranges
+		{plan.IndexRange{Start: key(2), End: key(2)}, false, nil},
+		{plan.IndexRange{Start: key(3), StartInclusive: true, End: key(2), EndInclusive: true}, false, nil},
+		{plan.IndexRange{Start: key(16), StartInclusive: true}, false, nil},
+		// the reverse ones
+		{plan.IndexRange{End: key(-2)}, true, []int64{-3, -3, -4, -4}},
+		{plan.IndexRange{End: key(-2), EndInclusive: true}, true, []int64{-2, -2, -3, -3, -4, -4}},
+		{plan.IndexRange{Start: key(13)}, true, []int64{15, 15, 14, 14}},
+		{plan.IndexRange{Start: key(13), StartInclusive: true}, true, []int64{15, 15, 14, 14, 13, 13}},
+		{plan.IndexRange{Start: key(0), End: key(2), EndInclusive: true}, true, []int64{2, 2, 1, 1}},
+		{plan.IndexRange{Start: key(2), End: key(2)}, true, nil},
+	}
+	for _, set := range sets {
+		assert.Equal(t, set.expected, rangeScan(set.r, set.reverse, nil, nil), set)
+	}
+
+	// the whole index
+	all := rangeScan(plan.IndexRange{}, false, nil, nil)
+	assert.Len(t, all, len(rules))
+	reversed := rangeScan(plan.IndexRange{}, true, nil, nil)
+	for i := range all {
+		assert.Equal(t, all[i], reversed[len(reversed)-1-i])
+	}
+
+	// the predicate filters the entries
+	predicate, accessor := expression.NewExpression(parser.MustParseFromString("p.priority == 14"))
+	ctx := ast.NewContext()
+	ctx.AddAccessor("p", accessor)
+	assert.Equal(t, []int64{14, 14}, rangeScan(plan.IndexRange{Start: key(13)}, true, predicate, ctx))
+}
@@ -2,6 +2,7 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"github.com/casbin-mesh/neo/pkg/db"
 	"github.com/casbin-mesh/neo/pkg/db/adapter"
 	"github.com/casbin-mesh/neo/pkg/neo/codec"
@@ -13,60 +14,101 @@ import (
 	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
 )
 
+var (
+	ErrIndexNotFound = errors.New("index not found")
+)
+
 type indexScanExecutor struct {
 	baseExecutor
 	indexScanPlan plan.IndexScanPlan
 	tableInfo     *model.TableInfo
+	indexInfo     *model.IndexInfo
 	iter          db.Iterator
+	// point is true if the prefix is the key of a unique index entry,
+	// which is looked up by Get instead of an iterator.
+	point  bool
+	done   bool
+	cancel canceler
 }
 
 func (i *indexScanExecutor) Init() {
+	i.GetSessionCtx().TrackPrefixRead(i.indexScanPlan.Prefix())
+	if i.point {
+		i.done = false
+		return
+	}
+	if i.iter != nil {
+		i.iter.Close()
+	}
 	i.iter = i.GetTxn().NewIterator(adapter.DefaultIteratorOptions)
 	i.iter.Seek(i.indexScanPlan.Prefix())
 }
 
-func (i *indexScanExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid *primitive.ObjectID) (next bool, err error) {
-	if !i.iter.ValidForPrefix(i.indexScanPlan.Prefix()) {
-		return
-	}
-
-	rawKey := i.iter.Item().KeyCopy(nil)
-	if *rid, err = codec.ParseTupleRecordKeyFromSecondaryIndex(rawKey); err != nil {
-		return
+// nextEntry returns the next index entry under the prefix, key is nil if there is no more.
+func (i *indexScanExecutor) nextEntry() (key, val []byte, err error) {
+	if i.point {
+		if i.done {
+			return
+		}
+		i.done = true
+		var item db.Item
+		if item, err = i.GetTxn().Get(i.indexScanPlan.Prefix()); err != nil {
+			if err == db.ErrKeyNotFound {
+				err = nil
+			}
+			return
+		}
+		if val, err = item.ValueCopy(nil); err != nil {
+			return
+		}
+		return i.indexScanPlan.Prefix(), val, nil
 	}
 
-	rawVal, err := i.iter.Item().ValueCopy(nil)
-	if err != nil {
+	if !i.iter.ValidForPrefix(i.indexScanPlan.Prefix()) {
 		return
 	}
-
-	tupleReader, err := btuple.NewReader(rawVal)
-	if err != nil {
-		return
+	key = i.iter.Item().KeyCopy(nil)
+	if val, err = i.iter.Item().ValueCopy(nil); err != nil {
+		return nil, nil, err
 	}
-
-	*tuple = btuple.NewModifier(tupleReader.Values())
-
 	i.iter.Next()
+	return
+}
+
+// Next yields the next entry satisfies the predicate, the filtered ones are skipped in a loop.
+func (i *indexScanExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid *primitive.ObjectID) (next bool, err error) {
 	predicate := i.indexScanPlan.Predicate()
-	if predicate != nil {
-		if res, err := predicate.Evaluate(i.GetSessionCtx(), i.indexScanPlan.GetEvalCtx(), *tuple, i.indexScanPlan.OutputSchema()); err == nil {
-			if value, err := expression.TryGetBool(res); err != nil {
-				return false, err
-			} else if !value {
-				return i.Next(ctx, tuple, rid)
-			}
-		} else {
+	for {
+		if err = i.cancel.check(ctx); err != nil {
 			return false, err
 		}
-	}
+		key, val, err := i.nextEntry()
+		if key == nil || err != nil {
+			return false, err
+		}
+		if *tuple, *rid, err = codec.DecodeIndexEntry(i.indexInfo, key, val); err != nil {
+			return false, err
+		}
+		if predicate == nil {
+			return true, nil
+		}
 
-	return true, nil
+		res, err := predicate.Evaluate(i.GetSessionCtx(), i.indexScanPlan.GetEvalCtx(), *tuple, i.indexScanPlan.OutputSchema())
+		if err != nil {
+			return false, err
+		}
+		if value, err := expression.TryGetBool(res); err != nil {
+			return false, err
+		} else if value {
+			return true, nil
+		}
+	}
 }
 
 func (i *indexScanExecutor) Close() error {
 	if i.iter != nil {
 		i.iter.Close()
+		i.iter = nil
 	}
 	return nil
 }
@@ -80,9 +122,41 @@ func NewIndexScanExecutor(ctx session.Context, scanPlan plan.IndexScanPlan) (Exe
 	if err != nil {
 		return nil, err
 	}
+	indexInfo, err := indexOfPrefix(tableInfo, scanPlan.Prefix())
+	if err != nil {
+		return nil, err
+	}
 	return &indexScanExecutor{
 		baseExecutor:  newBaseExecutor(ctx),
 		indexScanPlan: scanPlan,
 		tableInfo:     tableInfo,
+		indexInfo:     indexInfo,
+		point:         indexInfo.Unique && coversIndex(indexInfo, scanPlan.Prefix()),
 	}, nil
 }
+
+// indexOfPrefix returns the index of the table that the prefix of index entries belongs to.
+func indexOfPrefix(tableInfo *model.TableInfo, prefix []byte) (*model.IndexInfo, error) {
+	id, err := codec.ParseIndexId(prefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range tableInfo.Indices {
+		if index.ID == id {
+			return index, nil
+		}
+	}
+	return nil, ErrIndexNotFound
+}
+
+// coversIndex returns true if the prefix holds the values of all the columns of the index.
+func coversIndex(indexInfo *model.IndexInfo, prefix []byte) bool {
+	rest := prefix[10:]
+	for range indexInfo.Columns {
+		var err error
+		if _, rest, err = codec.DecodeCmpValue(rest); err != nil {
+			return false
+		}
+	}
+	return len(rest) == 0
+}
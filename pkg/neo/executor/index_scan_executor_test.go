@@ -7,10 +7,14 @@ import (
 	"fmt"
 	"github.com/casbin-mesh/neo/pkg/expression"
 	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
 	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
 	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/neo/utils"
 	"github.com/casbin-mesh/neo/pkg/parser"
 	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"testing"
@@ -41,7 +45,7 @@ func TestNewIndexScanExecutor(t *testing.T) {
 	var indexId [8]byte
 	binary.BigEndian.PutUint64(indexId[:], idxId)
 	// scan from
-	indexPrefix := []byte(fmt.Sprintf("i%s_%s", indexId, "bob"))
+	indexPrefix := append([]byte(fmt.Sprintf("i%s_", indexId)), codec.EncodeCmpValue(value.NewStringValue("bob"))...)
 
 	mockExpr, accessor := expression.NewExpression(parser.MustParseFromString("p.subject == \"bob\""))
 	ctx := ast.NewContext()
@@ -66,3 +70,55 @@ func TestNewIndexScanExecutor(t *testing.T) {
 
 	IdsAsserter(t, expected, ids)
 }
+
+func TestIndexScanExecutor_Unique(t *testing.T) {
+	p := "./__test_tmp__/index_scan_exec_unique"
+	mockDb := OpenMockDB(t, p)
+	defer func() {
+		mockDb.Close()
+		os.RemoveAll(p)
+	}()
+
+	info, err := utils.CompileModelFromString("unique", basicModelText)
+	assert.Nil(t, err)
+	table := info.TableInfo[0]
+	sc := mockDb.NewTxnAt(1, true)
+	mockDb.CreateDB(t, sc, info)
+	_, ids, err := mockDb.InsertTuples(t, sc, info.ID, table.ID, mockDBDataSet)
+	assert.Nil(t, err)
+	assert.Nil(t, sc.CommitTxn(context.TODO(), 2))
+	assert.Nil(t, mockDb.WaitForMark(context.TODO(), 2))
+
+	offset := len(table.Indices) - 1
+	primary := table.Indices[offset]
+	indexScan := func(values ...string) ([]btuple.Modifier, []primitive.ObjectID) {
+		columnValues := make([][]byte, 0, len(values))
+		for _, v := range values {
+			columnValues = append(columnValues, codec.EncodeCmpValue(value.NewStringValue(v)))
+		}
+		sc := mockDb.NewTxnAt(3, false)
+		defer sc.RollbackTxn(context.TODO())
+		builder := executorBuilder{ctx: sc}
+		exec, err := builder.Build(plan.NewIndexScanPlan(
+			model.NewIndexSchemaReader(table, offset), codec.IndexEntryPrefix(primary.ID, columnValues), nil, nil, info.ID, table.ID,
+		)), builder.Error()
+		assert.Nil(t, err)
+		result, ids, err := Execute(exec, context.TODO())
+		assert.Nil(t, err)
+		return result, ids
+	}
+
+	// looks up the row id of a rule
+	result, rids := indexScan("bob", "data2", "write")
+	assert.Len(t, result, 1)
+	assert.Equal(t, []primitive.ObjectID{ids[2]}, rids)
+	assert.Equal(t, []btuple.Elem{btuple.Elem("bob"), btuple.Elem("data2"), btuple.Elem("write")}, result[0].Values())
+
+	result, _ = indexScan("bob", "data2", "read")
+	assert.Empty(t, result)
+
+	// scans the rules by the leading columns
+	result, rids = indexScan("bob")
+	assert.Len(t, result, 3)
+	assert.ElementsMatch(t, ids[2:], rids)
+}
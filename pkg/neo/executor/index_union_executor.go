@@ -0,0 +1,96 @@
+package executor
+
+import (
+	"context"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/rowset"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+)
+
+// indexUnionExecutor drains its children into the row id sets, and yields the union of them.
+type indexUnionExecutor struct {
+	baseExecutor
+	plan     plan.IndexUnionPlan
+	children []Executor
+	prepared bool
+	rids     []primitive.ObjectID
+	cursor   int
+	cancel   canceler
+}
+
+func (u *indexUnionExecutor) Init() {
+	for _, child := range u.children {
+		child.Init()
+	}
+	u.prepared, u.rids, u.cursor = false, nil, 0
+}
+
+func (u *indexUnionExecutor) union(ctx context.Context) error {
+	sets := make([]rowset.RowIDSet, 0, len(u.children))
+	for _, child := range u.children {
+		var rids []primitive.ObjectID
+		for {
+			var (
+				tuple btuple.Modifier
+				rid   primitive.ObjectID
+			)
+			if err := u.cancel.check(ctx); err != nil {
+				return err
+			}
+			next, err := child.Next(ctx, &tuple, &rid)
+			if err != nil {
+				return err
+			}
+			if !next {
+				break
+			}
+			if !rid.IsEmpty() {
+				rids = append(rids, rid)
+			}
+		}
+		sets = append(sets, rowset.New(rids))
+	}
+	u.rids = rowset.IDs(rowset.Union(sets...))
+	return nil
+}
+
+func (u *indexUnionExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid *primitive.ObjectID) (next bool, err error) {
+	if !u.prepared {
+		if err = u.union(ctx); err != nil {
+			return false, err
+		}
+		u.prepared = true
+	}
+	if u.cursor >= len(u.rids) {
+		return false, nil
+	}
+	*tuple, *rid = nil, u.rids[u.cursor]
+	u.cursor++
+	return true, nil
+}
+
+func (u *indexUnionExecutor) Close() (err error) {
+	for _, child := range u.children {
+		if cErr := child.Close(); err == nil {
+			err = cErr
+		}
+	}
+	return
+}
+
+func NewIndexUnionExecutor(ctx session.Context, unionPlan plan.IndexUnionPlan, children []Executor) (Executor, error) {
+	dbInfo, err := ctx.GetCatalog().GetDBInfoByDBId(unionPlan.DBOid())
+	if err != nil {
+		return nil, err
+	}
+	if _, err = dbInfo.TableById(unionPlan.TableOid()); err != nil {
+		return nil, err
+	}
+	return &indexUnionExecutor{
+		baseExecutor: newBaseExecutor(ctx),
+		plan:         unionPlan,
+		children:     children,
+	}, nil
+}
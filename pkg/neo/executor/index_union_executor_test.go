@@ -0,0 +1,63 @@
+package executor
+
+import (
+	"context"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestIndexUnionExecutor(t *testing.T) {
+	p := "./__test_tmp__/index_union_exec"
+	mockDb, sc, info := openBatchTestDB(t, p, 100)
+	defer func() {
+		sc.RollbackTxn(context.TODO())
+		mockDb.Close()
+		os.RemoveAll(p)
+	}()
+	table := info.TableInfo[0]
+	scan := func(offset int, v string) plan.AbstractPlan {
+		prefix := codec.IndexEntryPrefix(table.Indices[offset].ID, [][]byte{codec.EncodeCmpValue(value.NewStringValue(v))})
+		return plan.NewIndexScanPlan(model.NewIndexSchemaReader(table, offset), prefix, nil, nil, info.ID, table.ID)
+	}
+
+	// sub = user1 or sub = user2 or obj = data11, the row of data11 is also the one of user1
+	builder := NewExecutorBuilder(sc)
+	exec, err := builder.Build(plan.NewTableRowIdScan(table, info.ID, table.ID, plan.NewIndexUnionPlan(
+		[]plan.AbstractPlan{scan(0, "user1"), scan(0, "user2"), scan(1, "data11"), scan(1, "data10")}, info.ID, table.ID,
+	))), builder.Error()
+	assert.Nil(t, err)
+	result, ids, err := Execute(exec, context.TODO())
+	assert.Nil(t, err)
+
+	// the same rows in the same order as a sequential scan
+	all, allIds, err := mockDb.SeqScan(t, sc, info.ID, table.ID, table)
+	assert.Nil(t, err)
+	var expectedIds []primitive.ObjectID
+	for i, tuple := range all {
+		sub, obj := string(tuple.ValueAt(0)), string(tuple.ValueAt(1))
+		if sub == "user1" || sub == "user2" || obj == "data11" || obj == "data10" {
+			expectedIds = append(expectedIds, allIds[i])
+		}
+	}
+	assert.Len(t, expectedIds, 21)
+	assert.Equal(t, expectedIds, ids)
+	assert.Len(t, result, 21)
+
+	// a child yields nothing
+	builder = NewExecutorBuilder(sc)
+	exec, err = builder.Build(plan.NewIndexUnionPlan([]plan.AbstractPlan{scan(0, "nobody"), scan(1, "data3")}, info.ID, table.ID)), builder.Error()
+	assert.Nil(t, err)
+	_, ids, err = Execute(exec, context.TODO())
+	assert.Nil(t, err)
+	assert.Len(t, ids, 1)
+
+	builder = NewExecutorBuilder(sc)
+	builder.Build(plan.NewIndexUnionPlan(nil, info.ID, table.ID))
+	assert.Equal(t, ErrMissChildPlan, builder.Error())
+}
@@ -2,14 +2,60 @@ package executor
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casbin-mesh/neo/pkg/db"
 	"github.com/casbin-mesh/neo/pkg/neo/codec"
 	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
 	"github.com/casbin-mesh/neo/pkg/neo/model"
 	"github.com/casbin-mesh/neo/pkg/neo/session"
 	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
 	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
 )
 
+// ErrDuplicateKey is returned when a tuple has the values of a unique index another tuple has.
+type ErrDuplicateKey struct {
+	// Index is the name of the unique index.
+	Index string
+	// Value is the values of the index columns joined by '-'.
+	Value string
+}
+
+func (e *ErrDuplicateKey) Error() string {
+	return fmt.Sprintf("duplicate entry '%s' for key '%s'", e.Value, e.Index)
+}
+
+func newErrDuplicateKey(index *model.IndexInfo, columns []*model.ColumnInfo, tuple btuple.Reader) *ErrDuplicateKey {
+	values := make([]string, 0, len(index.Columns))
+	for _, column := range index.Columns {
+		values = append(values, formatValue(codec.DecodeValue(tuple.ValueAt(column.Offset), columns[column.Offset].Tp)))
+	}
+	return &ErrDuplicateKey{Index: index.Name.O, Value: strings.Join(values, "-")}
+}
+
+func formatValue(v value.Value) string {
+	switch v.Type() {
+	case bsontype.String:
+		return v.GetString()
+	case bsontype.Int32, bsontype.Int64:
+		return strconv.FormatInt(v.GetInt64(), 10)
+	case bsontype.Double:
+		return strconv.FormatFloat(v.GetDouble(), 'g', -1, 64)
+	case bsontype.Boolean:
+		return strconv.FormatBool(v.GetBoolean())
+	case bsontype.DateTime:
+		return v.GetTime().UTC().Format(time.RFC3339Nano)
+	case bsontype.Null:
+		return "NULL"
+	}
+	return string(v.GetBytes())
+}
+
 type insertExecutor struct {
 	baseExecutor
 	insertPlan    plan.InsertPlan
@@ -57,16 +103,33 @@ func (i *insertExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid *
 	}
 
 	// insert indices
-	for _, index := range i.tableInfo.Indices {
-		key, value := codec.IndexEntry(index, i.tableInfo.Columns, *tuple, *rid)
-		if err = i.GetTxn().Set(key, value); err != nil {
-			return false, err
-		}
+	if err = setIndexEntries(i.GetTxn(), i.tableInfo, *tuple, *rid); err != nil {
+		return false, err
 	}
 
 	return true, nil
 }
 
+// setIndexEntries writes the index entries of the tuple, it fails with ErrDuplicateKey if another tuple
+// has the values of a unique index. The unique keys are read by the txn, so the later one to commit
+// of two concurrent txns writing the same key fails with a conflict.
+func setIndexEntries(txn db.Txn, table *model.TableInfo, tuple btuple.Reader, rid primitive.ObjectID) error {
+	for _, index := range table.Indices {
+		key, value := codec.IndexEntry(index, table.Columns, tuple, rid)
+		if index.Unique {
+			if _, err := txn.Get(key); err == nil {
+				return newErrDuplicateKey(index, table.Columns, tuple)
+			} else if err != db.ErrKeyNotFound {
+				return err
+			}
+		}
+		if err := txn.Set(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func NewInsertExecutor(ctx session.Context, insertPlan plan.InsertPlan, child Executor) (Executor, error) {
 	dbInfo, err := ctx.GetCatalog().GetDBInfoByDBId(insertPlan.DBOid())
 	if err != nil {
@@ -2,6 +2,11 @@ package executor
 
 import (
 	"context"
+	"errors"
+	"github.com/casbin-mesh/neo/pkg/db"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/neo/utils"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"testing"
@@ -46,3 +51,92 @@ func TestInsertExecutor(t *testing.T) {
 	TuplesAsserter(t, expected, result)
 
 }
+
+func TestInsertExecutor_Unique(t *testing.T) {
+	p := "./__test_tmp__/insert_exec_unique"
+	mockDb := OpenMockDB(t, p)
+	defer func() {
+		mockDb.Close()
+		os.RemoveAll(p)
+	}()
+
+	info, err := utils.CompileModelFromString("unique", basicModelText)
+	assert.Nil(t, err)
+	table := info.TableInfo[0]
+	sc := mockDb.NewTxnAt(1, true)
+	mockDb.CreateDB(t, sc, info)
+	_, _, err = mockDb.InsertTuples(t, sc, info.ID, table.ID, mockDBDataSet)
+	assert.Nil(t, err)
+	assert.Nil(t, sc.CommitTxn(context.TODO(), 2))
+	assert.Nil(t, mockDb.WaitForMark(context.TODO(), 2))
+
+	// a committed rule
+	sc = mockDb.NewTxnAt(3, true)
+	_, _, err = mockDb.InsertTuples(t, sc, info.ID, table.ID, []value.Values{newStringValues("alice", "data1", "read")})
+	var duplicate *ErrDuplicateKey
+	assert.True(t, errors.As(err, &duplicate))
+	assert.Equal(t, &ErrDuplicateKey{Index: model.PrimaryIndexName, Value: "alice-data1-read"}, duplicate)
+	assert.Equal(t, "duplicate entry 'alice-data1-read' for key 'primary'", err.Error())
+	sc.RollbackTxn(context.TODO())
+
+	// a rule inserted by the same transaction
+	sc = mockDb.NewTxnAt(3, true)
+	_, _, err = mockDb.InsertTuples(t, sc, info.ID, table.ID, []value.Values{
+		newStringValues("carol", "data1", "read"),
+		newStringValues("carol", "data1", "read"),
+	})
+	assert.Equal(t, &ErrDuplicateKey{Index: model.PrimaryIndexName, Value: "carol-data1-read"}, err)
+	sc.RollbackTxn(context.TODO())
+
+	// the rules differ in a column
+	sc = mockDb.NewTxnAt(3, true)
+	_, _, err = mockDb.InsertTuples(t, sc, info.ID, table.ID, []value.Values{
+		newStringValues("alice", "data1", "write"),
+		newStringValues("carol", "data1", "read"),
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, sc.CommitTxn(context.TODO(), 4))
+	assert.Nil(t, mockDb.WaitForMark(context.TODO(), 4))
+
+	sc = mockDb.NewTxnAt(5, false)
+	result, _, err := mockDb.SeqScan(t, sc, info.ID, table.ID, table)
+	assert.Nil(t, err)
+	assert.Len(t, result, len(mockDBDataSet)+2)
+	sc.RollbackTxn(context.TODO())
+}
+
+func TestInsertExecutor_ConcurrentUnique(t *testing.T) {
+	p := "./__test_tmp__/insert_exec_concurrent_unique"
+	mockDb := OpenMockDB(t, p)
+	defer func() {
+		mockDb.Close()
+		os.RemoveAll(p)
+	}()
+
+	info, err := utils.CompileModelFromString("unique", basicModelText)
+	assert.Nil(t, err)
+	table := info.TableInfo[0]
+	sc := mockDb.NewTxnAt(1, true)
+	mockDb.CreateDB(t, sc, info)
+	assert.Nil(t, sc.CommitTxn(context.TODO(), 2))
+	assert.Nil(t, mockDb.WaitForMark(context.TODO(), 2))
+
+	// neither transaction sees the rule of the other one
+	rule := []value.Values{newStringValues("alice", "data1", "read")}
+	first, second := mockDb.NewTxnAt(2, true), mockDb.NewTxnAt(2, true)
+	_, _, err = mockDb.InsertTuples(t, first, info.ID, table.ID, rule)
+	assert.Nil(t, err)
+	_, _, err = mockDb.InsertTuples(t, second, info.ID, table.ID, rule)
+	assert.Nil(t, err)
+
+	// the later one to commit conflicts
+	assert.Nil(t, first.CommitTxn(context.TODO(), 3))
+	assert.Equal(t, db.ErrConflict, second.CommitTxn(context.TODO(), 4))
+	assert.Nil(t, mockDb.WaitForMark(context.TODO(), 4))
+
+	sc = mockDb.NewTxnAt(5, false)
+	result, _, err := mockDb.SeqScan(t, sc, info.ID, table.ID, table)
+	assert.Nil(t, err)
+	assert.Len(t, result, 1)
+	sc.RollbackTxn(context.TODO())
+}
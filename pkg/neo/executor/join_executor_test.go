@@ -0,0 +1,142 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/neo/utils"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestJoinExecutor(t *testing.T) {
+	p := "./__test_tmp__/join_exec"
+	mockDb := OpenMockDB(t, p)
+	defer func() {
+		mockDb.Close()
+		os.RemoveAll(p)
+	}()
+
+	info, err := utils.CompileModelFromFile("rbac", "../../../examples/assets/model/rbac_model.conf")
+	assert.Nil(t, err)
+	pTable, err := info.TableByLName("p")
+	assert.Nil(t, err)
+	gTable, err := info.TableByLName("g")
+	assert.Nil(t, err)
+	sc := mockDb.NewTxnAt(1, true)
+	mockDb.CreateDB(t, sc, info)
+	_, _, err = mockDb.InsertTuples(t, sc, info.ID, pTable.ID, []value.Values{
+		newStringValues("admin", "data1", "read"),
+		newStringValues("alice", "data2", "read"),
+		newStringValues("admin", "data2", "write"),
+	})
+	assert.Nil(t, err)
+	_, gIds, err := mockDb.InsertTuples(t, sc, info.ID, gTable.ID, []value.Values{
+		newStringValues("alice", "admin"),
+		newStringValues("carol", "user"),
+		newStringValues("bob", "admin"),
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, sc.CommitTxn(context.TODO(), 2))
+	assert.Nil(t, mockDb.WaitForMark(context.TODO(), 2))
+
+	sc = mockDb.NewTxnAt(3, false)
+	defer sc.RollbackTxn(context.TODO())
+	join := func(p plan.AbstractPlan) ([]btuple.Modifier, error) {
+		builder := executorBuilder{ctx: sc}
+		exec, err := builder.Build(p), builder.Error()
+		if err != nil {
+			return nil, err
+		}
+		result, ids, err := Execute(exec, context.TODO())
+		if err == nil && len(result) > 0 {
+			// the row id of the first outer tuple
+			assert.Equal(t, gIds[0], ids[0])
+		}
+		return result, err
+	}
+	elems := func(values ...string) []btuple.Elem {
+		result := make([]btuple.Elem, 0, len(values))
+		for _, v := range values {
+			result = append(result, btuple.Elem(v))
+		}
+		return result
+	}
+	gScan := plan.NewSeqScanPlan(gTable, nil, nil, info.ID, gTable.ID)
+	pScan := plan.NewSeqScanPlan(pTable, nil, nil, info.ID, pTable.ID)
+	// the rules of the roles, in the order of the members
+	expected := [][]btuple.Elem{
+		elems("alice", "admin", "admin", "data1", "read"),
+		elems("alice", "admin", "admin", "data2", "write"),
+		elems("bob", "admin", "admin", "data1", "read"),
+		elems("bob", "admin", "admin", "data2", "write"),
+	}
+
+	t.Run("hash join", func(t *testing.T) {
+		hashJoin, err := plan.NewHashJoinPlan(gScan, pScan, []string{"v1"}, []string{"sub"})
+		assert.Nil(t, err)
+		schema := hashJoin.OutputSchema()
+		assert.Equal(t, 5, schema.FieldsLen())
+		assert.Equal(t, 2, schema.Field("sub"))
+		result, err := join(hashJoin)
+		assert.Nil(t, err)
+		assert.Len(t, result, len(expected))
+		for i, tuple := range result {
+			assert.Equal(t, expected[i], tuple.Values())
+		}
+	})
+
+	t.Run("index nested loop join", func(t *testing.T) {
+		// the primary index of p leads by sub
+		primary := pTable.Indices[len(pTable.Indices)-1]
+		assert.Equal(t, model.PrimaryIndexName, primary.Name.L)
+		indexJoin, err := plan.NewIndexNestedLoopJoinPlan(gScan, pTable, []string{"v1"}, info.ID, pTable.ID, primary.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, 5, indexJoin.OutputSchema().FieldsLen())
+		result, err := join(indexJoin)
+		assert.Nil(t, err)
+		assert.Len(t, result, len(expected))
+		for i, tuple := range result {
+			assert.Equal(t, expected[i], tuple.Values())
+		}
+
+		// inits again, the iterator of the earlier run is closed
+		builder := executorBuilder{ctx: sc}
+		exec, err := builder.Build(indexJoin), builder.Error()
+		assert.Nil(t, err)
+		exec.Init()
+		result, _, err = Execute(exec, context.TODO())
+		assert.Nil(t, err)
+		assert.Len(t, result, len(expected))
+	})
+
+	t.Run("unique index nested loop join", func(t *testing.T) {
+		// each member looks up its own rule of g by the primary index
+		primary := gTable.Indices[len(gTable.Indices)-1]
+		indexJoin, err := plan.NewIndexNestedLoopJoinPlan(gScan, gTable, []string{"v0", "v1"}, info.ID, gTable.ID, primary.ID)
+		assert.Nil(t, err)
+		result, err := join(indexJoin)
+		assert.Nil(t, err)
+		assert.Len(t, result, 3)
+		assert.Equal(t, elems("carol", "user"), result[1].Values())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := plan.NewHashJoinPlan(gScan, pScan, []string{"v1"}, nil)
+		assert.True(t, errors.Is(err, plan.ErrInvalidJoin))
+		_, err = plan.NewHashJoinPlan(gScan, pScan, []string{"v1"}, []string{"eft"})
+		assert.True(t, errors.Is(err, plan.ErrUnknownColumn))
+		_, err = plan.NewIndexNestedLoopJoinPlan(gScan, pTable, []string{"v2"}, info.ID, pTable.ID, pTable.Indices[0].ID)
+		assert.True(t, errors.Is(err, plan.ErrUnknownColumn))
+
+		// more keys than the columns of the index
+		indexJoin, err := plan.NewIndexNestedLoopJoinPlan(gScan, gTable, []string{"v0", "v1", "v0"}, info.ID, gTable.ID, gTable.Indices[0].ID)
+		assert.Nil(t, err)
+		_, err = join(indexJoin)
+		assert.True(t, errors.Is(err, plan.ErrInvalidJoin))
+	})
+}
@@ -242,9 +242,12 @@ type mockDB struct {
 }
 
 func (db *mockDB) NewTxnAt(readTs uint64, update bool) session.Context {
-	txn := db.db.NewTransactionAt(readTs, update)
 	metaTxn := db.metaIndex.NewTransactionAt(readTs, update)
 	infoTxn := db.infoIndex.NewTransactionAt(readTs, update)
+	if !update {
+		return session.NewReadOnlySessionCtx(db.db, readTs, meta.NewInMemMeta(metaTxn), schema.New(infoTxn), &db.txnMark)
+	}
+	txn := db.db.NewTransactionAt(readTs, update)
 	return session.NewSessionCtx(txn, meta.NewInMemMeta(metaTxn), schema.New(infoTxn), &db.txnMark)
 }
 
@@ -253,7 +256,7 @@ func (db *mockDB) Close() error {
 	return db.db.Close()
 }
 
-func OpenMockDB(t *testing.T, path string) *mockDB {
+func OpenMockDB(t testing.TB, path string) *mockDB {
 	db, err := badgerAdapter.OpenManaged(badger.DefaultOptions(path))
 	assert.Nil(t, err)
 	metaIndex := index.New[any](index.Options{})
@@ -274,14 +277,14 @@ func (db *mockDB) WaitForMark(ctx context.Context, ts uint64) error {
 	return db.txnMark.WaitForMark(ctx, ts)
 }
 
-func (db *mockDB) CreateDB(t *testing.T, sc session.Context, info *model.DBInfo) {
+func (db *mockDB) CreateDB(t testing.TB, sc session.Context, info *model.DBInfo) {
 	exec := NewSchemaExec(sc, plan.NewCreateDBPlan(info))
 	exec.Init()
 	_, err := exec.Next(context.TODO(), nil, nil)
 	assert.Nil(t, err)
 }
 
-func (db *mockDB) InsertTuples(t *testing.T, sc session.Context, dbOid, tableOid uint64, tuples []value.Values) (result []btuple.Modifier, ids []primitive.ObjectID, err error) {
+func (db *mockDB) InsertTuples(t testing.TB, sc session.Context, dbOid, tableOid uint64, tuples []value.Values) (result []btuple.Modifier, ids []primitive.ObjectID, err error) {
 	builder := executorBuilder{ctx: sc}
 	executor := builder.Build(plan.NewRawInsertPlan(tuples, dbOid, tableOid))
 	assert.Nil(t, builder.Error())
@@ -3,6 +3,7 @@ package executor
 import (
 	"context"
 	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/rowset"
 	"github.com/casbin-mesh/neo/pkg/neo/model"
 	"github.com/casbin-mesh/neo/pkg/neo/session"
 	"github.com/casbin-mesh/neo/pkg/primitive"
@@ -18,20 +19,39 @@ type multiIndexScanExecutor struct {
 	left     Executor
 	right    Executor
 	prepared bool
-	// TODO: uses a container interface instead.
-	// TODO: compares performance to the other implementations, e.g., using adaptive radix tree as the container
-	hashMap map[primitive.ObjectID]btuple.Modifier
+	// the row ids of the left tuples, which are at the positions of their ids
+	rids   rowset.RowIDSet
+	tuples []btuple.Modifier
+	cancel canceler
+}
+
+// buildRowIDSet returns the set of the row ids, and the tuples at the positions of their ids in the set.
+func buildRowIDSet(rids []primitive.ObjectID, tuples []btuple.Modifier) (rowset.RowIDSet, []btuple.Modifier) {
+	set := rowset.New(append([]primitive.ObjectID(nil), rids...))
+	ordered := make([]btuple.Modifier, set.Len())
+	for i, rid := range rids {
+		j, _ := set.Index(rid)
+		ordered[j] = tuples[i]
+	}
+	return set, ordered
 }
 
 func (m *multiIndexScanExecutor) fetchAndBuildHashTable(ctx context.Context) (err error) {
 	m.left.Init()
 	m.right.Init()
-	var next bool
+	var (
+		next   bool
+		rids   []primitive.ObjectID
+		tuples []btuple.Modifier
+	)
 	for {
 		var (
 			tuple btuple.Modifier
 			rid   primitive.ObjectID
 		)
+		if err = m.cancel.check(ctx); err != nil {
+			return
+		}
 		if next, err = m.left.Next(ctx, &tuple, &rid); err != nil {
 			return
 		}
@@ -39,14 +59,15 @@ func (m *multiIndexScanExecutor) fetchAndBuildHashTable(ctx context.Context) (er
 			break
 		}
 		if !rid.IsEmpty() {
-			m.hashMap[rid] = tuple
+			rids, tuples = append(rids, rid), append(tuples, tuple)
 		}
 	}
+	m.rids, m.tuples = buildRowIDSet(rids, tuples)
 	return m.left.Close()
 }
 
 func (m *multiIndexScanExecutor) Init() {
-	m.hashMap = make(map[primitive.ObjectID]btuple.Modifier)
+	m.rids, m.tuples = nil, nil
 }
 
 func (m *multiIndexScanExecutor) probe(ctx context.Context, tuple *btuple.Modifier, rid *primitive.ObjectID) (next bool, err error) {
@@ -54,15 +75,18 @@ func (m *multiIndexScanExecutor) probe(ctx context.Context, tuple *btuple.Modifi
 		right btuple.Modifier
 	)
 	for {
+		if err = m.cancel.check(ctx); err != nil {
+			return false, err
+		}
 		if next, err = m.right.Next(ctx, &right, rid); err != nil {
 			return
 		}
 		if !next {
 			return
 		}
-		if _, ok := m.hashMap[*rid]; ok {
+		if i, ok := m.rids.Index(*rid); ok {
 			mo, _ := utils.MergeModifier(
-				m.hashMap[*rid],
+				m.tuples[i],
 				m.multiIndexScanPlan.LeftOutputSchema(),
 				right,
 				m.multiIndexScanPlan.RightOutputSchema(),
@@ -80,7 +104,6 @@ func (m *multiIndexScanExecutor) Next(ctx context.Context, tuple *btuple.Modifie
 		}
 		m.prepared = true
 	}
-	// TODO: parallel probing
 	return m.probe(ctx, tuple, rid)
 }
 
@@ -7,10 +7,12 @@ import (
 	"fmt"
 	"github.com/casbin-mesh/neo/pkg/expression"
 	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
 	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
 	"github.com/casbin-mesh/neo/pkg/neo/model"
 	"github.com/casbin-mesh/neo/pkg/parser"
 	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"testing"
@@ -42,7 +44,7 @@ func TestNewMultiIndexScanExecutor(t *testing.T) {
 	var indexId [8]byte
 	binary.BigEndian.PutUint64(indexId[:], idxId)
 	// scan from
-	indexPrefix := []byte(fmt.Sprintf("i%s_%s", indexId, "bob"))
+	indexPrefix := append([]byte(fmt.Sprintf("i%s_", indexId)), codec.EncodeCmpValue(value.NewStringValue("bob"))...)
 
 	mockExpr, accessor := expression.NewExpression(parser.MustParseFromString("p.subject == \"bob\""))
 	ctx := ast.NewContext()
@@ -54,7 +56,7 @@ func TestNewMultiIndexScanExecutor(t *testing.T) {
 	idxId = uint64(2)
 	binary.BigEndian.PutUint64(indexId[:], idxId)
 	// scan from
-	objIndexPrefix := []byte(fmt.Sprintf("i%s_%s", indexId, "data2"))
+	objIndexPrefix := append([]byte(fmt.Sprintf("i%s_", indexId)), codec.EncodeCmpValue(value.NewStringValue("data2"))...)
 
 	mockExpr2, accessor2 := expression.NewExpression(parser.MustParseFromString("p.object == \"data2\""))
 	ctx2 := ast.NewContext()
@@ -0,0 +1,370 @@
+package executor
+
+import (
+	"context"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/rowset"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/casbin-mesh/neo/pkg/primitive/utils"
+	"runtime"
+	"sync"
+)
+
+// probeChunkSize is the number of the right rows probed by a worker at a time.
+const probeChunkSize = 256
+
+// probeChunk is a chunk of the right rows, or of the matched rows of them.
+// The rows of an index scan without a predicate are its raw entries, which are
+// decoded by the workers only if they match.
+type probeChunk struct {
+	seq    int
+	tuples []btuple.Modifier
+	rids   []primitive.ObjectID
+	keys   [][]byte
+	vals   [][]byte
+}
+
+func newProbeChunk(seq int) probeChunk {
+	return probeChunk{
+		seq:    seq,
+		tuples: make([]btuple.Modifier, 0, probeChunkSize),
+		rids:   make([]primitive.ObjectID, 0, probeChunkSize),
+	}
+}
+
+func (c probeChunk) len() int {
+	return len(c.rids) + len(c.keys)
+}
+
+// parallelMultiIndexScanExecutor builds the row id set from the left child while it reads the right child,
+// the right rows are probed in chunks by the workers once the set is built. The children read concurrently
+// only if they run on snapshots of their own, a Badger txn isn't safe for concurrent use. If they share the
+// session, e.g. an update one, the right child is read after the left one.
+// The matched rows are yielded in the order of the right rows, as multiIndexScanExecutor does.
+type parallelMultiIndexScanExecutor struct {
+	baseExecutor
+	scanPlan plan.ParallelMultiIndexScan
+	left     Executor
+	right    Executor
+	workers  int
+	// snapshots are the sessions of the left and the right child, their txns are discarded by Close.
+	snapshots []session.Context
+
+	started bool
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	errOnce sync.Once
+	err     error
+
+	rids    rowset.RowIDSet
+	tuples  []btuple.Modifier
+	built   chan struct{}
+	chunks  chan probeChunk
+	results chan probeChunk
+
+	// the results received ahead of their turn, and the one being yielded
+	pending map[int]probeChunk
+	nextSeq int
+	current probeChunk
+	pos     int
+}
+
+// Init stops the goroutines of an earlier scan before it resets the children they read.
+func (m *parallelMultiIndexScanExecutor) Init() {
+	m.stop()
+	m.left.Init()
+	m.right.Init()
+	m.started, m.err = false, nil
+	m.errOnce = sync.Once{}
+	m.pending, m.nextSeq, m.current, m.pos = make(map[int]probeChunk), 0, probeChunk{}, 0
+}
+
+// fail records the first error, and stops the goroutines.
+func (m *parallelMultiIndexScanExecutor) fail(err error) {
+	m.errOnce.Do(func() {
+		m.err = err
+		m.cancel()
+	})
+}
+
+// start runs the goroutines, they live until the scan is done, fails or the context is canceled.
+func (m *parallelMultiIndexScanExecutor) start(ctx context.Context) {
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.built = make(chan struct{})
+	m.chunks = make(chan probeChunk, m.workers)
+	m.results = make(chan probeChunk, m.workers)
+	m.started = true
+
+	if len(m.snapshots) == 0 {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			defer close(m.chunks)
+			m.build(ctx)
+			m.scanRight(ctx)
+		}()
+	} else {
+		m.wg.Add(2)
+		go func() {
+			defer m.wg.Done()
+			m.build(ctx)
+		}()
+		go func() {
+			defer m.wg.Done()
+			defer close(m.chunks)
+			m.scanRight(ctx)
+		}()
+	}
+
+	var workers sync.WaitGroup
+	workers.Add(m.workers)
+	for i := 0; i < m.workers; i++ {
+		go func() {
+			defer workers.Done()
+			m.probe(ctx)
+		}()
+	}
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		workers.Wait()
+		close(m.results)
+	}()
+}
+
+func (m *parallelMultiIndexScanExecutor) build(ctx context.Context) {
+	var (
+		rids   []primitive.ObjectID
+		tuples []btuple.Modifier
+	)
+	for ctx.Err() == nil {
+		var (
+			tuple btuple.Modifier
+			rid   primitive.ObjectID
+		)
+		next, err := m.left.Next(ctx, &tuple, &rid)
+		if err != nil {
+			m.fail(err)
+			return
+		}
+		if !next {
+			m.rids, m.tuples = buildRowIDSet(rids, tuples)
+			close(m.built)
+			return
+		}
+		if !rid.IsEmpty() {
+			rids, tuples = append(rids, rid), append(tuples, tuple)
+		}
+	}
+}
+
+// rawEntries returns the index scan of the right child if its entries can be probed before they are decoded.
+func (m *parallelMultiIndexScanExecutor) rawEntries() *indexScanExecutor {
+	if scan, ok := m.right.(*indexScanExecutor); ok && scan.indexScanPlan.Predicate() == nil {
+		return scan
+	}
+	return nil
+}
+
+func (m *parallelMultiIndexScanExecutor) scanRight(ctx context.Context) {
+	scan := m.rawEntries()
+	chunk := newProbeChunk(0)
+	send := func() bool {
+		select {
+		case m.chunks <- chunk:
+			chunk = newProbeChunk(chunk.seq + 1)
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	for ctx.Err() == nil {
+		var next bool
+		if scan != nil {
+			key, val, err := scan.nextEntry()
+			if err != nil {
+				m.fail(err)
+				return
+			}
+			if next = key != nil; next {
+				chunk.keys, chunk.vals = append(chunk.keys, key), append(chunk.vals, val)
+			}
+		} else {
+			var (
+				tuple btuple.Modifier
+				rid   primitive.ObjectID
+				err   error
+			)
+			if next, err = m.right.Next(ctx, &tuple, &rid); err != nil {
+				m.fail(err)
+				return
+			}
+			if next {
+				chunk.tuples, chunk.rids = append(chunk.tuples, tuple), append(chunk.rids, rid)
+			}
+		}
+		if !next {
+			if chunk.len() > 0 {
+				send()
+			}
+			return
+		}
+		if chunk.len() == probeChunkSize && !send() {
+			return
+		}
+	}
+}
+
+// probeChunk returns the matched rows of the chunk.
+func (m *parallelMultiIndexScanExecutor) probeChunk(chunk probeChunk) (matched probeChunk, err error) {
+	matched.seq = chunk.seq
+	merge := func(left, right btuple.Modifier, rid primitive.ObjectID) {
+		mo, _ := utils.MergeModifier(
+			left,
+			m.scanPlan.LeftOutputSchema(),
+			right,
+			m.scanPlan.RightOutputSchema(),
+		)
+		matched.tuples, matched.rids = append(matched.tuples, mo), append(matched.rids, rid)
+	}
+	for i, rid := range chunk.rids {
+		if j, ok := m.rids.Index(rid); ok {
+			merge(m.tuples[j], chunk.tuples[i], rid)
+		}
+	}
+	if len(chunk.keys) == 0 {
+		return
+	}
+	indexInfo := m.rawEntries().indexInfo
+	for i, key := range chunk.keys {
+		var rid primitive.ObjectID
+		if rid, err = codec.DecodeIndexEntryRid(indexInfo, key, chunk.vals[i]); err != nil {
+			return
+		}
+		if j, ok := m.rids.Index(rid); ok {
+			var right btuple.Modifier
+			if right, _, err = codec.DecodeIndexEntry(indexInfo, key, chunk.vals[i]); err != nil {
+				return
+			}
+			merge(m.tuples[j], right, rid)
+		}
+	}
+	return
+}
+
+func (m *parallelMultiIndexScanExecutor) probe(ctx context.Context) {
+	select {
+	case <-m.built:
+	case <-ctx.Done():
+		return
+	}
+	for {
+		var (
+			chunk probeChunk
+			ok    bool
+		)
+		select {
+		case chunk, ok = <-m.chunks:
+			if !ok {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+
+		// an empty result keeps the turns of the chunks
+		matched, err := m.probeChunk(chunk)
+		if err != nil {
+			m.fail(err)
+			return
+		}
+		select {
+		case m.results <- matched:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Next yields the matched rows, the goroutines run with the context of the first call.
+func (m *parallelMultiIndexScanExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid *primitive.ObjectID) (next bool, err error) {
+	if !m.started {
+		m.start(ctx)
+	}
+	for {
+		if m.pos < len(m.current.rids) {
+			*tuple, *rid = m.current.tuples[m.pos], m.current.rids[m.pos]
+			m.pos++
+			return true, nil
+		}
+		if chunk, ok := m.pending[m.nextSeq]; ok {
+			delete(m.pending, m.nextSeq)
+			m.current, m.pos = chunk, 0
+			m.nextSeq++
+			continue
+		}
+		select {
+		case chunk, ok := <-m.results:
+			if !ok {
+				// the workers are done, they stop early only if the scan fails or ctx is done
+				m.wg.Wait()
+				if m.err != nil {
+					return false, m.err
+				}
+				return false, canceledError(ctx)
+			}
+			m.pending[chunk.seq] = chunk
+		case <-ctx.Done():
+			return false, canceledError(ctx)
+		}
+	}
+}
+
+// stop cancels the goroutines and waits for them.
+func (m *parallelMultiIndexScanExecutor) stop() {
+	if m.started {
+		m.cancel()
+		m.wg.Wait()
+		m.started = false
+	}
+}
+
+// Close stops the goroutines, then closes the children and discards the txns of their snapshots.
+func (m *parallelMultiIndexScanExecutor) Close() error {
+	m.stop()
+	err := m.left.Close()
+	if rErr := m.right.Close(); err == nil {
+		err = rErr
+	}
+	for _, snapshot := range m.snapshots {
+		snapshot.RollbackTxn(context.TODO())
+	}
+	return err
+}
+
+// NewParallelMultiIndexScanExecutor returns the executor of the scan, the children run on the snapshots
+// if they are given, one for each of them, otherwise on ctx.
+func NewParallelMultiIndexScanExecutor(ctx session.Context, scanPlan plan.ParallelMultiIndexScan, left, right Executor, snapshots ...session.Context) (Executor, error) {
+	dbInfo, err := ctx.GetCatalog().GetDBInfoByDBId(scanPlan.DBOid())
+	if err != nil {
+		return nil, err
+	}
+	if _, err = dbInfo.TableById(scanPlan.TableOid()); err != nil {
+		return nil, err
+	}
+	workers := scanPlan.Workers()
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return &parallelMultiIndexScanExecutor{
+		baseExecutor: newBaseExecutor(ctx),
+		scanPlan:     scanPlan,
+		left:         left,
+		right:        right,
+		workers:      workers,
+		snapshots:    snapshots,
+	}, nil
+}
@@ -0,0 +1,214 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+// intersectPlans returns the index scans of sub = user3 and act = read, the latter yields all the rows.
+func intersectPlans(info *model.DBInfo) []plan.AbstractPlan {
+	table := info.TableInfo[0]
+	scan := func(offset int, v string) plan.AbstractPlan {
+		prefix := codec.IndexEntryPrefix(table.Indices[offset].ID, [][]byte{codec.EncodeCmpValue(value.NewStringValue(v))})
+		return plan.NewIndexScanPlan(model.NewIndexSchemaReader(table, offset), prefix, nil, nil, info.ID, table.ID)
+	}
+	return []plan.AbstractPlan{scan(0, "user3"), scan(2, "read")}
+}
+
+// snapshotsOf returns two snapshots of the read-only session, the children of a parallel scan read them concurrently.
+func snapshotsOf(t *testing.T, sc session.Context) []session.Context {
+	left, ok := sc.Snapshot()
+	assert.True(t, ok)
+	right, ok := sc.Snapshot()
+	assert.True(t, ok)
+	return []session.Context{left, right}
+}
+
+func TestParallelMultiIndexScanExecutor(t *testing.T) {
+	p := "./__test_tmp__/parallel_multi_index_scan_exec"
+	mockDb, sc, info := openBatchTestDB(t, p, 1000)
+	defer func() {
+		sc.RollbackTxn(context.TODO())
+		mockDb.Close()
+		os.RemoveAll(p)
+	}()
+	table := info.TableInfo[0]
+
+	builder := NewExecutorBuilder(sc)
+	exec, err := builder.Build(plan.NewMultiIndexScan(intersectPlans(info), info.ID, table.ID)), builder.Error()
+	assert.Nil(t, err)
+	expected, expectedIds, err := Execute(exec, context.TODO())
+	assert.Nil(t, err)
+	assert.Len(t, expected, 100)
+
+	// the same rows in the same order, whatever the number of the workers
+	for _, workers := range []int{0, 1, 2, 8} {
+		builder = NewExecutorBuilder(sc)
+		exec, err = builder.Build(plan.NewParallelMultiIndexScan(intersectPlans(info), info.ID, table.ID, workers)), builder.Error()
+		assert.Nil(t, err)
+		assert.IsType(t, &parallelMultiIndexScanExecutor{}, exec)
+		// the children read snapshots of their own
+		parallel := exec.(*parallelMultiIndexScanExecutor)
+		assert.Len(t, parallel.snapshots, 2)
+		assert.NotSame(t, parallel.snapshots[0], parallel.snapshots[1])
+		assert.Same(t, parallel.snapshots[0], parallel.left.(*indexScanExecutor).GetSessionCtx())
+		assert.Same(t, parallel.snapshots[1], parallel.right.(*indexScanExecutor).GetSessionCtx())
+		result, ids, err := Execute(exec, context.TODO())
+		assert.Nil(t, err)
+		assert.Equal(t, expectedIds, ids)
+		assert.Equal(t, expected, result)
+	}
+
+	// closes before all the rows are read
+	builder = NewExecutorBuilder(sc)
+	exec, err = builder.Build(plan.NewLimitPlan(
+		[]plan.AbstractPlan{plan.NewParallelMultiIndexScan(intersectPlans(info), info.ID, table.ID, 2)}, 10,
+	)), builder.Error()
+	assert.Nil(t, err)
+	_, ids, err := Execute(exec, context.TODO())
+	assert.Nil(t, err)
+	assert.Equal(t, expectedIds[:10], ids)
+
+	// inits again in the middle of the scan
+	builder = NewExecutorBuilder(sc)
+	exec, err = builder.Build(plan.NewParallelMultiIndexScan(intersectPlans(info), info.ID, table.ID, 2)), builder.Error()
+	assert.Nil(t, err)
+	exec.Init()
+	var (
+		tuple btuple.Modifier
+		rid   primitive.ObjectID
+	)
+	for i := 0; i < 10; i++ {
+		next, err := exec.Next(context.TODO(), &tuple, &rid)
+		assert.Nil(t, err)
+		assert.True(t, next)
+	}
+	result, ids, err := Execute(exec, context.TODO())
+	assert.Nil(t, err)
+	assert.Equal(t, expectedIds, ids)
+	assert.Equal(t, expected, result)
+
+	// the children share an update session, whose pending writes are invisible to a snapshot,
+	// the right one is read after the left one
+	update := mockDb.NewTxnAt(3, true) // the rows are committed at 3
+	defer update.RollbackTxn(context.TODO())
+	_, _, err = mockDb.InsertTuples(t, update, info.ID, table.ID, []value.Values{newStringValues("user3", "data", "read")})
+	assert.Nil(t, err)
+	_, ok := update.Snapshot()
+	assert.False(t, ok)
+	builder = NewExecutorBuilder(update)
+	exec, err = builder.Build(plan.NewParallelMultiIndexScan(intersectPlans(info), info.ID, table.ID, 2)), builder.Error()
+	assert.Nil(t, err)
+	assert.Empty(t, exec.(*parallelMultiIndexScanExecutor).snapshots)
+	_, ids, err = Execute(exec, context.TODO())
+	assert.Nil(t, err)
+	assert.Len(t, ids, len(expectedIds)+1)
+}
+
+// failedExecutor yields its rows, then fails, or blocks until the context is done if err is nil.
+type failedExecutor struct {
+	mockTuplesExecutor
+	err error
+}
+
+func (f *failedExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid *primitive.ObjectID) (bool, error) {
+	if next, _ := f.mockTuplesExecutor.Next(ctx, tuple, rid); next {
+		return true, nil
+	}
+	if f.err != nil {
+		return false, f.err
+	}
+	<-ctx.Done()
+	return false, ctx.Err()
+}
+
+func TestParallelMultiIndexScanExecutor_Error(t *testing.T) {
+	p := "./__test_tmp__/parallel_multi_index_scan_exec_error"
+	mockDb, sc, info := openBatchTestDB(t, p, 10)
+	defer func() {
+		sc.RollbackTxn(context.TODO())
+		mockDb.Close()
+		os.RemoveAll(p)
+	}()
+	scanPlan := plan.NewParallelMultiIndexScan(intersectPlans(info), info.ID, info.TableInfo[0].ID, 2)
+	// fewer rows than a chunk, the failed side is not held back by the bounded chunks
+	rows := &mockTuplesExecutor{}
+	for i := 0; i < 10; i++ {
+		rows.tuples = append(rows.tuples, newTestTuple("alice", "data1"))
+		rows.rids = append(rows.rids, primitive.NewObjectID())
+	}
+	errScan := errors.New("scan failed")
+
+	// either side fails while the other one blocks
+	for _, side := range []int{0, 1} {
+		children := []*failedExecutor{{mockTuplesExecutor: *rows}, {mockTuplesExecutor: *rows}}
+		children[side].err = errScan
+		blocked := &children[1-side].mockTuplesExecutor
+		blocked.tuples, blocked.rids = rows.tuples[:1], rows.rids[:1]
+
+		exec, err := NewParallelMultiIndexScanExecutor(sc, scanPlan, children[0], children[1], snapshotsOf(t, sc)...)
+		assert.Nil(t, err)
+		_, _, err = Execute(exec, context.TODO())
+		assert.Equal(t, errScan, err)
+		assert.True(t, children[0].closed)
+		assert.True(t, children[1].closed)
+	}
+
+	// the context is canceled while the children block
+	left, right := &failedExecutor{}, &failedExecutor{}
+	exec, err := NewParallelMultiIndexScanExecutor(sc, scanPlan, left, right, snapshotsOf(t, sc)...)
+	assert.Nil(t, err)
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err = Execute(exec, ctx)
+	assert.Equal(t, &ErrCanceled{Err: context.DeadlineExceeded}, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, left.closed)
+	assert.True(t, right.closed)
+}
+
+func BenchmarkMultiIndexScan(b *testing.B) {
+	p := "./__test_tmp__/multi_index_scan_bench"
+	mockDb, sc, info := openBatchTestDB(b, p, 100000)
+	defer func() {
+		sc.RollbackTxn(context.TODO())
+		mockDb.Close()
+		os.RemoveAll(p)
+	}()
+	table := info.TableInfo[0]
+
+	for _, c := range []struct {
+		name string
+		plan func() plan.AbstractPlan
+	}{
+		{"sequential", func() plan.AbstractPlan { return plan.NewMultiIndexScan(intersectPlans(info), info.ID, table.ID) }},
+		{"parallel", func() plan.AbstractPlan {
+			return plan.NewParallelMultiIndexScan(intersectPlans(info), info.ID, table.ID, 0)
+		}},
+	} {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				builder := NewExecutorBuilder(sc)
+				exec, err := builder.Build(c.plan()), builder.Error()
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, _, err = Execute(exec, context.TODO()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
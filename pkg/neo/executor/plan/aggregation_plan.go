@@ -0,0 +1,127 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"github.com/casbin-mesh/neo/pkg/primitive/bschema"
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+)
+
+var (
+	ErrInvalidAggregate = errors.New("invalid aggregate")
+)
+
+type AggregateType int
+
+const (
+	// AggregateCount counts the non-NULL values of the column, or the tuples if the column is empty.
+	AggregateCount AggregateType = iota + 1
+	// AggregateCountDistinct counts the distinct non-NULL values of the column.
+	AggregateCountDistinct
+	// AggregateMin is the minimum non-NULL value of the column, or NULL if there is none.
+	AggregateMin
+	// AggregateMax is the maximum non-NULL value of the column, or NULL if there is none.
+	AggregateMax
+)
+
+func (t AggregateType) String() string {
+	switch t {
+	case AggregateCount:
+		return "count"
+	case AggregateCountDistinct:
+		return "count distinct"
+	case AggregateMin:
+		return "min"
+	case AggregateMax:
+		return "max"
+	}
+	return "unknown"
+}
+
+// Aggregate is an aggregate function over a column of the child tuples.
+type Aggregate struct {
+	Type   AggregateType
+	Column string
+}
+
+// Name returns the name of the output column of the aggregate, e.g. count(*), min(priority).
+func (a Aggregate) Name() string {
+	column := a.Column
+	if column == "" {
+		column = "*"
+	}
+	if a.Type == AggregateCountDistinct {
+		return "count(distinct " + column + ")"
+	}
+	return a.Type.String() + "(" + column + ")"
+}
+
+type AggregationPlan interface {
+	AbstractPlan
+	// GroupBy returns the offsets of the group columns in the child output schema.
+	GroupBy() []int
+	Aggregates() []Aggregate
+	// AggregateOffsets returns the offsets of the aggregated columns in the child output schema, -1 for COUNT(*).
+	AggregateOffsets() []int
+}
+
+type aggregationPlan struct {
+	AbstractPlan
+	groupBy          []int
+	aggregates       []Aggregate
+	aggregateOffsets []int
+}
+
+func (a aggregationPlan) GroupBy() []int {
+	return a.groupBy
+}
+
+func (a aggregationPlan) Aggregates() []Aggregate {
+	return a.aggregates
+}
+
+func (a aggregationPlan) AggregateOffsets() []int {
+	return a.aggregateOffsets
+}
+
+// NewAggregationPlan returns the plan groups the child tuples by the group columns, and yields a tuple
+// per group, the group columns followed by the aggregates. Without group columns, it yields a single tuple
+// even if there are no child tuples.
+func NewAggregationPlan(child AbstractPlan, groupBy []string, aggregates []Aggregate) (AggregationPlan, error) {
+	input := InputSchema(child)
+	if input == nil {
+		return nil, fmt.Errorf("%w: the child plan has no output schema", ErrUnknownColumn)
+	}
+	schema := bschema.NewReaderWriter()
+	p := &aggregationPlan{aggregates: aggregates}
+	for _, column := range groupBy {
+		idx := input.Field(column)
+		if idx < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownColumn, column)
+		}
+		p.groupBy = append(p.groupBy, idx)
+		schema.AppendFromField(input.FieldAt(idx))
+	}
+	for _, aggregate := range aggregates {
+		if aggregate.Type < AggregateCount || aggregate.Type > AggregateMax {
+			return nil, fmt.Errorf("%w: unknown type %d", ErrInvalidAggregate, aggregate.Type)
+		}
+		idx := -1
+		if aggregate.Column != "" {
+			if idx = input.Field(aggregate.Column); idx < 0 {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownColumn, aggregate.Column)
+			}
+		} else if aggregate.Type != AggregateCount {
+			return nil, fmt.Errorf("%w: %s needs a column", ErrInvalidAggregate, aggregate.Type)
+		}
+		p.aggregateOffsets = append(p.aggregateOffsets, idx)
+
+		tp := bsontype.Int64
+		if aggregate.Type == AggregateMin || aggregate.Type == AggregateMax {
+			tp = input.FieldAt(idx).Type()
+		}
+		schema.Append(tp, []byte(aggregate.Name()), nil)
+	}
+	p.AbstractPlan = NewAbstractPlan(schema, []AbstractPlan{child})
+	return p, nil
+}
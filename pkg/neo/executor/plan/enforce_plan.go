@@ -0,0 +1,68 @@
+package plan
+
+import (
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+)
+
+// EnforcePlan evaluates the matcher on the candidate policy tuples yielded by its child,
+// and folds the results into a single decision following the matcher's effect policy.
+type EnforcePlan interface {
+	AbstractPlan
+	Matcher() *model.MatcherInfo
+	// Predicate returns the predicate the candidates are evaluated by, it's the predicate
+	// of the matcher, unless the child has already answered some of its conjuncts.
+	// A nil predicate matches every candidate.
+	Predicate() ast.Evaluable
+	// Request returns the request tuple, its elements follow the matcher's request fields.
+	Request() btuple.Reader
+	DBOid() uint64
+	GetEvalCtx() *ast.Context
+}
+
+type enforcePlan struct {
+	AbstractPlan
+	matcher   *model.MatcherInfo
+	predicate ast.Evaluable
+	request   btuple.Reader
+	dbOid     uint64
+	ctx       *ast.Context
+}
+
+func (e enforcePlan) Matcher() *model.MatcherInfo {
+	return e.matcher
+}
+
+func (e enforcePlan) Predicate() ast.Evaluable {
+	return e.predicate
+}
+
+func (e enforcePlan) Request() btuple.Reader {
+	return e.request
+}
+
+func (e enforcePlan) DBOid() uint64 {
+	return e.dbOid
+}
+
+func (e enforcePlan) GetEvalCtx() *ast.Context {
+	return e.ctx
+}
+
+func NewEnforcePlan(children []AbstractPlan, matcher *model.MatcherInfo, request btuple.Reader, ctx *ast.Context, dbOid uint64) EnforcePlan {
+	return NewFilteredEnforcePlan(children, matcher, matcher.Predicate, request, ctx, dbOid)
+}
+
+// NewFilteredEnforcePlan returns an EnforcePlan evaluates the predicate instead of the
+// predicate of the matcher, on the candidates of the child the two predicates must agree.
+func NewFilteredEnforcePlan(children []AbstractPlan, matcher *model.MatcherInfo, predicate ast.Evaluable, request btuple.Reader, ctx *ast.Context, dbOid uint64) EnforcePlan {
+	return &enforcePlan{
+		AbstractPlan: NewAbstractPlan(nil, children),
+		matcher:      matcher,
+		predicate:    predicate,
+		request:      request,
+		dbOid:        dbOid,
+		ctx:          ctx,
+	}
+}
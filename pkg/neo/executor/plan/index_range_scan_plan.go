@@ -0,0 +1,82 @@
+package plan
+
+import (
+	"github.com/casbin-mesh/neo/pkg/expression"
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/primitive/bschema"
+)
+
+// IndexRange bounds the keys of the index entries. A key is in the range if it is after Start
+// and before End, the keys prefixed by an inclusive bound are in the range as well, e.g. the
+// entries of a value are all in the range ending at the inclusive key of the value.
+// A nil Start or End leaves the range open on the side.
+type IndexRange struct {
+	Start          []byte
+	StartInclusive bool
+	End            []byte
+	EndInclusive   bool
+}
+
+type IndexRangeScanPlan interface {
+	AbstractPlan
+	Predicate() expression.Expression
+	DBOid() uint64
+	TableOid() uint64
+	IndexOid() uint64
+	Range() IndexRange
+	// Reverse is true if the entries are yielded in the descending order of their keys.
+	Reverse() bool
+	GetEvalCtx() ast.EvaluateCtx
+}
+
+type indexRangeScanPlan struct {
+	AbstractPlan
+	tableOid  uint64
+	dbOid     uint64
+	indexOid  uint64
+	r         IndexRange
+	reverse   bool
+	predicate expression.Expression
+	ctx       ast.EvaluateCtx
+}
+
+func (s indexRangeScanPlan) GetEvalCtx() ast.EvaluateCtx {
+	return s.ctx
+}
+
+func (s indexRangeScanPlan) Range() IndexRange {
+	return s.r
+}
+
+func (s indexRangeScanPlan) Reverse() bool {
+	return s.reverse
+}
+
+func (s indexRangeScanPlan) Predicate() expression.Expression {
+	return s.predicate
+}
+
+func (s indexRangeScanPlan) IndexOid() uint64 {
+	return s.indexOid
+}
+
+func (s indexRangeScanPlan) TableOid() uint64 {
+	return s.tableOid
+}
+
+func (s indexRangeScanPlan) DBOid() uint64 {
+	return s.dbOid
+}
+
+func NewIndexRangeScanPlan(schema bschema.Reader, indexOid uint64, r IndexRange, reverse bool, predicate expression.Expression, ctx ast.EvaluateCtx, dbOid, tableOid uint64) IndexRangeScanPlan {
+	return &indexRangeScanPlan{
+		AbstractPlan: NewAbstractPlan(schema, nil),
+		indexOid:     indexOid,
+		r:            r,
+		reverse:      reverse,
+		predicate:    predicate,
+		dbOid:        dbOid,
+		tableOid:     tableOid,
+		ctx:          ctx,
+	}
+}
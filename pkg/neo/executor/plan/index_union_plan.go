@@ -0,0 +1,32 @@
+package plan
+
+// IndexUnionPlan yields the row ids in any of its children, e.g., the index scans of the
+// disjuncts of a matcher, in ascending order and without duplicates.
+// It yields no tuples, as the children may scan different indexes, the rows are fetched by its parent.
+type IndexUnionPlan interface {
+	AbstractPlan
+	DBOid() uint64
+	TableOid() uint64
+}
+
+type indexUnionPlan struct {
+	AbstractPlan
+	tableOid uint64
+	dbOid    uint64
+}
+
+func (p indexUnionPlan) TableOid() uint64 {
+	return p.tableOid
+}
+
+func (p indexUnionPlan) DBOid() uint64 {
+	return p.dbOid
+}
+
+func NewIndexUnionPlan(children []AbstractPlan, dbOid, tableOid uint64) IndexUnionPlan {
+	return &indexUnionPlan{
+		AbstractPlan: NewAbstractPlan(nil, children),
+		tableOid:     tableOid,
+		dbOid:        dbOid,
+	}
+}
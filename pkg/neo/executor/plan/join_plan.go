@@ -0,0 +1,147 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"github.com/casbin-mesh/neo/pkg/primitive/bschema"
+	"github.com/casbin-mesh/neo/pkg/primitive/utils"
+)
+
+var (
+	ErrInvalidJoin = errors.New("invalid join")
+)
+
+// joinKeys returns the offsets of the key columns in the schema.
+func joinKeys(schema bschema.Reader, keys []string) ([]int, error) {
+	if schema == nil {
+		return nil, fmt.Errorf("%w: the child plan has no output schema", ErrUnknownColumn)
+	}
+	offsets := make([]int, 0, len(keys))
+	for _, key := range keys {
+		idx := schema.Field(key)
+		if idx < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownColumn, key)
+		}
+		offsets = append(offsets, idx)
+	}
+	return offsets, nil
+}
+
+type HashJoinPlan interface {
+	AbstractPlan
+	// LeftKeys returns the offsets of the key columns in the left output schema.
+	LeftKeys() []int
+	// RightKeys returns the offsets of the key columns in the right output schema.
+	RightKeys() []int
+	LeftOutputSchema() bschema.Reader
+	RightOutputSchema() bschema.Reader
+}
+
+type hashJoinPlan struct {
+	AbstractPlan
+	left, right         bschema.Reader
+	leftKeys, rightKeys []int
+}
+
+func (h hashJoinPlan) LeftKeys() []int {
+	return h.leftKeys
+}
+
+func (h hashJoinPlan) RightKeys() []int {
+	return h.rightKeys
+}
+
+func (h hashJoinPlan) LeftOutputSchema() bschema.Reader {
+	return h.left
+}
+
+func (h hashJoinPlan) RightOutputSchema() bschema.Reader {
+	return h.right
+}
+
+// NewHashJoinPlan returns the plan joins the tuples of left and right whose key columns are equal,
+// the right tuples build the hash table. The output schema is merged by utils.MergeSchema,
+// the right columns named as left ones are dropped.
+func NewHashJoinPlan(left, right AbstractPlan, leftKeys, rightKeys []string) (HashJoinPlan, error) {
+	if len(leftKeys) == 0 || len(leftKeys) != len(rightKeys) {
+		return nil, fmt.Errorf("%w: %d left keys and %d right keys", ErrInvalidJoin, len(leftKeys), len(rightKeys))
+	}
+	p := &hashJoinPlan{left: InputSchema(left), right: InputSchema(right)}
+	var err error
+	if p.leftKeys, err = joinKeys(p.left, leftKeys); err != nil {
+		return nil, err
+	}
+	if p.rightKeys, err = joinKeys(p.right, rightKeys); err != nil {
+		return nil, err
+	}
+	p.AbstractPlan = NewAbstractPlan(utils.MergeSchema(p.left, p.right), []AbstractPlan{left, right})
+	return p, nil
+}
+
+type IndexNestedLoopJoinPlan interface {
+	AbstractPlan
+	DBOid() uint64
+	// TableOid returns the oid of the inner table.
+	TableOid() uint64
+	// IndexOid returns the oid of the index of the inner table probed per outer tuple.
+	IndexOid() uint64
+	// OuterKeys returns the offsets of the outer columns compared to the leading columns of the index.
+	OuterKeys() []int
+	OuterOutputSchema() bschema.Reader
+	InnerOutputSchema() bschema.Reader
+}
+
+type indexNestedLoopJoinPlan struct {
+	AbstractPlan
+	outer, inner bschema.Reader
+	outerKeys    []int
+	dbOid        uint64
+	tableOid     uint64
+	indexOid     uint64
+}
+
+func (i indexNestedLoopJoinPlan) DBOid() uint64 {
+	return i.dbOid
+}
+
+func (i indexNestedLoopJoinPlan) TableOid() uint64 {
+	return i.tableOid
+}
+
+func (i indexNestedLoopJoinPlan) IndexOid() uint64 {
+	return i.indexOid
+}
+
+func (i indexNestedLoopJoinPlan) OuterKeys() []int {
+	return i.outerKeys
+}
+
+func (i indexNestedLoopJoinPlan) OuterOutputSchema() bschema.Reader {
+	return i.outer
+}
+
+func (i indexNestedLoopJoinPlan) InnerOutputSchema() bschema.Reader {
+	return i.inner
+}
+
+// NewIndexNestedLoopJoinPlan returns the plan joins the outer tuples with the rows of the inner table,
+// the rows are looked up by the index whose leading columns equal the outer keys. The output schema
+// is merged by utils.MergeSchema as the hash join's.
+func NewIndexNestedLoopJoinPlan(outer AbstractPlan, inner bschema.Reader, outerKeys []string, dbOid, tableOid, indexOid uint64) (IndexNestedLoopJoinPlan, error) {
+	if len(outerKeys) == 0 {
+		return nil, fmt.Errorf("%w: no outer keys", ErrInvalidJoin)
+	}
+	p := &indexNestedLoopJoinPlan{
+		outer:    InputSchema(outer),
+		inner:    inner,
+		dbOid:    dbOid,
+		tableOid: tableOid,
+		indexOid: indexOid,
+	}
+	var err error
+	if p.outerKeys, err = joinKeys(p.outer, outerKeys); err != nil {
+		return nil, err
+	}
+	p.AbstractPlan = NewAbstractPlan(utils.MergeSchema(p.outer, p.inner), []AbstractPlan{outer})
+	return p, nil
+}
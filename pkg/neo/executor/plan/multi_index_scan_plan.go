@@ -49,3 +49,27 @@ func NewMultiIndexScan(children []AbstractPlan, dbOid, tableOid uint64) MultiInd
 		dbOid:    dbOid,
 	}
 }
+
+// ParallelMultiIndexScan is a multi-index scan runs its children concurrently,
+// and probes the right rows by a pool of workers.
+type ParallelMultiIndexScan interface {
+	MultiIndexScan
+	// Workers returns the number of the probe workers, 0 for GOMAXPROCS.
+	Workers() int
+}
+
+type parallelMultiIndexPlan struct {
+	MultiIndexScan
+	workers int
+}
+
+func (p parallelMultiIndexPlan) Workers() int {
+	return p.workers
+}
+
+func NewParallelMultiIndexScan(children []AbstractPlan, dbOid, tableOid uint64, workers int) ParallelMultiIndexScan {
+	return &parallelMultiIndexPlan{
+		MultiIndexScan: NewMultiIndexScan(children, dbOid, tableOid),
+		workers:        workers,
+	}
+}
@@ -0,0 +1,78 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"github.com/casbin-mesh/neo/pkg/expression"
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/primitive/bschema"
+)
+
+var (
+	ErrUnknownColumn = errors.New("unknown column")
+)
+
+type ProjectionPlan interface {
+	AbstractPlan
+	// Exprs returns the expressions of the output columns, in the order of the output schema.
+	// A column expression copies the column of the child tuple, the others are evaluated
+	// and encoded as the types of their output columns.
+	Exprs() []expression.Expression
+	GetEvalCtx() ast.EvaluateCtx
+}
+
+type projectionPlan struct {
+	AbstractPlan
+	exprs []expression.Expression
+	ctx   ast.EvaluateCtx
+}
+
+func (p projectionPlan) Exprs() []expression.Expression {
+	return p.exprs
+}
+
+func (p projectionPlan) GetEvalCtx() ast.EvaluateCtx {
+	return p.ctx
+}
+
+func NewProjectionPlan(children []AbstractPlan, schema bschema.Reader, exprs []expression.Expression, ctx ast.EvaluateCtx) ProjectionPlan {
+	return &projectionPlan{
+		AbstractPlan: NewAbstractPlan(schema, children),
+		exprs:        exprs,
+		ctx:          ctx,
+	}
+}
+
+// NewColumnsProjectionPlan returns the projection of the columns of the child output schema, in the order of columns.
+func NewColumnsProjectionPlan(child AbstractPlan, columns ...string) (ProjectionPlan, error) {
+	input := InputSchema(child)
+	if input == nil {
+		return nil, fmt.Errorf("%w: the child plan has no output schema", ErrUnknownColumn)
+	}
+	schema := bschema.NewReaderWriter()
+	exprs := make([]expression.Expression, 0, len(columns))
+	for _, column := range columns {
+		idx := input.Field(column)
+		if idx < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownColumn, column)
+		}
+		schema.AppendFromField(input.FieldAt(idx))
+		exprs = append(exprs, expression.NewColumnExpression(column))
+	}
+	return NewProjectionPlan([]AbstractPlan{child}, schema, exprs, nil), nil
+}
+
+// InputSchema returns the schema of the tuples yielded by the plan, the plans without
+// an output schema, e.g. a limit plan, yield the tuples of their first child.
+func InputSchema(p AbstractPlan) bschema.Reader {
+	for p != nil {
+		if schema := p.OutputSchema(); schema != nil {
+			return schema
+		}
+		if !p.HasChildren() {
+			return nil
+		}
+		p = p.GetChildAt(0)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/casbin-mesh/neo/pkg/expression"
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/bschema"
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
+)
+
+var (
+	ErrInvalidProjection = errors.New("invalid projection")
+)
+
+type projectionExecutor struct {
+	baseExecutor
+	projectionPlan plan.ProjectionPlan
+	childExecutor  Executor
+	// inputSchema is the schema of the child tuples
+	inputSchema bschema.Reader
+	// offsets are the child columns copied to the output columns, -1 for the evaluated ones
+	offsets []int
+}
+
+func (p *projectionExecutor) Init() {
+	p.childExecutor.Init()
+}
+
+func (p *projectionExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid *primitive.ObjectID) (next bool, err error) {
+	var child btuple.Modifier
+	if next, err = p.childExecutor.Next(ctx, &child, rid); !next || err != nil {
+		return
+	}
+
+	schema := p.projectionPlan.OutputSchema()
+	elems := make([]btuple.Elem, len(p.offsets))
+	for i, offset := range p.offsets {
+		if offset >= 0 {
+			if child.Occupied(offset) {
+				elems[i] = child.ValueAt(offset)
+			}
+			continue
+		}
+		res, err := p.projectionPlan.Exprs()[i].Evaluate(p.GetSessionCtx(), p.projectionPlan.GetEvalCtx(), child, p.inputSchema)
+		if err != nil {
+			return false, err
+		}
+		v, err := primitiveValue(res, schema.FieldAt(i).Type())
+		if err != nil {
+			return false, fmt.Errorf("%w: column %s: %v", ErrInvalidProjection, schema.FieldAt(i).Name(), err)
+		}
+		elems[i] = codec.EncodeValue(v)
+	}
+	*tuple = btuple.NewModifier(elems)
+	return true, nil
+}
+
+func (p *projectionExecutor) Close() error {
+	return p.childExecutor.Close()
+}
+
+// primitiveValue converts the evaluation result to a value of the type.
+func primitiveValue(res interface{}, tp bsontype.Type) (value.Value, error) {
+	prim, ok := res.(*ast.Primitive)
+	if !ok {
+		return value.Value{}, fmt.Errorf("unknown result %T", res)
+	}
+	switch {
+	case prim.Typ == ast.NULL:
+		return value.NewNullValue(), nil
+	case prim.Typ == ast.STRING && tp == bsontype.String:
+		return value.NewStringValue(prim.Value.(string)), nil
+	case prim.Typ == ast.INT && tp == bsontype.Int32:
+		return value.NewInt32Value(int32(prim.Value.(int))), nil
+	case prim.Typ == ast.INT && tp == bsontype.Int64:
+		return value.NewInt64Value(int64(prim.Value.(int))), nil
+	case prim.Typ == ast.INT && tp == bsontype.Double:
+		return value.NewDoubleValue(float64(prim.Value.(int))), nil
+	case prim.Typ == ast.FLOAT && tp == bsontype.Double:
+		return value.NewDoubleValue(prim.Value.(float64)), nil
+	case prim.Typ == ast.BOOLEAN && tp == bsontype.Boolean:
+		return value.NewBooleanValue(prim.Value.(bool)), nil
+	}
+	return value.Value{}, fmt.Errorf("cannot convert %s to %s", prim.Typ, tp)
+}
+
+func NewProjectionExecutor(ctx session.Context, projectionPlan plan.ProjectionPlan, child Executor) (Executor, error) {
+	schema, exprs := projectionPlan.OutputSchema(), projectionPlan.Exprs()
+	if schema == nil || schema.FieldsLen() != len(exprs) {
+		return nil, fmt.Errorf("%w: the expressions don't match the output schema", ErrInvalidProjection)
+	}
+	inputSchema := plan.InputSchema(projectionPlan.GetChildAt(0))
+	offsets := make([]int, len(exprs))
+	for i, expr := range exprs {
+		offsets[i] = -1
+		if column, ok := expr.(*expression.ColumnExpression); ok {
+			if inputSchema == nil {
+				return nil, fmt.Errorf("%w: the child plan has no output schema", plan.ErrUnknownColumn)
+			}
+			if offsets[i] = inputSchema.Field(column.Name); offsets[i] < 0 {
+				return nil, fmt.Errorf("%w: %s", plan.ErrUnknownColumn, column.Name)
+			}
+		}
+	}
+	return &projectionExecutor{
+		baseExecutor:   newBaseExecutor(ctx),
+		projectionPlan: projectionPlan,
+		childExecutor:  child,
+		inputSchema:    inputSchema,
+		offsets:        offsets,
+	}, nil
+}
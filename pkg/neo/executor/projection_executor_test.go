@@ -0,0 +1,139 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"github.com/casbin-mesh/neo/pkg/expression"
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/neo/utils"
+	"github.com/casbin-mesh/neo/pkg/parser"
+	"github.com/casbin-mesh/neo/pkg/primitive/bschema"
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestProjectionExecutor(t *testing.T) {
+	p := "./__test_tmp__/projection_exec"
+	mockDb := OpenMockDB(t, p)
+	defer func() {
+		mockDb.Close()
+		os.RemoveAll(p)
+	}()
+
+	info, err := utils.CompileModelFromString("projection", `[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = priority, sub, obj, act
+
+[policy_effect]
+e = priority(p.eft) || deny
+
+[matchers]
+m = r.sub == p.sub && r.obj == p.obj && r.act == p.act`)
+	assert.Nil(t, err)
+	table := info.TableInfo[0]
+	table.Columns[0].Tp = bsontype.Int64
+	rules := []value.Values{
+		{value.NewInt64Value(1), value.NewStringValue("alice"), value.NewStringValue("data1"), value.NewStringValue("read")},
+		{value.NewInt64Value(2), value.NewStringValue("bob"), value.NewStringValue("data2"), value.NewStringValue("write")},
+	}
+	sc := mockDb.NewTxnAt(1, true)
+	mockDb.CreateDB(t, sc, info)
+	_, ids, err := mockDb.InsertTuples(t, sc, info.ID, table.ID, rules)
+	assert.Nil(t, err)
+	assert.Nil(t, sc.CommitTxn(context.TODO(), 2))
+	assert.Nil(t, mockDb.WaitForMark(context.TODO(), 2))
+
+	seqScan := plan.NewSeqScanPlan(table, nil, nil, info.ID, table.ID)
+	project := func(sc session.Context, p plan.AbstractPlan) ([]btuple.Modifier, error) {
+		builder := executorBuilder{ctx: sc}
+		exec, err := builder.Build(p), builder.Error()
+		if err != nil {
+			return nil, err
+		}
+		result, rids, err := Execute(exec, context.TODO())
+		if err == nil {
+			// the row ids of the child tuples
+			assert.Equal(t, ids[:len(rids)], rids)
+		}
+		return result, err
+	}
+	sc = mockDb.NewTxnAt(3, false)
+	defer sc.RollbackTxn(context.TODO())
+
+	t.Run("columns", func(t *testing.T) {
+		columns, err := plan.NewColumnsProjectionPlan(seqScan, "act", "sub")
+		assert.Nil(t, err)
+		assert.Equal(t, 2, columns.OutputSchema().FieldsLen())
+		result, err := project(sc, columns)
+		assert.Nil(t, err)
+		assert.Equal(t, []btuple.Elem{btuple.Elem("read"), btuple.Elem("alice")}, result[0].Values())
+		assert.Equal(t, []btuple.Elem{btuple.Elem("write"), btuple.Elem("bob")}, result[1].Values())
+
+		// the columns of a plan without a schema are the ones of its child
+		limit := plan.NewLimitPlan([]plan.AbstractPlan{seqScan}, 1)
+		columns, err = plan.NewColumnsProjectionPlan(limit, "obj")
+		assert.Nil(t, err)
+		result, err = project(sc, plan.NewLimitPlan([]plan.AbstractPlan{columns}, 2))
+		assert.Nil(t, err)
+		assert.Equal(t, []btuple.Elem{btuple.Elem("data1")}, result[0].Values())
+
+		_, err = plan.NewColumnsProjectionPlan(seqScan, "eft")
+		assert.True(t, errors.Is(err, plan.ErrUnknownColumn))
+	})
+
+	t.Run("computed", func(t *testing.T) {
+		next, accessor := expression.NewExpression(parser.MustParseFromString("p.priority * 10 + 1"))
+		admin, _ := expression.NewExpression(parser.MustParseFromString("p.sub == \"alice\""))
+		ctx := ast.NewContext()
+		ctx.AddAccessor("p", accessor)
+		schema := bschema.NewReaderWriter()
+		schema.Append(bsontype.String, []byte("obj"), nil)
+		schema.Append(bsontype.Int64, []byte("next"), nil)
+		schema.Append(bsontype.Boolean, []byte("admin"), nil)
+		result, err := project(sc, plan.NewProjectionPlan([]plan.AbstractPlan{seqScan}, schema, []expression.Expression{
+			expression.NewColumnExpression("obj"), next, admin,
+		}, ctx))
+		assert.Nil(t, err)
+		assert.Len(t, result, 2)
+		for i, expected := range []struct {
+			obj   string
+			next  int64
+			admin bool
+		}{{"data1", 11, true}, {"data2", 21, false}} {
+			tuple := result[i]
+			assert.Equal(t, btuple.Elem(expected.obj), tuple.ValueAt(0))
+			v := codec.DecodeValue(tuple.ValueAt(1), bsontype.Int64)
+			assert.Equal(t, expected.next, v.GetInt64())
+			v = codec.DecodeValue(tuple.ValueAt(2), bsontype.Boolean)
+			assert.Equal(t, expected.admin, v.GetBoolean())
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		schema := bschema.NewReaderWriter()
+		schema.Append(bsontype.Int64, []byte("sub"), nil)
+		sub, accessor := expression.NewExpression(parser.MustParseFromString("p.sub"))
+		ctx := ast.NewContext()
+		ctx.AddAccessor("p", accessor)
+
+		// a string isn't an int64
+		_, err := project(sc, plan.NewProjectionPlan([]plan.AbstractPlan{seqScan}, schema, []expression.Expression{sub}, ctx))
+		assert.True(t, errors.Is(err, ErrInvalidProjection))
+
+		// the expressions don't match the schema
+		_, err = project(sc, plan.NewProjectionPlan([]plan.AbstractPlan{seqScan}, schema, nil, ctx))
+		assert.True(t, errors.Is(err, ErrInvalidProjection))
+
+		_, err = project(sc, plan.NewProjectionPlan([]plan.AbstractPlan{seqScan}, schema, []expression.Expression{expression.NewColumnExpression("eft")}, ctx))
+		assert.True(t, errors.Is(err, plan.ErrUnknownColumn))
+	})
+}
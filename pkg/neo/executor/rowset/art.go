@@ -0,0 +1,48 @@
+package rowset
+
+import (
+	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/storage/mem/index/art"
+)
+
+// artSet looks up the ids by an adaptive radix tree, the values are the positions of the ids.
+type artSet struct {
+	tree art.Tree[int]
+	n    int
+}
+
+func newARTSet(rids []primitive.ObjectID) *artSet {
+	s := &artSet{n: len(rids)}
+	for i := range rids {
+		s.tree.Insert(art.Key(rids[i][:]), i)
+	}
+	return s
+}
+
+func (s *artSet) Kind() Kind {
+	return ART
+}
+
+func (s *artSet) Len() int {
+	return s.n
+}
+
+func (s *artSet) Contains(rid primitive.ObjectID) bool {
+	_, ok := s.tree.Search(rid[:])
+	return ok
+}
+
+func (s *artSet) Index(rid primitive.ObjectID) (int, bool) {
+	return s.tree.Search(rid[:])
+}
+
+func (s *artSet) Iterate(fn func(rid primitive.ObjectID) bool) {
+	iter := s.tree.Iterator(nil, nil)
+	for iter.Next() {
+		var rid primitive.ObjectID
+		copy(rid[:], iter.Key())
+		if !fn(rid) {
+			return
+		}
+	}
+}
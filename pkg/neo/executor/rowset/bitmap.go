@@ -0,0 +1,228 @@
+package rowset
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"sort"
+
+	"github.com/casbin-mesh/neo/pkg/primitive"
+)
+
+const (
+	chunkBits  = 16
+	chunkWords = 1 << chunkBits / 64
+	// arrayMaxLen is the cardinality of a chunk from which its bits take less space than its values.
+	arrayMaxLen = 4096
+)
+
+// bitmapSet is a compressed bitmap of the ids, partitioned into the chunks of their high 48 bits as a roaring bitmap.
+// The low 16 bits of the ids of a chunk are kept in a sorted array if they are a few, or in a bitmap otherwise.
+// The ids are generated from a timestamp and a counter, the ones inserted together share their chunks.
+type bitmapSet struct {
+	chunks []bitmapChunk
+	n      int
+}
+
+type bitmapChunk struct {
+	high uint64
+	// rank is the number of the ids in the preceding chunks
+	rank  int
+	array []uint16
+	words []uint64
+	// wordRanks are the numbers of the ids in the preceding words
+	wordRanks []uint16
+}
+
+func toUint64(rid primitive.ObjectID) uint64 {
+	return binary.BigEndian.Uint64(rid[:])
+}
+
+func toObjectID(v uint64) (rid primitive.ObjectID) {
+	binary.BigEndian.PutUint64(rid[:], v)
+	return
+}
+
+func newBitmapSet(rids []primitive.ObjectID) *bitmapSet {
+	s := &bitmapSet{n: len(rids)}
+	for start := 0; start < len(rids); {
+		high := toUint64(rids[start]) >> chunkBits
+		end := start + 1
+		for end < len(rids) && toUint64(rids[end])>>chunkBits == high {
+			end++
+		}
+		lows := make([]uint16, 0, end-start)
+		for _, rid := range rids[start:end] {
+			lows = append(lows, uint16(toUint64(rid)))
+		}
+		s.chunks = append(s.chunks, newBitmapChunk(high, start, lows))
+		start = end
+	}
+	return s
+}
+
+func newBitmapChunk(high uint64, rank int, lows []uint16) bitmapChunk {
+	c := bitmapChunk{high: high, rank: rank}
+	if len(lows) <= arrayMaxLen {
+		c.array = lows
+		return c
+	}
+	c.words = make([]uint64, chunkWords)
+	for _, low := range lows {
+		c.words[low/64] |= 1 << (low % 64)
+	}
+	c.rankWords()
+	return c
+}
+
+func (c *bitmapChunk) rankWords() {
+	c.wordRanks = make([]uint16, chunkWords)
+	n := 0
+	for i, w := range c.words {
+		c.wordRanks[i] = uint16(n)
+		n += bits.OnesCount64(w)
+	}
+}
+
+func (c *bitmapChunk) len() int {
+	if c.words == nil {
+		return len(c.array)
+	}
+	last := chunkWords - 1
+	return int(c.wordRanks[last]) + bits.OnesCount64(c.words[last])
+}
+
+func (c *bitmapChunk) index(low uint16) (int, bool) {
+	if c.words == nil {
+		i := sort.Search(len(c.array), func(i int) bool { return c.array[i] >= low })
+		return i, i < len(c.array) && c.array[i] == low
+	}
+	w, bit := c.words[low/64], uint64(1)<<(low%64)
+	return int(c.wordRanks[low/64]) + bits.OnesCount64(w&(bit-1)), w&bit != 0
+}
+
+func (c *bitmapChunk) iterate(fn func(low uint16) bool) bool {
+	if c.words == nil {
+		for _, low := range c.array {
+			if !fn(low) {
+				return false
+			}
+		}
+		return true
+	}
+	for i, w := range c.words {
+		for w != 0 {
+			if !fn(uint16(i*64 + bits.TrailingZeros64(w))) {
+				return false
+			}
+			w &= w - 1
+		}
+	}
+	return true
+}
+
+func (s *bitmapSet) chunk(high uint64) *bitmapChunk {
+	i := sort.Search(len(s.chunks), func(i int) bool { return s.chunks[i].high >= high })
+	if i < len(s.chunks) && s.chunks[i].high == high {
+		return &s.chunks[i]
+	}
+	return nil
+}
+
+func (s *bitmapSet) Kind() Kind {
+	return Bitmap
+}
+
+func (s *bitmapSet) Len() int {
+	return s.n
+}
+
+func (s *bitmapSet) Contains(rid primitive.ObjectID) bool {
+	_, ok := s.Index(rid)
+	return ok
+}
+
+func (s *bitmapSet) Index(rid primitive.ObjectID) (int, bool) {
+	v := toUint64(rid)
+	c := s.chunk(v >> chunkBits)
+	if c == nil {
+		return 0, false
+	}
+	i, ok := c.index(uint16(v))
+	return c.rank + i, ok
+}
+
+func (s *bitmapSet) Iterate(fn func(rid primitive.ObjectID) bool) {
+	for i := range s.chunks {
+		high := s.chunks[i].high << chunkBits
+		if !s.chunks[i].iterate(func(low uint16) bool {
+			return fn(toObjectID(high | uint64(low)))
+		}) {
+			return
+		}
+	}
+}
+
+func allBitmaps(sets []RowIDSet) ([]*bitmapSet, bool) {
+	bitmaps := make([]*bitmapSet, 0, len(sets))
+	for _, s := range sets {
+		b, ok := s.(*bitmapSet)
+		if !ok {
+			return nil, false
+		}
+		bitmaps = append(bitmaps, b)
+	}
+	return bitmaps, true
+}
+
+// intersectBitmaps intersects the chunks of the same high bits, the bitmap chunks by their words.
+func intersectBitmaps(sets []*bitmapSet) (rids []primitive.ObjectID) {
+	words := make([]uint64, chunkWords)
+	for i := range sets[0].chunks {
+		first := &sets[0].chunks[i]
+		chunks := []*bitmapChunk{first}
+		for _, s := range sets[1:] {
+			c := s.chunk(first.high)
+			if c == nil {
+				chunks = nil
+				break
+			}
+			chunks = append(chunks, c)
+		}
+		if chunks == nil {
+			continue
+		}
+
+		// probes the others by the smallest chunk, unless all of them are bitmaps
+		smallest, dense := chunks[0], true
+		for _, c := range chunks {
+			if c.len() < smallest.len() {
+				smallest = c
+			}
+			dense = dense && c.words != nil
+		}
+		high := first.high << chunkBits
+		if dense {
+			copy(words, chunks[0].words)
+			for _, c := range chunks[1:] {
+				for j := range words {
+					words[j] &= c.words[j]
+				}
+			}
+			(&bitmapChunk{words: words}).iterate(func(low uint16) bool {
+				rids = append(rids, toObjectID(high|uint64(low)))
+				return true
+			})
+			continue
+		}
+		smallest.iterate(func(low uint16) bool {
+			for _, c := range chunks {
+				if _, ok := c.index(low); c != smallest && !ok {
+					return true
+				}
+			}
+			rids = append(rids, toObjectID(high|uint64(low)))
+			return true
+		})
+	}
+	return
+}
@@ -0,0 +1,45 @@
+package rowset
+
+import (
+	"github.com/casbin-mesh/neo/pkg/primitive"
+)
+
+// hashSet looks up the ids by a hash map, it keeps the ids for the ordered iteration.
+type hashSet struct {
+	index map[primitive.ObjectID]int
+	rids  []primitive.ObjectID
+}
+
+func newHashSet(rids []primitive.ObjectID) *hashSet {
+	index := make(map[primitive.ObjectID]int, len(rids))
+	for i, rid := range rids {
+		index[rid] = i
+	}
+	return &hashSet{index: index, rids: rids}
+}
+
+func (s *hashSet) Kind() Kind {
+	return Hash
+}
+
+func (s *hashSet) Len() int {
+	return len(s.rids)
+}
+
+func (s *hashSet) Contains(rid primitive.ObjectID) bool {
+	_, ok := s.index[rid]
+	return ok
+}
+
+func (s *hashSet) Index(rid primitive.ObjectID) (int, bool) {
+	i, ok := s.index[rid]
+	return i, ok
+}
+
+func (s *hashSet) Iterate(fn func(rid primitive.ObjectID) bool) {
+	for _, rid := range s.rids {
+		if !fn(rid) {
+			return
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package rowset
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/casbin-mesh/neo/pkg/primitive"
+)
+
+// RowIDSet is an immutable set of row ids, e.g., the row ids yielded by an index scan.
+// The ids are ordered ascending, a set is built from the sorted ids by its constructor.
+type RowIDSet interface {
+	Kind() Kind
+	Len() int
+	Contains(rid primitive.ObjectID) bool
+	// Index returns the position of the id among the ascending ids of the set.
+	Index(rid primitive.ObjectID) (int, bool)
+	// Iterate calls fn with the ids in ascending order, until fn returns false.
+	Iterate(fn func(rid primitive.ObjectID) bool)
+}
+
+type Kind uint8
+
+const (
+	Hash Kind = iota
+	Sorted
+	Bitmap
+	ART
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Hash:
+		return "hash"
+	case Sorted:
+		return "sorted"
+	case Bitmap:
+		return "bitmap"
+	case ART:
+		return "art"
+	}
+	return "unknown"
+}
+
+const (
+	// sortedMaxLen is the cardinality up to which a binary search beats hashing the id.
+	sortedMaxLen = 128
+	// hashMaxLen is the cardinality up to which a hash set beats a bitmap.
+	hashMaxLen = 1 << 14
+)
+
+// Choose returns the kind of set fits the estimated cardinality.
+func Choose(cardinality int) Kind {
+	switch {
+	case cardinality <= sortedMaxLen:
+		return Sorted
+	case cardinality <= hashMaxLen:
+		return Hash
+	default:
+		return Bitmap
+	}
+}
+
+// New returns a set of the ids, its kind is chosen by their cardinality.
+// The set takes the ids, which are sorted in place.
+func New(rids []primitive.ObjectID) RowIDSet {
+	rids = Normalize(rids)
+	return build(Choose(len(rids)), rids)
+}
+
+// NewOf returns a set of the kind of the ids, the set takes the ids as New does.
+func NewOf(kind Kind, rids []primitive.ObjectID) RowIDSet {
+	return build(kind, Normalize(rids))
+}
+
+// build returns a set of the kind of the sorted and unique ids.
+func build(kind Kind, rids []primitive.ObjectID) RowIDSet {
+	switch kind {
+	case Sorted:
+		return newSortedSet(rids)
+	case Bitmap:
+		return newBitmapSet(rids)
+	case ART:
+		return newARTSet(rids)
+	default:
+		return newHashSet(rids)
+	}
+}
+
+// Normalize sorts the ids and removes the duplicates in place.
+func Normalize(rids []primitive.ObjectID) []primitive.ObjectID {
+	if !sort.SliceIsSorted(rids, func(i, j int) bool { return less(rids[i], rids[j]) }) {
+		sort.Slice(rids, func(i, j int) bool { return less(rids[i], rids[j]) })
+	}
+	n := 0
+	for i, rid := range rids {
+		if i == 0 || rid != rids[n-1] {
+			rids[n] = rid
+			n++
+		}
+	}
+	return rids[:n]
+}
+
+// IDs returns the ids of the set in ascending order.
+func IDs(s RowIDSet) []primitive.ObjectID {
+	if sorted, ok := s.(*sortedSet); ok {
+		return sorted.rids
+	}
+	rids := make([]primitive.ObjectID, 0, s.Len())
+	s.Iterate(func(rid primitive.ObjectID) bool {
+		rids = append(rids, rid)
+		return true
+	})
+	return rids
+}
+
+// Intersect returns the ids in all the sets, its kind is chosen by its cardinality.
+func Intersect(sets ...RowIDSet) RowIDSet {
+	if len(sets) == 0 {
+		return New(nil)
+	}
+	if bitmaps, ok := allBitmaps(sets); ok {
+		rids := intersectBitmaps(bitmaps)
+		return build(Choose(len(rids)), rids)
+	}
+	// probes the others by the smallest set
+	smallest := 0
+	for i, s := range sets {
+		if s.Len() < sets[smallest].Len() {
+			smallest = i
+		}
+	}
+	var rids []primitive.ObjectID
+	sets[smallest].Iterate(func(rid primitive.ObjectID) bool {
+		for i, s := range sets {
+			if i != smallest && !s.Contains(rid) {
+				return true
+			}
+		}
+		rids = append(rids, rid)
+		return true
+	})
+	return build(Choose(len(rids)), rids)
+}
+
+// Union returns the ids in any of the sets, its kind is chosen by its cardinality.
+func Union(sets ...RowIDSet) RowIDSet {
+	var rids []primitive.ObjectID
+	for _, s := range sets {
+		rids = merge(rids, IDs(s))
+	}
+	return build(Choose(len(rids)), rids)
+}
+
+// merge returns the sorted union of the sorted ids.
+func merge(a, b []primitive.ObjectID) []primitive.ObjectID {
+	if len(a) == 0 {
+		return append([]primitive.ObjectID(nil), b...)
+	}
+	rids := make([]primitive.ObjectID, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch c := bytes.Compare(a[i][:], b[j][:]); {
+		case c < 0:
+			rids = append(rids, a[i])
+			i++
+		case c > 0:
+			rids = append(rids, b[j])
+			j++
+		default:
+			rids = append(rids, a[i])
+			i, j = i+1, j+1
+		}
+	}
+	rids = append(rids, a[i:]...)
+	return append(rids, b[j:]...)
+}
+
+func less(a, b primitive.ObjectID) bool {
+	return bytes.Compare(a[:], b[:]) < 0
+}
@@ -0,0 +1,95 @@
+package rowset
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+)
+
+// insertedIDs returns the ids of n rows inserted together, as they are generated by the inserts.
+func insertedIDs(n int) []primitive.ObjectID {
+	now := time.Now()
+	rids := make([]primitive.ObjectID, 0, n)
+	for i := 0; i < n; i++ {
+		rids = append(rids, primitive.NewObjectIDFromTimestamp(now))
+	}
+	return rids
+}
+
+// intersectWorkload returns the build side of one in every step rows, and the probe side of all the rows shuffled.
+func intersectWorkload(n, step int) (build, probe []primitive.ObjectID) {
+	probe = insertedIDs(n)
+	for i := 0; i < n; i += step {
+		build = append(build, probe[i])
+	}
+	rand.New(rand.NewSource(1)).Shuffle(len(probe), func(i, j int) { probe[i], probe[j] = probe[j], probe[i] })
+	return
+}
+
+// BenchmarkIntersect builds the left side and probes by the right side, as the multi-index scan does,
+// the current map of the tuples against the sets and the tuples at their positions.
+func BenchmarkIntersect(b *testing.B) {
+	tuple := btuple.NewModifier([]btuple.Elem{btuple.Elem("alice")})
+	for _, n := range []int{100, 10000, 1000000} {
+		build, probe := intersectWorkload(n, 10)
+		b.Run(fmt.Sprintf("map/%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				hashMap := make(map[primitive.ObjectID]btuple.Modifier)
+				for _, rid := range build {
+					hashMap[rid] = tuple
+				}
+				matched := 0
+				for _, rid := range probe {
+					if _, ok := hashMap[rid]; ok {
+						matched++
+					}
+				}
+			}
+		})
+		for _, kind := range kinds {
+			b.Run(fmt.Sprintf("%s/%d", kind, n), func(b *testing.B) {
+				b.ReportAllocs()
+				rids := make([]primitive.ObjectID, len(build))
+				for i := 0; i < b.N; i++ {
+					copy(rids, build)
+					s := NewOf(kind, rids)
+					tuples := make([]btuple.Modifier, s.Len())
+					for j := range tuples {
+						tuples[j] = tuple
+					}
+					matched := 0
+					for _, rid := range probe {
+						if j, ok := s.Index(rid); ok && tuples[j] != nil {
+							matched++
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkIntersectSets(b *testing.B) {
+	all := insertedIDs(1000000)
+	every := func(step int) []primitive.ObjectID {
+		var rids []primitive.ObjectID
+		for i := 0; i < len(all); i += step {
+			rids = append(rids, all[i])
+		}
+		return rids
+	}
+	for _, kind := range kinds {
+		sets := []RowIDSet{NewOf(kind, every(2)), NewOf(kind, every(3)), NewOf(kind, every(5))}
+		b.Run(kind.String(), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				Intersect(sets...)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package rowset
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/stretchr/testify/assert"
+)
+
+var kinds = []Kind{Hash, Sorted, Bitmap, ART}
+
+// randomIDs returns n ids, the ones of a dense chunk of the bitmap and the scattered ones.
+func randomIDs(r *rand.Rand, n int) []primitive.ObjectID {
+	rids := make([]primitive.ObjectID, 0, n)
+	for i := 0; i < n; i++ {
+		v := uint64(r.Intn(arrayMaxLen * 3))
+		if i%3 == 0 {
+			v = r.Uint64()
+		}
+		rids = append(rids, toObjectID(1<<32|v))
+	}
+	return rids
+}
+
+func sortedUnique(rids []primitive.ObjectID) []primitive.ObjectID {
+	set := map[primitive.ObjectID]struct{}{}
+	var unique []primitive.ObjectID
+	for _, rid := range rids {
+		if _, ok := set[rid]; !ok {
+			set[rid] = struct{}{}
+			unique = append(unique, rid)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool { return less(unique[i], unique[j]) })
+	return unique
+}
+
+func TestRowIDSet(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 100, 20000} {
+		rids := randomIDs(r, n)
+		expected := sortedUnique(rids)
+		for _, kind := range kinds {
+			s := NewOf(kind, append([]primitive.ObjectID(nil), rids...))
+			assert.Equal(t, kind, s.Kind())
+			assert.Equal(t, len(expected), s.Len())
+			if len(expected) == 0 {
+				assert.Empty(t, IDs(s))
+			} else {
+				assert.Equal(t, expected, IDs(s), kind.String())
+			}
+			for i, rid := range expected {
+				index, ok := s.Index(rid)
+				assert.True(t, ok)
+				assert.Equal(t, i, index)
+			}
+			assert.False(t, s.Contains(toObjectID(2<<32)))
+			assert.False(t, s.Contains(primitive.ObjectID{}))
+
+			// stops early
+			count := 0
+			s.Iterate(func(rid primitive.ObjectID) bool {
+				count++
+				return count < 3
+			})
+			assert.Equal(t, minInt(3, len(expected)), count)
+		}
+	}
+}
+
+func TestChoose(t *testing.T) {
+	assert.Equal(t, Sorted, Choose(0))
+	assert.Equal(t, Sorted, Choose(sortedMaxLen))
+	assert.Equal(t, Hash, Choose(sortedMaxLen+1))
+	assert.Equal(t, Bitmap, Choose(hashMaxLen+1))
+	assert.Equal(t, Sorted, New(randomIDs(rand.New(rand.NewSource(1)), 10)).Kind())
+}
+
+func TestIntersectUnion(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	a, b, c := randomIDs(r, 30000), randomIDs(r, 30000), randomIDs(r, 20000)
+	contains := func(rids []primitive.ObjectID, rid primitive.ObjectID) bool {
+		for _, v := range rids {
+			if v == rid {
+				return true
+			}
+		}
+		return false
+	}
+	var intersection []primitive.ObjectID
+	for _, rid := range sortedUnique(a) {
+		if contains(b, rid) && contains(c, rid) {
+			intersection = append(intersection, rid)
+		}
+	}
+	assert.NotEmpty(t, intersection)
+	union := sortedUnique(append(append(append([]primitive.ObjectID(nil), a...), b...), c...))
+
+	for _, kind := range kinds {
+		for _, other := range kinds {
+			sets := []RowIDSet{
+				NewOf(kind, append([]primitive.ObjectID(nil), a...)),
+				NewOf(other, append([]primitive.ObjectID(nil), b...)),
+				NewOf(kind, append([]primitive.ObjectID(nil), c...)),
+			}
+			assert.Equal(t, intersection, IDs(Intersect(sets...)), "%s %s", kind, other)
+			assert.Equal(t, union, IDs(Union(sets...)), "%s %s", kind, other)
+		}
+	}
+	assert.Equal(t, 0, Intersect().Len())
+	assert.Equal(t, 0, Union().Len())
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
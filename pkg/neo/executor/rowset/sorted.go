@@ -0,0 +1,42 @@
+package rowset
+
+import (
+	"sort"
+
+	"github.com/casbin-mesh/neo/pkg/primitive"
+)
+
+// sortedSet looks up the ids by a binary search, it suits a few ids.
+type sortedSet struct {
+	rids []primitive.ObjectID
+}
+
+func newSortedSet(rids []primitive.ObjectID) *sortedSet {
+	return &sortedSet{rids: rids}
+}
+
+func (s *sortedSet) Kind() Kind {
+	return Sorted
+}
+
+func (s *sortedSet) Len() int {
+	return len(s.rids)
+}
+
+func (s *sortedSet) Contains(rid primitive.ObjectID) bool {
+	_, ok := s.Index(rid)
+	return ok
+}
+
+func (s *sortedSet) Index(rid primitive.ObjectID) (int, bool) {
+	i := sort.Search(len(s.rids), func(i int) bool { return !less(s.rids[i], rid) })
+	return i, i < len(s.rids) && s.rids[i] == rid
+}
+
+func (s *sortedSet) Iterate(fn func(rid primitive.ObjectID) bool) {
+	for _, rid := range s.rids {
+		if !fn(rid) {
+			return
+		}
+	}
+}
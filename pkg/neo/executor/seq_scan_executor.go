@@ -19,10 +19,16 @@ type seqScanExecutor struct {
 	tableInfo   *model.TableInfo
 	iter        db.Iterator
 	prefix      []byte
+	keyBuf      []byte
+	cancel      canceler
 }
 
 func (s *seqScanExecutor) Init() {
 	s.prefix = codec.TupleRecordBegin(s.tableInfo.ID)
+	s.GetSessionCtx().TrackPrefixRead(s.prefix)
+	if s.iter != nil {
+		s.iter.Close()
+	}
 	s.iter = s.GetTxn().NewIterator(adapter.DefaultIteratorOptions)
 	s.iter.Seek(s.prefix)
 }
@@ -30,17 +36,20 @@ func (s *seqScanExecutor) Init() {
 func (s *seqScanExecutor) Close() error {
 	if s.iter != nil {
 		s.iter.Close()
+		s.iter = nil
 	}
 	return nil
 }
 
-func (s *seqScanExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid *primitive.ObjectID) (next bool, err error) {
+// nextRow reads the next row of the table, the reader is nil if there is no more.
+func (s *seqScanExecutor) nextRow(rid *primitive.ObjectID) (tuple btuple.Reader, err error) {
 	if !s.iter.ValidForPrefix(s.prefix) {
 		return
 	}
 
-	rawKey := s.iter.Item().KeyCopy(nil)
-	if *rid, err = codec.ParseTupleRecordKey(rawKey); err != nil {
+	// the key is parsed right away, its buffer is reused
+	s.keyBuf = s.iter.Item().KeyCopy(s.keyBuf[:0])
+	if *rid, err = codec.ParseTupleRecordKey(s.keyBuf); err != nil {
 		return
 	}
 
@@ -50,30 +59,41 @@ func (s *seqScanExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid
 	}
 
 	// TODO(weny): create modifier directly
-	tupleReader, err := btuple.NewReader(rawVal)
-	if err != nil {
-		return
+	if tuple, err = btuple.NewReader(rawVal); err != nil {
+		return nil, err
 	}
 
-	//TODO:(weny): generates tuple following the output schema
-	*tuple = btuple.NewModifier(tupleReader.Values())
-
 	s.iter.Next()
+	return
+}
 
+// Next yields the next row satisfies the predicate, the filtered ones are skipped in a loop.
+func (s *seqScanExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid *primitive.ObjectID) (next bool, err error) {
 	predicate := s.seqScanPlan.Predicate()
-	if predicate != nil {
-		if res, err := predicate.Evaluate(s.GetSessionCtx(), s.seqScanPlan.GetEvalCtx(), *tuple, s.seqScanPlan.OutputSchema()); err == nil {
-			if value, err := expression.TryGetBool(res); err != nil {
-				return false, err
-			} else if !value {
-				return s.Next(ctx, tuple, rid)
-			}
-		} else {
+	for {
+		if err = s.cancel.check(ctx); err != nil {
 			return false, err
 		}
-	}
+		tupleReader, err := s.nextRow(rid)
+		if tupleReader == nil || err != nil {
+			return false, err
+		}
+		//TODO:(weny): generates tuple following the output schema
+		*tuple = btuple.NewModifier(tupleReader.Values())
+		if predicate == nil {
+			return true, nil
+		}
 
-	return true, nil
+		res, err := predicate.Evaluate(s.GetSessionCtx(), s.seqScanPlan.GetEvalCtx(), *tuple, s.seqScanPlan.OutputSchema())
+		if err != nil {
+			return false, err
+		}
+		if value, err := expression.TryGetBool(res); err != nil {
+			return false, err
+		} else if value {
+			return true, nil
+		}
+	}
 }
 
 func NewSeqScanExecutor(ctx session.Context, scanPlan plan.SeqScanPlan) (Executor, error) {
@@ -1,7 +1,10 @@
 package executor
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"github.com/casbin-mesh/neo/pkg/db"
 	"github.com/casbin-mesh/neo/pkg/db/adapter"
 	"github.com/casbin-mesh/neo/pkg/neo/codec"
@@ -9,29 +12,76 @@ import (
 	"github.com/casbin-mesh/neo/pkg/neo/session"
 	"github.com/casbin-mesh/neo/pkg/primitive"
 	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"sort"
 )
 
+var (
+	ErrRowNotFound = errors.New("row not found")
+)
+
+// tableRowIdScanExecutor drains the row ids of its child, and yields the rows
+// in the order of their ids as a sequential scan does, whatever order the
+// child yields them in, the effects without a priority column depend on it.
 type tableRowIdScanExecutor struct {
 	baseExecutor
-	plan  *plan.TableRowIdScan
-	iter  db.Iterator
-	child Executor
+	plan   *plan.TableRowIdScan
+	iter   db.Iterator
+	child  Executor
+	rids   []primitive.ObjectID
+	pos    int
+	loaded bool
 }
 
 func (t *tableRowIdScanExecutor) Init() {
 	t.child.Init()
+	if t.iter != nil {
+		t.iter.Close()
+	}
 	t.iter = t.GetTxn().NewIterator(adapter.DefaultIteratorOptions)
+	t.rids, t.pos, t.loaded = t.rids[:0], 0, false
+}
+
+// load drains the row ids of the child, and sorts them.
+func (t *tableRowIdScanExecutor) load(ctx context.Context) error {
+	var (
+		tuple btuple.Modifier
+		rid   primitive.ObjectID
+	)
+	for {
+		next, err := t.child.Next(ctx, &tuple, &rid)
+		if err != nil {
+			return err
+		}
+		if !next {
+			break
+		}
+		t.rids = append(t.rids, rid)
+	}
+	sort.Slice(t.rids, func(i, j int) bool {
+		return bytes.Compare(t.rids[i][:], t.rids[j][:]) < 0
+	})
+	t.loaded = true
+	return nil
 }
 
 func (t *tableRowIdScanExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid *primitive.ObjectID) (next bool, err error) {
-	if next, err = t.child.Next(ctx, tuple, rid); !next || err != nil {
+	if !t.loaded {
+		if err = t.load(ctx); err != nil {
+			return
+		}
+	}
+	if t.pos == len(t.rids) {
 		return
 	}
-	key := codec.TupleRecordKey(t.plan.TableOid(), *rid)
+	id := t.rids[t.pos]
+	t.pos++
+	key := codec.TupleRecordKey(t.plan.TableOid(), id)
+	t.GetSessionCtx().TrackRead(key)
 	t.iter.Seek(key)
 
-	if !t.iter.Valid() {
-		return
+	// the index entries of a row are written and deleted with it, a row id without its row is a corruption
+	if !t.iter.Valid() || !bytes.Equal(t.iter.Item().KeyCopy(nil), key) {
+		return false, fmt.Errorf("%w: %x of table %d", ErrRowNotFound, id[:], t.plan.TableOid())
 	}
 
 	rawVal, err := t.iter.Item().ValueCopy(nil)
@@ -46,7 +96,7 @@ func (t *tableRowIdScanExecutor) Next(ctx context.Context, tuple *btuple.Modifie
 	}
 
 	//TODO: generates tuple following the output schema
-	*tuple = btuple.NewModifier(tupleReader.Values())
+	*tuple, *rid = btuple.NewModifier(tupleReader.Values()), id
 
 	return true, nil
 }
@@ -54,6 +104,7 @@ func (t *tableRowIdScanExecutor) Next(ctx context.Context, tuple *btuple.Modifie
 func (t *tableRowIdScanExecutor) Close() error {
 	if t.iter != nil {
 		t.iter.Close()
+		t.iter = nil
 	}
 	err := t.child.Close()
 	if err != nil {
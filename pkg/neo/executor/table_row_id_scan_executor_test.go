@@ -7,11 +7,13 @@ import (
 	"fmt"
 	"github.com/casbin-mesh/neo/pkg/expression"
 	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
 	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
 	"github.com/casbin-mesh/neo/pkg/neo/model"
 	"github.com/casbin-mesh/neo/pkg/parser"
 	"github.com/casbin-mesh/neo/pkg/primitive"
 	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"testing"
@@ -42,7 +44,7 @@ func TestNewTableRowIdScanExecutor(t *testing.T) {
 	var indexId [8]byte
 	binary.BigEndian.PutUint64(indexId[:], idxId)
 	// scan from
-	indexPrefix := []byte(fmt.Sprintf("i%s_%s", indexId, "bob"))
+	indexPrefix := append([]byte(fmt.Sprintf("i%s_", indexId)), codec.EncodeCmpValue(value.NewStringValue("bob"))...)
 
 	mockExpr, accessor := expression.NewExpression(parser.MustParseFromString("p.subject == \"bob\""))
 	ctx := ast.NewContext()
@@ -74,3 +76,63 @@ func TestNewTableRowIdScanExecutor(t *testing.T) {
 	IdsAsserter(t, expected, ids)
 	TuplesAsserter(t, expectedTuple, tuples)
 }
+
+func TestTableRowIdScanExecutor_Order(t *testing.T) {
+	p := "./__test_tmp__/table_row_id_scan_order"
+	mockDb, sc, info := openBatchTestDB(t, p, 100)
+	defer func() {
+		sc.RollbackTxn(context.TODO())
+		mockDb.Close()
+		os.RemoveAll(p)
+	}()
+	table := info.TableInfo[0]
+
+	// the whole subject index yields the row ids in the order of the subjects
+	prefix := codec.IndexEntryPrefix(table.Indices[0].ID, nil)
+	indexScan := plan.NewIndexScanPlan(model.NewIndexSchemaReader(table, 0), prefix, nil, nil, info.ID, table.ID)
+	builder := NewExecutorBuilder(sc)
+	exec, err := builder.Build(indexScan), builder.Error()
+	assert.Nil(t, err)
+	_, indexIds, err := Execute(exec, context.TODO())
+	assert.Nil(t, err)
+
+	builder = NewExecutorBuilder(sc)
+	exec, err = builder.Build(plan.NewTableRowIdScan(table, info.ID, table.ID, indexScan)), builder.Error()
+	assert.Nil(t, err)
+	tuples, ids, err := Execute(exec, context.TODO())
+	assert.Nil(t, err)
+
+	// the rows in the same order as a sequential scan
+	all, allIds, err := mockDb.SeqScan(t, sc, info.ID, table.ID, table)
+	assert.Nil(t, err)
+	assert.Len(t, allIds, 100)
+	assert.NotEqual(t, allIds, indexIds)
+	assert.Equal(t, allIds, ids)
+	TuplesAsserter(t, all, tuples)
+
+	// executes again after Init
+	exec.Init()
+	var (
+		tuple btuple.Modifier
+		rid   primitive.ObjectID
+	)
+	next, err := exec.Next(context.TODO(), &tuple, &rid)
+	assert.Nil(t, err)
+	assert.True(t, next)
+	assert.Equal(t, allIds[0], rid)
+	assert.Nil(t, exec.Close())
+
+	// a row id without its row is an error, the seek never yields the row after it
+	child := &mockTuplesExecutor{tuples: []btuple.Modifier{nil, nil}, rids: []primitive.ObjectID{allIds[0], primitive.NewObjectID()}}
+	exec, err = NewTableRowIdScanExecutor(sc, plan.NewTableRowIdScan(table, info.ID, table.ID, indexScan), child)
+	assert.Nil(t, err)
+	exec.Init()
+	next, err = exec.Next(context.TODO(), &tuple, &rid)
+	assert.Nil(t, err)
+	assert.True(t, next)
+	next, err = exec.Next(context.TODO(), &tuple, &rid)
+	assert.ErrorIs(t, err, ErrRowNotFound)
+	assert.False(t, next)
+	assert.Equal(t, allIds[0], rid)
+	assert.Nil(t, exec.Close())
+}
@@ -34,16 +34,12 @@ func (u *updateExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid *
 
 	for cond() {
 
-		// remove old indices
-		for i, _ := range u.tableInfo.Columns {
-			for _, index := range u.tableInfo.Indices {
-				if index.Leftmost().Offset == i {
-					key := codec.IndexEntryKey(index, u.tableInfo.Columns, *tuple, *rid)
-					if err = u.GetTxn().Delete(key); err != nil {
-						if err != db.ErrKeyNotFound {
-							return false, err
-						}
-					}
+		// remove old indices, the keys of an index cover all its columns
+		for _, index := range u.tableInfo.Indices {
+			key := codec.IndexEntryKey(index, u.tableInfo.Columns, *tuple, *rid)
+			if err = u.GetTxn().Delete(key); err != nil {
+				if err != db.ErrKeyNotFound {
+					return false, err
 				}
 			}
 		}
@@ -60,11 +56,8 @@ func (u *updateExecutor) Next(ctx context.Context, tuple *btuple.Modifier, rid *
 		}
 
 		// update indices
-		for _, index := range u.tableInfo.Indices {
-			key, value := codec.IndexEntry(index, u.tableInfo.Columns, *tuple, *rid)
-			if err = u.GetTxn().Set(key, value); err != nil {
-				return false, err
-			}
+		if err = setIndexEntries(u.GetTxn(), u.tableInfo, *tuple, *rid); err != nil {
+			return false, err
 		}
 	}
 
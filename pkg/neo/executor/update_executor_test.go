@@ -2,7 +2,11 @@ package executor
 
 import (
 	"context"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
 	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/neo/utils"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
 	"github.com/casbin-mesh/neo/pkg/primitive/value"
 	"github.com/stretchr/testify/assert"
 	"os"
@@ -71,3 +75,144 @@ func TestNewUpdateExecutor(t *testing.T) {
 	TuplesAsserter(t, expected, result)
 
 }
+
+func TestUpdateExecutor_CompositeIndex(t *testing.T) {
+	p := "./__test_tmp__/update_exec_composite"
+	mockDb := OpenMockDB(t, p)
+	defer func() {
+		mockDb.Close()
+		os.RemoveAll(p)
+	}()
+
+	info, err := utils.CompileModelFromString("composite", basicModelText)
+	assert.Nil(t, err)
+	table := info.TableInfo[0]
+	table.Indices = append(table.Indices, &model.IndexInfo{
+		Name:  model.CIStr{O: "sub_obj_index", L: "sub_obj_index"},
+		Table: table.Name,
+		Columns: []*model.IndexColumn{
+			{ColName: model.CIStr{O: "sub", L: "sub"}, Offset: 0},
+			{ColName: model.CIStr{O: "obj", L: "obj"}, Offset: 1},
+		},
+	})
+	index := table.Indices[len(table.Indices)-1]
+	indexScan := func(ts uint64, values ...string) []btuple.Modifier {
+		columnValues := make([][]byte, 0, len(values))
+		for _, v := range values {
+			columnValues = append(columnValues, codec.EncodeCmpValue(value.NewStringValue(v)))
+		}
+		sc := mockDb.NewTxnAt(ts, false)
+		defer sc.RollbackTxn(context.TODO())
+		builder := executorBuilder{ctx: sc}
+		exec, err := builder.Build(plan.NewIndexScanPlan(
+			model.NewIndexSchemaReader(table, len(table.Indices)-1), codec.IndexEntryPrefix(index.ID, columnValues), nil, nil, info.ID, table.ID,
+		)), builder.Error()
+		assert.Nil(t, err)
+		result, _, err := Execute(exec, context.TODO())
+		assert.Nil(t, err)
+		return result
+	}
+
+	sc := mockDb.NewTxnAt(1, true)
+	mockDb.CreateDB(t, sc, info)
+	// the rows stay unique after the update
+	_, _, err = mockDb.InsertTuples(t, sc, info.ID, table.ID, []value.Values{
+		newStringValues("alice", "data1", "read"),
+		newStringValues("alice", "data2", "write"),
+		newStringValues("bob", "data2", "write"),
+		newStringValues("bob", "data4", "read"),
+		newStringValues("bob", "data3", "delete"),
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, sc.CommitTxn(context.TODO(), 2))
+	assert.Nil(t, mockDb.WaitForMark(context.TODO(), 2))
+
+	// the entries of all the index columns
+	assert.Len(t, indexScan(3, "alice"), 2)
+	assert.Len(t, indexScan(3, "alice", "data1"), 1)
+	assert.Len(t, indexScan(3, "bob", "data2"), 1)
+
+	// update the second column of the index
+	sc = mockDb.NewTxnAt(3, true)
+	builder := executorBuilder{ctx: sc}
+	exec, err := builder.Build(plan.NewUpdatePlan([]plan.AbstractPlan{
+		plan.NewSeqScanPlan(table, nil, nil, info.ID, table.ID),
+	}, info.ID, table.ID, map[int]plan.Modifier{1: plan.NewModifier(plan.ModifierSet, value.NewStringValue("data5"))})), builder.Error()
+	assert.Nil(t, err)
+	_, _, err = Execute(exec, context.TODO())
+	assert.Nil(t, err)
+	assert.Nil(t, sc.CommitTxn(context.TODO(), 4))
+	assert.Nil(t, mockDb.WaitForMark(context.TODO(), 4))
+
+	assert.Len(t, indexScan(5, "alice"), 2)
+	assert.Empty(t, indexScan(5, "alice", "data1"))
+	tuples := indexScan(5, "bob", "data5")
+	assert.Len(t, tuples, 3)
+	for _, tuple := range tuples {
+		assert.Equal(t, []btuple.Elem{btuple.Elem("bob"), btuple.Elem("data5")}, tuple.Values())
+	}
+
+	// delete the rows, and their entries
+	sc = mockDb.NewTxnAt(5, true)
+	builder = executorBuilder{ctx: sc}
+	exec, err = builder.Build(plan.NewDeletePlan([]plan.AbstractPlan{
+		plan.NewSeqScanPlan(table, nil, nil, info.ID, table.ID),
+	}, info.ID, table.ID)), builder.Error()
+	assert.Nil(t, err)
+	_, _, err = Execute(exec, context.TODO())
+	assert.Nil(t, err)
+	assert.Nil(t, sc.CommitTxn(context.TODO(), 6))
+	assert.Nil(t, mockDb.WaitForMark(context.TODO(), 6))
+
+	assert.Empty(t, indexScan(7, "alice"))
+	assert.Empty(t, indexScan(7, "bob", "data5"))
+}
+
+func TestUpdateExecutor_Unique(t *testing.T) {
+	p := "./__test_tmp__/update_exec_unique"
+	mockDb := OpenMockDB(t, p)
+	defer func() {
+		mockDb.Close()
+		os.RemoveAll(p)
+	}()
+
+	info, err := utils.CompileModelFromString("unique", basicModelText)
+	assert.Nil(t, err)
+	table := info.TableInfo[0]
+	sc := mockDb.NewTxnAt(1, true)
+	mockDb.CreateDB(t, sc, info)
+	_, _, err = mockDb.InsertTuples(t, sc, info.ID, table.ID, mockDBDataSet)
+	assert.Nil(t, err)
+	assert.Nil(t, sc.CommitTxn(context.TODO(), 2))
+	assert.Nil(t, mockDb.WaitForMark(context.TODO(), 2))
+
+	update := func(sc *executorBuilder, offset int, v string) error {
+		exec, err := sc.Build(plan.NewUpdatePlan([]plan.AbstractPlan{
+			plan.NewSeqScanPlan(table, nil, nil, info.ID, table.ID),
+		}, info.ID, table.ID, map[int]plan.Modifier{offset: plan.NewModifier(plan.ModifierSet, value.NewStringValue(v))})), sc.Error()
+		assert.Nil(t, err)
+		_, _, err = Execute(exec, context.TODO())
+		return err
+	}
+
+	// bob data3 write becomes the same as bob data2 write
+	sc = mockDb.NewTxnAt(3, true)
+	err = update(&executorBuilder{ctx: sc}, 1, "data2")
+	assert.Equal(t, &ErrDuplicateKey{Index: model.PrimaryIndexName, Value: "bob-data2-write"}, err)
+	sc.RollbackTxn(context.TODO())
+
+	// the rules keep their own keys
+	sc = mockDb.NewTxnAt(3, true)
+	assert.Nil(t, update(&executorBuilder{ctx: sc}, 2, "write"))
+	assert.Nil(t, sc.CommitTxn(context.TODO(), 4))
+	assert.Nil(t, mockDb.WaitForMark(context.TODO(), 4))
+
+	sc = mockDb.NewTxnAt(5, false)
+	result, _, err := mockDb.SeqScan(t, sc, info.ID, table.ID, table)
+	assert.Nil(t, err)
+	assert.Len(t, result, len(mockDBDataSet))
+	for _, tuple := range result {
+		assert.Equal(t, btuple.Elem("write"), tuple.ValueAt(2))
+	}
+	sc.RollbackTxn(context.TODO())
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index
+
+import "sync"
+
+// epochs groups the active transactions by the epoch they begin in.
+// Every garbage collection starts a new epoch, an epoch is retired once all
+// its transactions are done, and the oldest read timestamp of the active
+// epochs bounds the versions the garbage collection may reclaim.
+type epochs struct {
+	mu      sync.Mutex
+	current uint64
+	active  map[uint64]*epoch
+}
+
+type epoch struct {
+	txns      int
+	minReadTs uint64
+}
+
+func newEpochs() *epochs {
+	return &epochs{active: make(map[uint64]*epoch)}
+}
+
+// enter registers a transaction reading at readTs, returns its epoch.
+func (e *epochs) enter(readTs uint64) uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ep, ok := e.active[e.current]
+	if !ok {
+		ep = &epoch{minReadTs: readTs}
+		e.active[e.current] = ep
+	}
+	ep.txns++
+	if readTs < ep.minReadTs {
+		ep.minReadTs = readTs
+	}
+	return e.current
+}
+
+// exit unregisters a transaction of the epoch.
+func (e *epochs) exit(id uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ep, ok := e.active[id]
+	if !ok {
+		return
+	}
+	ep.txns--
+	if ep.txns == 0 {
+		delete(e.active, id)
+	}
+}
+
+// advance starts a new epoch, and returns the oldest read timestamp of the
+// active transactions, ok is false if there is no active transaction.
+func (e *epochs) advance() (oldest uint64, ok bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.current++
+	for _, ep := range e.active {
+		if !ok || ep.minReadTs < oldest {
+			oldest, ok = ep.minReadTs, true
+		}
+	}
+	return
+}
+
+// len returns the number of active transactions.
+func (e *epochs) len() (n int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, ep := range e.active {
+		n += ep.txns
+	}
+	return
+}
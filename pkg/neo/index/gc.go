@@ -0,0 +1,106 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index
+
+import "github.com/casbin-mesh/neo/pkg/storage/mem/index/art"
+
+// Stats is the statistics of the version chains and the garbage collection.
+type Stats struct {
+	// Keys is the number of keys in the index.
+	Keys int
+	// Versions is the number of versions of all keys.
+	Versions int
+	// MaxChainLength is the number of versions of the longest version chain.
+	MaxChainLength int
+	// ActiveTxns is the number of active transactions.
+	ActiveTxns int
+
+	// DiscardTs is the discard timestamp of the last garbage collection.
+	DiscardTs uint64
+	// Runs is the number of garbage collections.
+	Runs uint64
+	// ReclaimedVersions is the number of versions reclaimed by all garbage collections.
+	ReclaimedVersions uint64
+	// RemovedKeys is the number of keys removed by all garbage collections.
+	RemovedKeys uint64
+}
+
+func (s *backend[T]) Stats() Stats {
+	s.gcMu.Lock()
+	defer s.gcMu.Unlock()
+	return s.stats
+}
+
+func (s *backend[T]) SetDiscardTs(ts uint64) {
+	s.gcMu.Lock()
+	defer s.gcMu.Unlock()
+	if oldest, ok := s.epochs.advance(); ok && oldest < ts {
+		ts = oldest
+	}
+	s.gc(ts)
+}
+
+// gc unlinks the versions ended at or below the discard timestamp, which
+// are invisible to the transactions reading at or above it, and removes
+// the keys without versions. The caller must hold gcMu.
+func (s *backend[T]) gc(discardTs uint64) {
+	stats := Stats{
+		DiscardTs:         discardTs,
+		Runs:              s.stats.Runs + 1,
+		ReclaimedVersions: s.stats.ReclaimedVersions,
+		RemovedKeys:       s.stats.RemovedKeys,
+	}
+
+	var empty [][]byte
+	iter := s.root.Iterator(nil, nil)
+	for iter.Next() {
+		head := iter.Value()
+		head.mu.Lock()
+		reclaimed := head.truncate(discardTs)
+		length := head.len()
+		head.mu.Unlock()
+
+		stats.ReclaimedVersions += uint64(reclaimed)
+		if length == 0 {
+			empty = append(empty, iter.Key())
+			continue
+		}
+		stats.Keys++
+		stats.Versions += length
+		if length > stats.MaxChainLength {
+			stats.MaxChainLength = length
+		}
+	}
+
+	for _, key := range empty {
+		head, exists := s.root.Search(key)
+		if !exists {
+			continue
+		}
+		head.mu.Lock()
+		if head.next == nil {
+			// a writer finding the head removed inserts a new one
+			head.removed = true
+			s.root.Remove(art.Key(key))
+			stats.RemovedKeys++
+		} else {
+			stats.Keys++
+			stats.Versions += head.len()
+		}
+		head.mu.Unlock()
+	}
+	stats.ActiveTxns = s.epochs.len()
+	s.stats = stats
+}
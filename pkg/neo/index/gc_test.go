@@ -0,0 +1,151 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// updateHelper commits value to key at the commitTs, reading at commitTs-1.
+func updateHelper(t *testing.T, s Index[int], key string, value int, commitTs uint64) {
+	txn := s.NewTransactionAt(commitTs-1, true)
+	setHelper[int](t, txn, key, value)
+	assert.Nil(t, txn.CommitAt(commitTs, nil))
+}
+
+func TestGC_ReclaimVersions(t *testing.T) {
+	s := New[int](Options{})
+	for ts := uint64(1); ts <= 5; ts++ {
+		updateHelper(t, s, "hello", int(ts), ts)
+		updateHelper(t, s, "alice", int(ts), ts)
+	}
+
+	s.SetDiscardTs(5)
+	stats := s.Stats()
+	assert.Equal(t, 2, stats.Keys)
+	assert.Equal(t, 2, stats.Versions)
+	assert.Equal(t, 1, stats.MaxChainLength)
+	assert.Equal(t, uint64(8), stats.ReclaimedVersions)
+	assert.Equal(t, uint64(1), stats.Runs)
+
+	txn := s.NewTransactionAt(5, false)
+	v, err := txn.Get([]byte("hello"))
+	assert.Nil(t, err)
+	assert.Equal(t, 5, v)
+	txn.Discard()
+
+	// the reclaimed versions are counted once
+	s.SetDiscardTs(5)
+	assert.Equal(t, uint64(8), s.Stats().ReclaimedVersions)
+	assert.Equal(t, uint64(2), s.Stats().Runs)
+}
+
+func TestGC_ActiveTxn(t *testing.T) {
+	s := New[int](Options{})
+	updateHelper(t, s, "hello", 1, 1)
+	updateHelper(t, s, "hello", 2, 2)
+
+	// the old reader holds the versions visible at its read timestamp
+	reader := s.NewTransactionAt(2, false)
+	updateHelper(t, s, "hello", 3, 3)
+	updateHelper(t, s, "hello", 4, 4)
+	s.SetDiscardTs(4)
+	stats := s.Stats()
+	assert.Equal(t, uint64(2), stats.DiscardTs)
+	assert.Equal(t, 1, stats.ActiveTxns)
+	assert.Equal(t, 3, stats.MaxChainLength)
+	assert.Equal(t, uint64(1), stats.ReclaimedVersions)
+
+	v, err := reader.Get([]byte("hello"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, v)
+	reader.Discard()
+
+	s.SetDiscardTs(4)
+	stats = s.Stats()
+	assert.Equal(t, uint64(4), stats.DiscardTs)
+	assert.Equal(t, 0, stats.ActiveTxns)
+	assert.Equal(t, 1, stats.MaxChainLength)
+	assert.Equal(t, uint64(3), stats.ReclaimedVersions)
+}
+
+func TestGC_PendingVersions(t *testing.T) {
+	s := New[int](Options{})
+	updateHelper(t, s, "hello", 1, 1)
+
+	writer := s.NewTransactionAt(1, true)
+	setHelper[int](t, writer, "hello", 2)
+	s.SetDiscardTs(1)
+	assert.Equal(t, 2, s.Stats().MaxChainLength)
+	assert.Equal(t, uint64(0), s.Stats().ReclaimedVersions)
+	assert.Nil(t, writer.CommitAt(2, nil))
+
+	s.SetDiscardTs(2)
+	assert.Equal(t, 1, s.Stats().MaxChainLength)
+	assert.Equal(t, uint64(1), s.Stats().ReclaimedVersions)
+}
+
+func TestGC_RemoveKeys(t *testing.T) {
+	s := New[int](Options{})
+	updateHelper(t, s, "hello", 1, 1)
+
+	txn := s.NewTransactionAt(1, true)
+	setHelper[int](t, txn, "alice", 1)
+	txn.Discard()
+
+	s.SetDiscardTs(1)
+	stats := s.Stats()
+	assert.Equal(t, 1, stats.Keys)
+	assert.Equal(t, uint64(1), stats.RemovedKeys)
+
+	// the removed key can be written again
+	updateHelper(t, s, "alice", 2, 2)
+	txn = s.NewTransactionAt(2, false)
+	v, err := txn.Get([]byte("alice"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, v)
+	txn.Discard()
+
+	s.SetDiscardTs(2)
+	assert.Equal(t, 2, s.Stats().Keys)
+}
+
+func TestGC_Tombstones(t *testing.T) {
+	s := New[int](Options{})
+	updateHelper(t, s, "hello", 1, 1)
+	updateHelper(t, s, "alice", 1, 1)
+
+	txn := s.NewTransactionAt(1, true)
+	assert.Nil(t, txn.Delete([]byte("hello")))
+	assert.Nil(t, txn.CommitAt(2, nil))
+
+	// the reader below the tombstone holds the deleted version
+	s.SetDiscardTs(1)
+	assert.Equal(t, 2, s.Stats().Keys)
+	assert.Equal(t, 2, s.Stats().MaxChainLength)
+
+	s.SetDiscardTs(2)
+	stats := s.Stats()
+	assert.Equal(t, 1, stats.Keys)
+	assert.Equal(t, uint64(2), stats.ReclaimedVersions)
+	assert.Equal(t, uint64(1), stats.RemovedKeys)
+
+	txn = s.NewTransactionAt(2, false)
+	_, err := txn.Get([]byte("hello"))
+	assert.Equal(t, ErrKeyNotExists, err)
+	txn.Discard()
+}
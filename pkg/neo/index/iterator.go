@@ -0,0 +1,110 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index
+
+import (
+	"bytes"
+
+	"github.com/casbin-mesh/neo/pkg/storage/mem/index/art"
+)
+
+// Iterator iterates over a snapshot of the keys visible to a transaction.
+type Iterator[T any] interface {
+	// Next moves to the next visible key, it returns false when the
+	// iteration is finished or failed.
+	Next() bool
+	Key() []byte
+	Value() T
+	// Err returns the error stopped the iteration, e.g. the write-lock of
+	// a version is held by another transaction.
+	Err() error
+}
+
+type treeIterator[T any] interface {
+	Next() bool
+	Key() art.Key
+	Value() T
+}
+
+// iterator reads every key of the tree iterator through the transaction,
+// the keys inserted after its read timestamp, and the tombstones, are skipped.
+type iterator[T any] struct {
+	txn *txn[T]
+	// start is checked before the tree iterator, which excludes it
+	start []byte
+	// end is excluded, while the tree iterator includes it
+	end  []byte
+	iter treeIterator[*VersionChainHead[T]]
+
+	key   []byte
+	value T
+	err   error
+}
+
+func (it *iterator[T]) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if len(it.start) != 0 && (len(it.end) == 0 || bytes.Compare(it.start, it.end) < 0) {
+		key := it.start
+		it.start = nil
+		if it.load(key) {
+			return true
+		}
+		if it.err != nil {
+			return false
+		}
+	}
+	it.start = nil
+	for it.iter.Next() {
+		key := it.iter.Key()
+		if len(it.end) != 0 && bytes.Equal(key, it.end) {
+			return false
+		}
+		if it.load(key) {
+			return true
+		}
+		if it.err != nil {
+			return false
+		}
+	}
+	return false
+}
+
+// load reads the key, returns false if the key is invisible.
+func (it *iterator[T]) load(key []byte) bool {
+	value, err := it.txn.Get(key)
+	if err == ErrKeyNotExists {
+		return false
+	}
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.key, it.value = key, value
+	return true
+}
+
+func (it *iterator[T]) Key() []byte {
+	return it.key
+}
+
+func (it *iterator[T]) Value() T {
+	return it.value
+}
+
+func (it *iterator[T]) Err() error {
+	return it.err
+}
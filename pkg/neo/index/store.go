@@ -15,12 +15,18 @@
 package index
 
 import (
+	"sync"
+
 	"github.com/casbin-mesh/neo/pkg/storage/mem/index/art"
-	"sync/atomic"
 )
 
 type Index[T any] interface {
 	NewTransactionAt(readTs uint64, update bool) Txn[T]
+	// SetDiscardTs sets the timestamp at or below which no transaction reads,
+	// and reclaims the versions invisible to the active transactions.
+	SetDiscardTs(ts uint64)
+	// Stats returns the statistics of the version chains as of the last garbage collection.
+	Stats() Stats
 }
 
 type backend[T any] struct {
@@ -31,8 +37,11 @@ type backend[T any] struct {
 	// TODO(weny): change it to sync version and supports the generic
 	root *art.Tree[*VersionChainHead[T]]
 
-	// TODO: move it to epochs
-	txnCnt uint64
+	epochs *epochs
+
+	// gcMu serializes the garbage collections, and guards stats
+	gcMu  sync.Mutex
+	stats Stats
 }
 
 type Options struct {
@@ -40,25 +49,20 @@ type Options struct {
 
 func New[T any](opts Options) Index[T] {
 	return &backend[T]{
-		root: &art.Tree[*VersionChainHead[T]]{},
+		root:   &art.Tree[*VersionChainHead[T]]{},
+		epochs: newEpochs(),
 	}
 }
 
-func (s *backend[T]) incRef() {
-	atomic.AddUint64(&s.txnCnt, uint64(1))
-}
-
-func (s *backend[T]) decRef() {
-	atomic.AddUint64(&s.txnCnt, ^uint64(1))
-}
-
 func (s *backend[T]) NewTransactionAt(readTs uint64, update bool) Txn[T] {
-	s.incRef()
+	id := s.epochs.enter(readTs)
 	return &txn[T]{
 		pendingWrites: make(map[string]*Value[T]),
-		decRef:        s.decRef,
-		root:          s.root,
-		readTs:        readTs,
-		update:        update,
+		exitEpoch: func() {
+			s.epochs.exit(id)
+		},
+		root:   s.root,
+		readTs: readTs,
+		update: update,
 	}
 }
@@ -93,3 +93,186 @@ func TestTxn(t *testing.T) {
 	})
 
 }
+
+func TestTxn_ReadModifyWrite(t *testing.T) {
+	s := New[int](Options{})
+	txn1 := s.NewTransactionAt(1, true)
+	setHelper[int](t, txn1, "counter", 1)
+	assert.Nil(t, txn1.CommitAt(1, nil))
+
+	// a transaction is allowed to update the version it has read
+	txn2 := s.NewTransactionAt(2, true)
+	v, err := txn2.Get([]byte("counter"))
+	assert.Nil(t, err)
+	setHelper[int](t, txn2, "counter", v+1)
+	assert.Nil(t, txn2.CommitAt(2, nil))
+
+	txn3 := s.NewTransactionAt(3, false)
+	v, err = txn3.Get([]byte("counter"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, v)
+}
+
+func TestTxn_SharedReadTs(t *testing.T) {
+	s := New[int](Options{})
+	txn1 := s.NewTransactionAt(1, true)
+	setHelper[int](t, txn1, "counter", 1)
+	assert.Nil(t, txn1.CommitAt(2, nil))
+
+	// the transactions read at 2, their writes are committed above it
+	txn2 := s.NewTransactionAt(2, true)
+	txn3 := s.NewTransactionAt(2, true)
+	reader := s.NewTransactionAt(2, false)
+	for _, txn := range []Txn[int]{txn2, txn3, reader} {
+		v, err := txn.Get([]byte("counter"))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, v)
+	}
+
+	// the reads at 2 don't forbid a write at 2
+	setHelper[int](t, txn2, "counter", 2)
+	assert.Equal(t, ErrFailedToAcquireWLock, txn3.Set([]byte("counter"), 2))
+	assert.Nil(t, txn2.CommitAt(3, nil))
+
+	// the version committed after the snapshot of txn3 conflicts
+	assert.Equal(t, ErrWriteConflicts, txn3.Set([]byte("counter"), 2))
+	txn3.Discard()
+	v, err := reader.Get([]byte("counter"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, v)
+
+	// a read above the id of the writer forbids the write
+	txn4 := s.NewTransactionAt(3, true)
+	_, err = s.NewTransactionAt(4, false).Get([]byte("counter"))
+	assert.Nil(t, err)
+	assert.Equal(t, ErrFailedToAcquireWLock, txn4.Set([]byte("counter"), 3))
+}
+
+func TestTxn_Discard(t *testing.T) {
+	s := New[int](Options{})
+	txn1 := s.NewTransactionAt(1, true)
+	setHelper[int](t, txn1, "hello", 1)
+	assert.Nil(t, txn1.CommitAt(1, nil))
+
+	txn2 := s.NewTransactionAt(2, true)
+	setHelper[int](t, txn2, "hello", 2)
+	setHelper[int](t, txn2, "alice", 2)
+	txn2.Discard()
+
+	// the pending versions are gone, and their write-locks are released
+	txn3 := s.NewTransactionAt(3, true)
+	v, err := txn3.Get([]byte("hello"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, v)
+	_, err = txn3.Get([]byte("alice"))
+	assert.Equal(t, ErrKeyNotExists, err)
+	setHelper[int](t, txn3, "hello", 3)
+	setHelper[int](t, txn3, "alice", 3)
+	assert.Nil(t, txn3.CommitAt(3, nil))
+
+	txn4 := s.NewTransactionAt(4, false)
+	v, err = txn4.Get([]byte("hello"))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, v)
+	v, err = txn4.Get([]byte("alice"))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, v)
+}
+
+func TestTxn_Delete(t *testing.T) {
+	s := New[int](Options{})
+	txn1 := s.NewTransactionAt(1, true)
+	setHelper[int](t, txn1, "hello", 1)
+	assert.Nil(t, txn1.CommitAt(1, nil))
+
+	txn2 := s.NewTransactionAt(2, true)
+	assert.Nil(t, txn2.Delete([]byte("hello")))
+	assert.Equal(t, ErrKeyNotExists, txn2.Delete([]byte("hello")))
+	assert.Equal(t, ErrKeyNotExists, txn2.Delete([]byte("alice")))
+	_, err := txn2.Get([]byte("hello"))
+	assert.Equal(t, ErrKeyNotExists, err)
+
+	// the old reader still sees the deleted key
+	reader := s.NewTransactionAt(2, false)
+	assert.Nil(t, txn2.CommitAt(3, nil))
+	v, err := reader.Get([]byte("hello"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, v)
+	reader.Discard()
+
+	txn3 := s.NewTransactionAt(3, true)
+	_, err = txn3.Get([]byte("hello"))
+	assert.Equal(t, ErrKeyNotExists, err)
+	// the deleted key can be written again
+	setHelper[int](t, txn3, "hello", 3)
+	assert.Nil(t, txn3.CommitAt(4, nil))
+
+	txn4 := s.NewTransactionAt(4, true)
+	v, err = txn4.Get([]byte("hello"))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, v)
+	// the discarded tombstone is undone
+	assert.Nil(t, txn4.Delete([]byte("hello")))
+	txn4.Discard()
+
+	txn5 := s.NewTransactionAt(5, false)
+	v, err = txn5.Get([]byte("hello"))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, v)
+}
+
+func iterHelper[T any](t *testing.T, iter Iterator[T]) (keys []string, values []T) {
+	for iter.Next() {
+		keys = append(keys, string(iter.Key()))
+		values = append(values, iter.Value())
+	}
+	assert.Nil(t, iter.Err())
+	return keys, values
+}
+
+func TestTxn_Iterator(t *testing.T) {
+	s := New[int](Options{})
+	txn1 := s.NewTransactionAt(1, true)
+	for i, key := range []string{"a", "b", "c", "d", "e"} {
+		setHelper[int](t, txn1, key, i)
+	}
+	assert.Nil(t, txn1.CommitAt(1, nil))
+
+	txn2 := s.NewTransactionAt(1, true)
+	assert.Nil(t, txn2.Delete([]byte("b")))
+	setHelper[int](t, txn2, "c", 20)
+	setHelper[int](t, txn2, "f", 50)
+	assert.Nil(t, txn2.CommitAt(2, nil))
+
+	t.Run("should iterate over the snapshot", func(t *testing.T) {
+		reader := s.NewTransactionAt(1, false)
+		keys, values := iterHelper(t, reader.Iterator(nil, nil))
+		assert.Equal(t, []string{"a", "b", "c", "d", "e"}, keys)
+		assert.Equal(t, []int{0, 1, 2, 3, 4}, values)
+		reader.Discard()
+
+		reader = s.NewTransactionAt(2, false)
+		keys, values = iterHelper(t, reader.Iterator(nil, nil))
+		assert.Equal(t, []string{"a", "c", "d", "e", "f"}, keys)
+		assert.Equal(t, []int{0, 20, 3, 4, 50}, values)
+		reader.Discard()
+	})
+	t.Run("should include start and exclude end", func(t *testing.T) {
+		reader := s.NewTransactionAt(2, false)
+		keys, _ := iterHelper(t, reader.Iterator([]byte("c"), []byte("e")))
+		assert.Equal(t, []string{"c", "d"}, keys)
+		keys, _ = iterHelper(t, reader.Iterator([]byte("bb"), []byte("ee")))
+		assert.Equal(t, []string{"c", "d", "e"}, keys)
+		keys, _ = iterHelper(t, reader.Iterator([]byte("e"), []byte("c")))
+		assert.Empty(t, keys)
+		reader.Discard()
+	})
+	t.Run("should see pending writes", func(t *testing.T) {
+		writer := s.NewTransactionAt(2, true)
+		assert.Nil(t, writer.Delete([]byte("a")))
+		setHelper[int](t, writer, "g", 60)
+		keys, _ := iterHelper(t, writer.Iterator(nil, nil))
+		assert.Equal(t, []string{"c", "d", "e", "f", "g"}, keys)
+		writer.Discard()
+	})
+}
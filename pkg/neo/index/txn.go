@@ -23,7 +23,17 @@ import (
 type Txn[T any] interface {
 	Get(key []byte) (ret T, err error)
 	Set(key []byte, value T) error
+	// Delete installs a tombstone version of the key, it returns ErrKeyNotExists
+	// if the key is invisible to the transaction.
+	Delete(key []byte) error
+	// Iterator returns an iterator over the keys in [start, end) visible to the
+	// transaction in ascending order, an empty end means no upper bound.
+	Iterator(start, end []byte) Iterator[T]
+	// CommitAt installs the pending writes at commitTs, it always returns nil:
+	// their write-locks are acquired when they are made, so nothing conflicts.
 	CommitAt(commitTs uint64, callback func(error)) error
+	// Discard undoes the pending writes and releases their write-locks.
+	Discard()
 	ReadTS() uint64
 }
 
@@ -31,35 +41,39 @@ type txn[T any] struct {
 	readTs        uint64
 	update        bool
 	root          *art.Tree[*VersionChainHead[T]]
-	decRef        func()
+	exitEpoch     func()
 	pendingWrites map[string]*Value[T]
 	discarded     bool
 }
 
-func (m *txn[T]) Discord() {
+func (m *txn[T]) Discard() {
 	defer func() {
 		if !m.discarded {
 			m.discarded = true
-			m.decRef()
+			m.exitEpoch()
 		}
 	}()
 
-	for key, _ := range m.pendingWrites {
-		value, exists := m.root.Search(art.Key(key))
+	for key, wr := range m.pendingWrites {
+		head, exists := m.root.Search(art.Key(key))
 		if exists {
-			head := value
 			head.mu.Lock()
-			head.next = head.next.next
-			head.next.txn = 0
+			if head.next == wr {
+				head.next = wr.next
+			}
+			if wr.next != nil {
+				atomic.StoreUint64(&wr.next.txn, 0)
+			}
 			head.mu.Unlock()
-			delete(m.pendingWrites, key) // release resources
 		}
+		delete(m.pendingWrites, key) // release resources
 	}
 }
 
 func (m *txn[T]) getVersion(key []byte, txnId uint64) (v *Value[T], err error) {
 	value, exists := m.root.Search(key)
 	if exists {
+		value.mu.Lock()
 		head := value.next
 		for head != nil {
 			if txnId < head.beginTs || head.uncommitted {
@@ -68,6 +82,7 @@ func (m *txn[T]) getVersion(key []byte, txnId uint64) (v *Value[T], err error) {
 			}
 			break
 		}
+		value.mu.Unlock()
 		v = head
 		if v == nil {
 			return v, ErrKeyNotExists
@@ -84,33 +99,70 @@ func (m *txn[T]) getVersion(key []byte, txnId uint64) (v *Value[T], err error) {
 			}
 			readTs = atomic.LoadUint64(&v.readTs)
 		}
+		if v.deleted {
+			return v, ErrKeyNotExists
+		}
 		return v, nil
 	}
 	return nil, ErrKeyNotExists
 }
 
-func (m *txn[T]) newVersion(key []byte, txnId uint64, value T) (*Value[T], error) {
+func (m *txn[T]) newVersion(key []byte, txnId uint64, value T, deleted bool) (*Value[T], error) {
 	head, exists := m.root.Search(key)
 	// TODO: change the insert to atomic
 	// TODO: add SearchOrInsert
+	if exists {
+		head.mu.Lock()
+		if head.removed {
+			// the key has been removed by the garbage collection
+			exists = false
+		}
+		head.mu.Unlock()
+	}
 	if !exists {
 		vi := &Value[T]{
 			txn:         txnId, //w-lock held
 			value:       value,
+			deleted:     deleted,
 			uncommitted: true,
 		}
 		m.root.Insert(key, &VersionChainHead[T]{next: vi})
 		return vi, nil
 	}
+	head.mu.Lock()
 	previous := head.next
+	if previous == nil {
+		// all versions of the key were discarded
+		vi := &Value[T]{
+			txn:         txnId, //w-lock held
+			value:       value,
+			deleted:     deleted,
+			uncommitted: true,
+		}
+		head.next = vi
+		head.mu.Unlock()
+		return vi, nil
+	}
+	// the commit timestamp of a committed version is set under the lock
+	prevBeginTs, prevCommitted := previous.beginTs, !previous.uncommitted
+	head.mu.Unlock()
+	if prevCommitted && prevBeginTs > txnId {
+		// the transactions share their read timestamps as ids, the version is committed by
+		// a concurrent one after txnId, the first committer wins
+		return nil, ErrWriteConflicts
+	}
 	prevTxnId := atomic.LoadUint64(&previous.txn)
 	prevReadTs := atomic.LoadUint64(&previous.readTs)
-	allowed := txnId > prevReadTs && // txnId is larger previous version's readTs
+	// A version is committed above the read timestamp of its writer, it's invisible to the transactions
+	// reading at txnId, so a read at txnId, e.g. by the writer itself, doesn't forbid the write, a later one does.
+	allowed := txnId >= prevReadTs && // no transaction reading after txnId has read the previous version
 		atomic.CompareAndSwapUint64(&previous.txn, 0, txnId) // // no active transaction holds previous version write lock
 
 	if allowed {
 		vi := &Value[T]{
 			txn:         txnId, //w-lock held
+			value:       value,
+			deleted:     deleted,
 			next:        previous,
 			uncommitted: true,
 		}
@@ -132,6 +184,9 @@ func (m *txn[T]) Get(key []byte) (ret T, err error) {
 	// read pending writes
 	v, ok := m.pendingWrites[string(key)]
 	if ok {
+		if v.deleted {
+			return ret, ErrKeyNotExists
+		}
 		return v.value, nil
 	}
 
@@ -160,10 +215,35 @@ func (m *txn[T]) Set(key []byte, value T) error {
 	v, ok := m.pendingWrites[string(key)]
 	if ok {
 		v.value = value
+		v.deleted = false
+		return nil
+	}
+
+	vi, err := m.newVersion(key, m.readTs, value, false)
+	if err != nil {
+		return err
+	}
+	m.pendingWrites[string(key)] = vi
+	return nil
+}
+
+func (m *txn[T]) Delete(key []byte) error {
+	var zero T
+	v, ok := m.pendingWrites[string(key)]
+	if ok {
+		if v.deleted {
+			return ErrKeyNotExists
+		}
+		v.value = zero
+		v.deleted = true
 		return nil
 	}
 
-	vi, err := m.newVersion(key, m.readTs, value)
+	// a tombstone is only installed over a visible version
+	if _, err := m.getVersion(key, m.readTs); err != nil {
+		return err
+	}
+	vi, err := m.newVersion(key, m.readTs, zero, true)
 	if err != nil {
 		return err
 	}
@@ -171,11 +251,20 @@ func (m *txn[T]) Set(key []byte, value T) error {
 	return nil
 }
 
+func (m *txn[T]) Iterator(start, end []byte) Iterator[T] {
+	return &iterator[T]{
+		txn:   m,
+		start: start,
+		end:   end,
+		iter:  m.root.Iterator(start, end),
+	}
+}
+
 func (m *txn[T]) CommitAt(commitTs uint64, callback func(error)) error {
 	defer func() {
 		if !m.discarded {
 			m.discarded = true
-			m.decRef()
+			m.exitEpoch()
 		}
 	}()
 
@@ -183,6 +272,10 @@ func (m *txn[T]) CommitAt(commitTs uint64, callback func(error)) error {
 	// the DBMS sets Bx+1’s begin-ts and end-ts fields to Tid and INF (respectively),
 	// and Bx’s end-ts field to Tid.
 	for key, wr := range m.pendingWrites {
+		head, exists := m.root.Search(art.Key(key))
+		if exists {
+			head.mu.Lock()
+		}
 		if wr.next != nil {
 			wr.txn = 0
 			wr.next.txn = 0
@@ -192,6 +285,9 @@ func (m *txn[T]) CommitAt(commitTs uint64, callback func(error)) error {
 		wr.beginTs = commitTs
 		wr.endTs = ^uint64(0)
 		wr.uncommitted = false
+		if exists {
+			head.mu.Unlock()
+		}
 		delete(m.pendingWrites, key)
 	}
 	return nil
@@ -19,6 +19,41 @@ import "sync"
 type VersionChainHead[T any] struct {
 	next *Value[T]
 	mu   sync.Mutex
+	// removed is set when the garbage collection removes the key from the index
+	removed bool
+}
+
+// truncate unlinks the versions ended at or below discardTs, and the
+// tombstone visible at discardTs along with the versions before it,
+// returns the number of versions unlinked. The caller must hold mu.
+func (h *VersionChainHead[T]) truncate(discardTs uint64) (n int) {
+	var prev *Value[T]
+	for v := h.next; v != nil; prev, v = v, v.next {
+		if v.uncommitted {
+			continue
+		}
+		if v.endTs > discardTs && !(v.deleted && v.beginTs <= discardTs) {
+			continue
+		}
+		if prev == nil {
+			h.next = nil
+		} else {
+			prev.next = nil
+		}
+		for ; v != nil; v = v.next {
+			n++
+		}
+		return n
+	}
+	return 0
+}
+
+// len returns the number of versions. The caller must hold mu.
+func (h *VersionChainHead[T]) len() (n int) {
+	for v := h.next; v != nil; v = v.next {
+		n++
+	}
+	return n
 }
 
 // Value value and Timestamp Ordering header (MVTO)
@@ -30,6 +65,8 @@ type Value[T any] struct {
 	beginTs     uint64
 	endTs       uint64 // TODO: uses uint64.MAX to represent the +INF ?
 	uncommitted bool
+	// deleted marks a tombstone, the key is invisible to the transactions reading it
+	deleted bool
 
 	// pointer to older version
 	next *Value[T]
@@ -15,6 +15,7 @@
 package meta
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -28,6 +29,8 @@ type Reader interface {
 	GetIndexId(tid uint64, index string) (uint64, error)
 	GetMatcherId(did uint64, matcher string) (uint64, error)
 	GetColumnId(tid uint64, column string) (uint64, error)
+	// ListDbs returns the namespaces and their ids in ascending order of the namespaces.
+	ListDbs() (namespaces []string, ids []uint64, err error)
 }
 
 type ReaderWriter interface {
@@ -37,8 +40,23 @@ type ReaderWriter interface {
 	NewIndex(tid uint64, indexName string) (indexId uint64, err error)
 	NewMatcher(did uint64, matcher string) (matcherId uint64, err error)
 	NewColumn(tid uint64, column string) (columnId uint64, err error)
+	DeleteDb(namespace string) error
+	DeleteTable(did uint64, tableName string) error
+	DeleteIndex(tid uint64, indexName string) error
+	DeleteMatcher(did uint64, matcher string) error
+	DeleteColumn(tid uint64, column string) error
 
-	CommitAt(commitTs uint64) error
+	// RestoreDb restores the id of a database recovered from the storage,
+	// the ids up to it are never allocated again.
+	RestoreDb(namespace string, id uint64) error
+	RestoreTable(did uint64, tableName string, id uint64) error
+	RestoreIndex(tid uint64, indexName string, id uint64) error
+	RestoreMatcher(did uint64, matcher string, id uint64) error
+	RestoreColumn(tid uint64, column string, id uint64) error
+
+	// CommitAt commits the writes at commitTs, it never fails, so a session
+	// commits it after the Badger txn, which may conflict.
+	CommitAt(commitTs uint64)
 	Rollback()
 }
 
@@ -68,12 +86,13 @@ func uint642Bytes(v uint64) [8]byte {
 	return data
 }
 
-func (i *inMemMeta) CommitAt(commitTs uint64) error {
-	return i.Txn.CommitAt(commitTs, nil)
+func (i *inMemMeta) CommitAt(commitTs uint64) {
+	// never fails, see index.Txn
+	_ = i.Txn.CommitAt(commitTs, nil)
 }
 
 func (i *inMemMeta) Rollback() {
-	//TODO: TBD
+	i.Txn.Discard()
 }
 
 // incUint64 increases the value for key in index by step, returns increased value.
@@ -131,6 +150,55 @@ func (i *inMemMeta) GetColumnId(tid uint64, column string) (uint64, error) {
 	return i.getMeta(codec.ColumnKey(tid, column))
 }
 
+func (i *inMemMeta) ListDbs() (namespaces []string, ids []uint64, err error) {
+	prefix := codec.MetaKeyPrefix()
+	iter := i.Iterator(prefix, nil)
+	for iter.Next() {
+		key := iter.Key()
+		if !bytes.HasPrefix(key, prefix) {
+			break
+		}
+		id, ok := iter.Value().(uint64)
+		if !ok {
+			return nil, nil, ErrUnknownType
+		}
+		namespaces = append(namespaces, string(key[len(prefix):]))
+		ids = append(ids, id)
+	}
+	if err = iter.Err(); err != nil {
+		return nil, nil, err
+	}
+	return namespaces, ids, nil
+}
+
+func (i *inMemMeta) deleteMeta(key []byte) error {
+	err := i.Delete(key)
+	if IsErrNotFound(err) {
+		return ErrKeyNotExists
+	}
+	return err
+}
+
+func (i *inMemMeta) DeleteDb(namespace string) error {
+	return i.deleteMeta(codec.MetaKey(namespace))
+}
+
+func (i *inMemMeta) DeleteTable(did uint64, tableName string) error {
+	return i.deleteMeta(codec.TableKey(did, tableName))
+}
+
+func (i *inMemMeta) DeleteIndex(tid uint64, indexName string) error {
+	return i.deleteMeta(codec.IndexKey(tid, indexName))
+}
+
+func (i *inMemMeta) DeleteMatcher(did uint64, matcher string) error {
+	return i.deleteMeta(codec.MatcherKey(did, matcher))
+}
+
+func (i *inMemMeta) DeleteColumn(tid uint64, column string) error {
+	return i.deleteMeta(codec.ColumnKey(tid, column))
+}
+
 type nextIdGen func() (uint64, error)
 
 func (i *inMemMeta) newMeta(key []byte, idGen nextIdGen) (uint64, error) {
@@ -179,6 +247,48 @@ func (i *inMemMeta) NewMatcher(did uint64, matcher string) (matcherId uint64, er
 	})
 }
 
+// restoreMeta sets the id of key, and raises the counter to the id.
+func (i *inMemMeta) restoreMeta(key []byte, counter []byte, id uint64) error {
+	if err := i.Set(key, id); err != nil {
+		return err
+	}
+	old, err := i.Get(counter)
+	if IsErrNotFound(err) {
+		return i.Set(counter, id)
+	}
+	if err != nil {
+		return err
+	}
+	val, ok := old.(uint64)
+	if !ok {
+		return ErrUnknownType
+	}
+	if val < id {
+		return i.Set(counter, id)
+	}
+	return nil
+}
+
+func (i *inMemMeta) RestoreDb(namespace string, id uint64) error {
+	return i.restoreMeta(codec.MetaKey(namespace), mNextGlobalIDKey, id)
+}
+
+func (i *inMemMeta) RestoreTable(did uint64, tableName string, id uint64) error {
+	return i.restoreMeta(codec.TableKey(did, tableName), mNextGlobalTableIDKey, id)
+}
+
+func (i *inMemMeta) RestoreIndex(tid uint64, indexName string, id uint64) error {
+	return i.restoreMeta(codec.IndexKey(tid, indexName), mNextGlobalIndexIDKey, id)
+}
+
+func (i *inMemMeta) RestoreMatcher(did uint64, matcher string, id uint64) error {
+	return i.restoreMeta(codec.MatcherKey(did, matcher), mNextGlobalMatcherIDKey, id)
+}
+
+func (i *inMemMeta) RestoreColumn(tid uint64, column string, id uint64) error {
+	return i.restoreMeta(codec.ColumnKey(tid, column), mNextGlobalColumnIDKey, id)
+}
+
 func NewInMemMeta(index index.Txn[any]) ReaderWriter {
 	return &inMemMeta{index}
 }
@@ -51,7 +51,7 @@ func metaHelper(t *testing.T) (index index.Index[any], ts uint64) {
 	assert.Nil(t, err)
 
 	// commit it
-	assert.Nil(t, meta.CommitAt(2))
+	meta.CommitAt(2)
 	return index, 2
 }
 
@@ -85,7 +85,7 @@ func TestInMemMeta_NewDb(t *testing.T) {
 	assert.Nil(t, err)
 
 	// commit it
-	assert.Nil(t, meta.CommitAt(2))
+	meta.CommitAt(2)
 }
 
 type GetSet struct {
@@ -104,3 +104,43 @@ func TestInMemMeta_GetDBId(t *testing.T) {
 		assert.Nil(t, err)
 	}
 }
+
+func TestInMemMeta_DeleteDb(t *testing.T) {
+	index, readTs := metaHelper(t)
+	meta := NewInMemMeta(index.NewTransactionAt(readTs, true))
+	assert.Nil(t, meta.DeleteDb("test_namespace"))
+	assert.Equal(t, ErrKeyNotExists, meta.DeleteDb("test_namespace"))
+	_, err := meta.GetDBId("test_namespace")
+	assert.Equal(t, ErrKeyNotExists, err)
+	meta.CommitAt(readTs + 1)
+
+	meta = NewInMemMeta(index.NewTransactionAt(readTs+1, true))
+	namespaces, ids, err := meta.ListDbs()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"test_namespace_2"}, namespaces)
+	assert.Equal(t, []uint64{2}, ids)
+
+	// the namespace can be created again with a new id
+	id, err := meta.NewDb("test_namespace")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), id)
+	namespaces, ids, err = meta.ListDbs()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"test_namespace", "test_namespace_2"}, namespaces)
+	assert.Equal(t, []uint64{3, 2}, ids)
+}
+
+func TestInMemMeta_RestoreDb(t *testing.T) {
+	meta := NewInMemMeta(newIndex[any]().NewTransactionAt(1, true))
+	assert.Nil(t, meta.RestoreDb("test_namespace_2", 2))
+	assert.Nil(t, meta.RestoreDb("test_namespace", 1))
+
+	id, err := meta.GetDBId("test_namespace_2")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), id)
+
+	// the restored ids are not allocated again
+	id, err = meta.NewDb("test_namespace_3")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), id)
+}
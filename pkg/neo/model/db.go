@@ -5,7 +5,10 @@ import (
 	"strings"
 )
 
-var ErrTableNotExists = errors.New("table not exits")
+var (
+	ErrTableNotExists   = errors.New("table not exits")
+	ErrMatcherNotExists = errors.New("matcher not exists")
+)
 
 type DBInfo struct {
 	ID          uint64
@@ -44,3 +47,12 @@ func (d *DBInfo) TableById(id uint64) (*TableInfo, error) {
 	}
 	return nil, ErrTableNotExists
 }
+
+func (d *DBInfo) MatcherByLName(name string) (*MatcherInfo, error) {
+	for _, info := range d.MatcherInfo {
+		if info.Name.L == strings.ToLower(name) {
+			return info, nil
+		}
+	}
+	return nil, ErrMatcherNotExists
+}
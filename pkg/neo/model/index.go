@@ -2,6 +2,9 @@ package model
 
 type IndexType uint8
 
+// PrimaryIndexName is the name of the unique index over all the columns of a table.
+const PrimaryIndexName = "primary"
+
 type IndexColumn struct {
 	ColName CIStr
 	Offset  int
@@ -12,16 +12,37 @@ const (
 	PriorityBaseOnRole
 )
 
+const (
+	// EffectColumnName is the policy column holds the effect of a rule, a rule without it is allowed.
+	EffectColumnName = "eft"
+	// PriorityColumnName is the policy column holds the priority of a rule, the lower the higher.
+	PriorityColumnName = "priority"
+
+	AllowEffect = "allow"
+	DenyEffect  = "deny"
+)
+
 type MatcherInfo struct {
 	ID           uint64
 	Name         CIStr
 	Raw          string
 	EffectPolicy EffectPolicyType
 	Predicate    ast.Evaluable
+	// Request is the accessor name of the request tuple, e.g. r
+	Request CIStr
+	// RequestFields are the request_definition tokens, in order
+	RequestFields []CIStr
+	// Policy is the table that the matcher accesses as its policy tuple, e.g. p
+	Policy CIStr
 }
 
 func (m *MatcherInfo) Clone() *MatcherInfo {
-	return &*m
+	nm := *m
+	if m.Predicate != nil {
+		nm.Predicate = m.Predicate.Clone()
+	}
+	nm.RequestFields = append([]CIStr{}, m.RequestFields...)
+	return &nm
 }
 
 func GenerateEffectPolicyAst(policyTable, eftColumnName, allow, deny string, policyType EffectPolicyType) []ast.Evaluable {
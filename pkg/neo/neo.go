@@ -0,0 +1,191 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package neo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/casbin-mesh/neo/pkg/db"
+	badgerAdapter "github.com/casbin-mesh/neo/pkg/db/adapter/badger"
+	"github.com/casbin-mesh/neo/pkg/neo/executor"
+	"github.com/casbin-mesh/neo/pkg/neo/index"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/neo/planner"
+	"github.com/casbin-mesh/neo/pkg/neo/rbac"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/neo/txn"
+	"github.com/dgraph-io/badger/v3"
+)
+
+var (
+	ErrEngineClosed   = errors.New("engine closed")
+	ErrInvalidRequest = errors.New("invalid request")
+	ErrRoleNotExists  = errors.New("role definition not exists")
+	// ErrSerializationFailure is returned by a serializable engine when a write
+	// conflicts with the reads of concurrent transactions, it's safe to retry.
+	ErrSerializationFailure = txn.ErrSerializationFailure
+)
+
+// ErrCanceled is returned by EnforceContext if its context is canceled or its deadline is exceeded
+// before the enforcement is done, it wraps the error of the context.
+type ErrCanceled = executor.ErrCanceled
+
+type Options struct {
+	// InMemory keeps all data in memory, the dir will be ignored.
+	InMemory bool
+	// Logger is used by the storage layer, nil disables logging.
+	Logger badger.Logger
+	// MaxHierarchyLevel limits the depth of role inheritance, rbac.DefaultMaxHierarchyLevel is used if it's zero.
+	MaxHierarchyLevel int
+	// DiscardInterval is the interval of reclaiming the stale versions, txn.DefaultDiscardInterval is used if it's zero.
+	DiscardInterval time.Duration
+	// Serializable runs the read-write transactions under the serializable snapshot isolation.
+	Serializable bool
+}
+
+var DefaultOptions = Options{}
+
+// Engine is an embeddable Casbin-compatible enforcer,
+// it owns the storage, the timestamps and the sessions.
+type Engine struct {
+	opts      Options
+	db        db.DB
+	metaIndex index.Index[any]
+	infoIndex index.Index[*model.DBInfo]
+	txns      *txn.Manager
+	planner   *planner.Planner
+
+	// roles holds the role graphs: model -> ptype -> role manager
+	rolesMu sync.RWMutex
+	roles   map[string]map[string]*rbac.RoleManager
+	// rolesTs holds the commit timestamp of the latest role rule change applied to the graphs of a model
+	rolesTs map[string]uint64
+}
+
+// Open opens an engine located at dir.
+func Open(dir string, opts *Options) (*Engine, error) {
+	if opts == nil {
+		opts = &DefaultOptions
+	}
+	badgerOpts := badger.DefaultOptions(dir).
+		WithInMemory(opts.InMemory).
+		WithLogger(opts.Logger)
+	if opts.InMemory {
+		badgerOpts = badgerOpts.WithDir("").WithValueDir("")
+	}
+
+	store, err := badgerAdapter.OpenManaged(badgerOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Engine{
+		opts:      *opts,
+		db:        store,
+		metaIndex: index.New[any](index.Options{}),
+		infoIndex: index.New[*model.DBInfo](index.Options{}),
+		planner:   planner.New(),
+		roles:     make(map[string]map[string]*rbac.RoleManager),
+		rolesTs:   make(map[string]uint64),
+	}
+	e.txns = txn.NewManager(e.db, e.metaIndex, e.infoIndex, txn.Options{
+		DiscardInterval: opts.DiscardInterval,
+		Serializable:    opts.Serializable,
+	})
+	if err = e.recover(); err != nil {
+		e.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+// recover loads the models persisted in the storage, and rebuilds their role graphs.
+func (e *Engine) recover() error {
+	ctx := context.TODO()
+	var infos []*model.DBInfo
+	err := e.update(ctx, func(sc session.Context) (err error) {
+		infos, err = sc.GetCatalog().Bootstrap(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		if err = e.BuildRoleLinks(info.Name.L); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close releases all resources held by the engine.
+func (e *Engine) Close() error {
+	if err := e.txns.Close(); err != nil {
+		return ErrEngineClosed
+	}
+	return e.db.Close()
+}
+
+func (e *Engine) newTxn(update bool) (*txn.Txn, error) {
+	t, err := e.txns.NewTxn(update)
+	if err == txn.ErrManagerClosed {
+		return nil, ErrEngineClosed
+	}
+	return t, err
+}
+
+// view runs fn in a read-only session.
+func (e *Engine) view(ctx context.Context, fn func(sc session.Context) error) error {
+	return e.viewTxn(ctx, func(t *txn.Txn) error {
+		return fn(t.Session())
+	})
+}
+
+// viewTxn runs fn in a read-only transaction.
+func (e *Engine) viewTxn(ctx context.Context, fn func(t *txn.Txn) error) error {
+	t, err := e.newTxn(false)
+	if err != nil {
+		return err
+	}
+	defer t.Discard(ctx)
+	return fn(t)
+}
+
+// update runs fn in a read-write session, and commits it if fn succeeds.
+// It returns after the changes are visible to subsequent sessions.
+func (e *Engine) update(ctx context.Context, fn func(sc session.Context) error) error {
+	return e.updateTxn(ctx, func(t *txn.Txn) error {
+		return fn(t.Session())
+	})
+}
+
+// updateTxn runs fn in a read-write transaction, and commits it as update does.
+func (e *Engine) updateTxn(ctx context.Context, fn func(t *txn.Txn) error) error {
+	t, err := e.newTxn(true)
+	if err != nil {
+		return err
+	}
+	if err = fn(t); err != nil {
+		t.Discard(ctx)
+		return err
+	}
+	if err = t.Commit(ctx); err == txn.ErrManagerClosed {
+		return ErrEngineClosed
+	}
+	return err
+}
@@ -0,0 +1,677 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package neo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/rbac"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/stretchr/testify/assert"
+)
+
+const basicModelPath = "../../examples/assets/model/basic_model.conf"
+
+func openTestEngine(t *testing.T, path string) *Engine {
+	e, err := Open(path, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, e.CreateModelFromFile("basic", basicModelPath))
+	return e
+}
+
+func TestEngine_Enforce(t *testing.T) {
+	p := "./__test_tmp__/enforce"
+	e := openTestEngine(t, p)
+	defer func() {
+		e.Close()
+		os.RemoveAll(p)
+	}()
+
+	added, err := e.AddPolicy("basic", "alice", "data1", "read")
+	assert.Nil(t, err)
+	assert.True(t, added)
+	added, err = e.AddPolicy("basic", "bob", "data2", "write")
+	assert.Nil(t, err)
+	assert.True(t, added)
+
+	// duplicated
+	added, err = e.AddPolicy("basic", "bob", "data2", "write")
+	assert.Nil(t, err)
+	assert.False(t, added)
+
+	sets := []struct {
+		req      []string
+		expected bool
+	}{
+		{[]string{"alice", "data1", "read"}, true},
+		{[]string{"alice", "data1", "write"}, false},
+		{[]string{"alice", "data2", "write"}, false},
+		{[]string{"bob", "data2", "write"}, true},
+		{[]string{"bob", "data1", "read"}, false},
+	}
+	for _, set := range sets {
+		allowed, err := e.Enforce("basic", set.req...)
+		assert.Nil(t, err)
+		assert.Equal(t, set.expected, allowed, set.req)
+	}
+
+	removed, err := e.RemovePolicy("basic", "bob", "data2", "write")
+	assert.Nil(t, err)
+	assert.True(t, removed)
+	removed, err = e.RemovePolicy("basic", "bob", "data2", "write")
+	assert.Nil(t, err)
+	assert.False(t, removed)
+
+	allowed, err := e.Enforce("basic", "bob", "data2", "write")
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+
+	_, err = e.Enforce("basic", "alice", "data1")
+	assert.Equal(t, ErrInvalidRequest, err)
+	_, err = e.Enforce("unknown", "alice", "data1", "read")
+	assert.NotNil(t, err)
+
+	// a request past its deadline
+	ctx, cancel := context.WithTimeout(context.TODO(), 0)
+	defer cancel()
+	<-ctx.Done()
+	allowed, err = e.EnforceContext(ctx, "basic", "alice", "data1", "read")
+	assert.False(t, allowed)
+	var canceled *ErrCanceled
+	assert.True(t, errors.As(err, &canceled))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	allowed, err = e.EnforceContext(context.TODO(), "basic", "alice", "data1", "read")
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+}
+
+// the rules are matched exactly, not by the values of their leading columns
+func TestEngine_ExactRule(t *testing.T) {
+	p := "./__test_tmp__/exact_rule"
+	e := openTestEngine(t, p)
+	defer func() {
+		e.Close()
+		os.RemoveAll(p)
+	}()
+
+	for _, rule := range [][]string{{"alice", "data1", "read"}, {"alice", "data2", "write"}} {
+		added, err := e.AddPolicy("basic", rule...)
+		assert.Nil(t, err)
+		assert.True(t, added)
+	}
+	_, err := e.AddPolicy("basic", "alice", "data1")
+	assert.Equal(t, ErrInvalidRequest, err)
+	_, err = e.RemovePolicy("basic", "alice")
+	assert.Equal(t, ErrInvalidRequest, err)
+	rules, err := e.GetNamedPolicy("basic", "p")
+	assert.Nil(t, err)
+	assert.Len(t, rules, 2)
+
+	removed, err := e.RemovePolicy("basic", "alice", "data1", "write")
+	assert.Nil(t, err)
+	assert.False(t, removed)
+	removed, err = e.RemovePolicy("basic", "alice", "data1", "read")
+	assert.Nil(t, err)
+	assert.True(t, removed)
+	rules, err = e.GetNamedPolicy("basic", "p")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"alice", "data2", "write"}}, rules)
+
+	// the omitted effect is allow, the default value of its column
+	assert.Nil(t, e.CreateModelFromString("eft", `
+[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act, eft
+
+[policy_effect]
+e = some(where (p.eft == allow)) && !some(where (p.eft == deny))
+
+[matchers]
+m = r.sub == p.sub && r.obj == p.obj && r.act == p.act
+`))
+	added, err := e.AddPolicy("eft", "alice", "data1", "read")
+	assert.Nil(t, err)
+	assert.True(t, added)
+	added, err = e.AddPolicy("eft", "alice", "data1", "read", "allow")
+	assert.Nil(t, err)
+	assert.False(t, added)
+	added, err = e.AddPolicy("eft", "alice", "data1", "read", "deny")
+	assert.Nil(t, err)
+	assert.True(t, added)
+	removed, err = e.RemovePolicy("eft", "alice", "data1", "read")
+	assert.Nil(t, err)
+	assert.True(t, removed)
+	rules, err = e.GetNamedPolicy("eft", "p")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"alice", "data1", "read", "deny"}}, rules)
+
+	// the rules are looked up by the primary index instead of a scan
+	assert.Nil(t, e.view(context.TODO(), func(sc session.Context) error {
+		dbInfo, tableInfo, err := lookupTable(sc, "eft", "p")
+		if err != nil {
+			return err
+		}
+		values, _ := ruleValues(tableInfo, []string{"alice", "data1", "read", "deny"})
+		lookup, rows := ruleLookup(dbInfo, tableInfo, values)
+		assert.Implements(t, (*plan.IndexScanPlan)(nil), lookup)
+		assert.IsType(t, &plan.TableRowIdScan{}, rows)
+		ids, err := execute(context.TODO(), sc, lookup)
+		assert.Len(t, ids, 1)
+		return err
+	}))
+}
+
+func TestEngine_Priority(t *testing.T) {
+	p := "./__test_tmp__/priority"
+	e := openTestEngine(t, p)
+	defer func() {
+		e.Close()
+		os.RemoveAll(p)
+	}()
+	assert.Nil(t, e.CreateModelFromString("priority", `
+[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = priority, sub, obj, act, eft
+
+[policy_effect]
+e = priority(p.eft) || deny
+
+[matchers]
+m = r.sub == p.sub && r.obj == p.obj && r.act == p.act
+`))
+
+	// the priorities are compared as numbers, 10 is after 9
+	for _, rule := range [][]string{{"10", "alice", "data1", "read", "allow"}, {"9", "alice", "data1", "read", "deny"}, {"-1", "bob", "data1", "read"}} {
+		added, err := e.AddPolicy("priority", rule...)
+		assert.Nil(t, err)
+		assert.True(t, added)
+	}
+	for _, rule := range [][]string{{"high", "alice", "data1", "read"}, {"", "alice", "data1", "read"}} {
+		_, err := e.AddPolicy("priority", rule...)
+		assert.Equal(t, ErrInvalidRequest, err)
+	}
+	allowed, err := e.Enforce("priority", "alice", "data1", "read")
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+	allowed, err = e.Enforce("priority", "bob", "data1", "read")
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+
+	rules, err := e.GetNamedPolicy("priority", "p")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"10", "alice", "data1", "read", "allow"}, {"9", "alice", "data1", "read", "deny"}, {"-1", "bob", "data1", "read", "allow"}}, rules)
+	values, err := e.GetAllNamedValues("priority", "p", "priority")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10", "9", "-1"}, values)
+
+	removed, err := e.RemovePolicy("priority", "9", "alice", "data1", "read", "deny")
+	assert.Nil(t, err)
+	assert.True(t, removed)
+	allowed, err = e.Enforce("priority", "alice", "data1", "read")
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+}
+
+func TestEngine_CreateModels(t *testing.T) {
+	p := "./__test_tmp__/create_models"
+	e := openTestEngine(t, p)
+	defer func() {
+		e.Close()
+		os.RemoveAll(p)
+	}()
+
+	assert.Nil(t, e.CreateModelFromFile("another", basicModelPath))
+	assert.NotNil(t, e.CreateModelFromFile("basic", basicModelPath))
+
+	_, err := e.AddPolicy("another", "alice", "data1", "read")
+	assert.Nil(t, err)
+	allowed, err := e.Enforce("another", "alice", "data1", "read")
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+	allowed, err = e.Enforce("basic", "alice", "data1", "read")
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+}
+
+func TestEngine_CreateExampleModels(t *testing.T) {
+	p := "./__test_tmp__/example_models"
+	e, err := Open(p, nil)
+	assert.Nil(t, err)
+	defer func() {
+		e.Close()
+		os.RemoveAll(p)
+	}()
+
+	files, err := filepath.Glob("../../examples/assets/model/*.conf")
+	assert.Nil(t, err)
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".conf")
+		assert.Nil(t, e.CreateModelFromFile(name, file), file)
+	}
+}
+
+func TestEngine_RBAC(t *testing.T) {
+	p := "./__test_tmp__/rbac"
+	e, err := Open(p, nil)
+	assert.Nil(t, err)
+	defer func() {
+		e.Close()
+		os.RemoveAll(p)
+	}()
+	assert.Nil(t, e.CreateModelFromFile("rbac", "../../examples/assets/model/rbac_model.conf"))
+
+	for _, rule := range [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}, {"data2_admin", "data2", "read"}, {"data2_admin", "data2", "write"}} {
+		_, err = e.AddPolicy("rbac", rule...)
+		assert.Nil(t, err)
+	}
+	added, err := e.AddGroupingPolicy("rbac", "alice", "data2_admin")
+	assert.Nil(t, err)
+	assert.True(t, added)
+
+	sets := []struct {
+		req      []string
+		expected bool
+	}{
+		{[]string{"alice", "data1", "read"}, true},
+		{[]string{"alice", "data2", "read"}, true},
+		{[]string{"alice", "data2", "write"}, true},
+		{[]string{"bob", "data2", "read"}, false},
+		{[]string{"bob", "data2", "write"}, true},
+	}
+	for _, set := range sets {
+		allowed, err := e.Enforce("rbac", set.req...)
+		assert.Nil(t, err)
+		assert.Equal(t, set.expected, allowed, set.req)
+	}
+	has, err := e.HasRoleForUser("rbac", "alice", "data2_admin")
+	assert.Nil(t, err)
+	assert.True(t, has)
+
+	// rebuilds the role graph from the committed rules
+	e.rolesMu.Lock()
+	e.roles = map[string]map[string]*rbac.RoleManager{}
+	e.rolesMu.Unlock()
+	assert.Nil(t, e.BuildRoleLinks("rbac"))
+	allowed, err := e.Enforce("rbac", "alice", "data2", "write")
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+
+	// a partial rule removes nothing
+	_, err = e.RemoveGroupingPolicy("rbac", "alice")
+	assert.Equal(t, ErrInvalidRequest, err)
+	has, err = e.HasRoleForUser("rbac", "alice", "data2_admin")
+	assert.Nil(t, err)
+	assert.True(t, has)
+
+	removed, err := e.RemoveGroupingPolicy("rbac", "alice", "data2_admin")
+	assert.Nil(t, err)
+	assert.True(t, removed)
+	allowed, err = e.Enforce("rbac", "alice", "data2", "write")
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+	has, err = e.HasRoleForUser("rbac", "alice", "data2_admin")
+	assert.Nil(t, err)
+	assert.False(t, has)
+
+	_, err = e.AddNamedGroupingPolicy("rbac", "p", "alice", "data2_admin")
+	assert.Equal(t, ErrInvalidRequest, err)
+}
+
+// the role graph follows the committed role rules while they are changed concurrently
+func TestEngine_ConcurrentRoleRules(t *testing.T) {
+	p := "./__test_tmp__/concurrent_roles"
+	e, err := Open(p, nil)
+	assert.Nil(t, err)
+	defer func() {
+		e.Close()
+		os.RemoveAll(p)
+	}()
+	assert.Nil(t, e.CreateModelFromFile("rbac", "../../examples/assets/model/rbac_model.conf"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				// the conflicted changes fail, the committed ones must be in the graph
+				user := fmt.Sprintf("user%d", (i+j)%3)
+				if (i+j)%2 == 0 {
+					e.AddGroupingPolicy("rbac", user, "admin")
+				} else {
+					e.RemoveGroupingPolicy("rbac", user, "admin")
+				}
+				if j%10 == 0 {
+					assert.Nil(t, e.BuildRoleLinks("rbac"))
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	rules, err := e.GetNamedPolicy("rbac", "g")
+	assert.Nil(t, err)
+	committed := make(map[string]bool)
+	for _, rule := range rules {
+		committed[rule[0]] = true
+	}
+	for i := 0; i < 3; i++ {
+		user := fmt.Sprintf("user%d", i)
+		has, err := e.HasRoleForUser("rbac", user, "admin")
+		assert.Nil(t, err)
+		assert.Equal(t, committed[user], has, user)
+	}
+}
+
+func TestEngine_Reopen(t *testing.T) {
+	p := "./__test_tmp__/reopen"
+	defer os.RemoveAll(p)
+	e, err := Open(p, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, e.CreateModelFromFile("basic", basicModelPath))
+	assert.Nil(t, e.CreateModelFromFile("rbac", "../../examples/assets/model/rbac_model.conf"))
+	_, err = e.AddPolicy("basic", "alice", "data1", "read")
+	assert.Nil(t, err)
+	_, err = e.AddPolicy("rbac", "data2_admin", "data2", "write")
+	assert.Nil(t, err)
+	_, err = e.AddGroupingPolicy("rbac", "alice", "data2_admin")
+	assert.Nil(t, err)
+	assert.Nil(t, e.Close())
+
+	e, err = Open(p, nil)
+	assert.Nil(t, err)
+	defer e.Close()
+
+	// the models, the rules and the role graphs are recovered
+	allowed, err := e.Enforce("basic", "alice", "data1", "read")
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+	allowed, err = e.Enforce("rbac", "alice", "data2", "write")
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+	_, err = e.Enforce("basic", "alice", "data1")
+	assert.Equal(t, ErrInvalidRequest, err)
+
+	// the recovered names and ids are never reused
+	err = e.CreateModelFromFile("basic", basicModelPath)
+	assert.NotNil(t, err)
+	assert.Nil(t, e.CreateModelFromFile("basic2", basicModelPath))
+	var dbIds, tableIds []uint64
+	assert.Nil(t, e.view(context.TODO(), func(sc session.Context) error {
+		for _, name := range []string{"basic", "rbac", "basic2"} {
+			info, err := sc.GetCatalog().GetDBInfoByName(name)
+			if err != nil {
+				return err
+			}
+			dbIds = append(dbIds, info.ID)
+			for _, table := range info.TableInfo {
+				tableIds = append(tableIds, table.ID)
+			}
+		}
+		return nil
+	}))
+	assert.Equal(t, []uint64{1, 2, 3}, dbIds)
+	assert.Equal(t, []uint64{1, 2, 3, 4}, tableIds)
+	added, err := e.AddPolicy("basic2", "alice", "data1", "read")
+	assert.Nil(t, err)
+	assert.True(t, added)
+	rules, err := e.GetNamedPolicy("basic", "p")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"alice", "data1", "read"}}, rules)
+}
+
+func TestEngine_RBACWithDomains(t *testing.T) {
+	p := "./__test_tmp__/rbac_with_domains"
+	e, err := Open(p, nil)
+	assert.Nil(t, err)
+	defer func() {
+		e.Close()
+		os.RemoveAll(p)
+	}()
+	assert.Nil(t, e.CreateModelFromFile("domains", "../../examples/assets/model/rbac_with_domains_model.conf"))
+
+	for _, rule := range [][]string{
+		{"admin", "domain1", "data1", "read"},
+		{"admin", "domain1", "data1", "write"},
+		{"admin", "domain2", "data2", "read"},
+		{"admin", "domain2", "data2", "write"},
+	} {
+		_, err = e.AddPolicy("domains", rule...)
+		assert.Nil(t, err)
+	}
+	_, err = e.AddGroupingPolicy("domains", "alice", "admin", "domain1")
+	assert.Nil(t, err)
+	_, err = e.AddGroupingPolicy("domains", "bob", "admin", "domain2")
+	assert.Nil(t, err)
+
+	sets := []struct {
+		req      []string
+		expected bool
+	}{
+		{[]string{"alice", "domain1", "data1", "read"}, true},
+		{[]string{"alice", "domain1", "data1", "write"}, true},
+		{[]string{"alice", "domain1", "data2", "read"}, false},
+		{[]string{"alice", "domain2", "data2", "read"}, false},
+		{[]string{"bob", "domain1", "data1", "read"}, false},
+		{[]string{"bob", "domain2", "data2", "write"}, true},
+	}
+	for _, set := range sets {
+		allowed, err := e.Enforce("domains", set.req...)
+		assert.Nil(t, err)
+		assert.Equal(t, set.expected, allowed, set.req)
+	}
+}
+
+func TestEngine_Serializable(t *testing.T) {
+	p := "./__test_tmp__/serializable"
+	e, err := Open(p, &Options{Serializable: true})
+	assert.Nil(t, err)
+	defer func() {
+		e.Close()
+		os.RemoveAll(p)
+	}()
+	assert.Nil(t, e.CreateModelFromFile("rbac", "../../examples/assets/model/rbac_model.conf"))
+	_, err = e.AddGroupingPolicy("rbac", "alice", "admin")
+	assert.Nil(t, err)
+	_, err = e.AddGroupingPolicy("rbac", "bob", "admin")
+	assert.Nil(t, err)
+
+	// both transactions see two admins, and remove themselves
+	ctx := context.TODO()
+	var read sync.WaitGroup
+	read.Add(2)
+	proceed := make(chan struct{})
+	removeUnlessLastAdmin := func(user string) error {
+		return e.update(ctx, func(sc session.Context) error {
+			dbInfo, tableInfo, err := lookupTable(sc, "rbac", "g")
+			if err != nil {
+				return err
+			}
+			rules, err := executeTuples(ctx, sc, plan.NewSeqScanPlan(tableInfo, nil, nil, dbInfo.ID, tableInfo.ID))
+			read.Done()
+			<-proceed
+			if err != nil || len(rules) < 2 {
+				return err
+			}
+			values, _ := ruleValues(tableInfo, []string{user, "admin"})
+			predicate, evalCtx := newRulePredicate(tableInfo, values)
+			scan := plan.NewSeqScanPlan(tableInfo, predicate, evalCtx, dbInfo.ID, tableInfo.ID)
+			_, err = execute(ctx, sc, plan.NewDeletePlan([]plan.AbstractPlan{scan}, tableInfo.ID, dbInfo.ID))
+			return err
+		})
+	}
+	errs := make(chan error, 2)
+	for _, user := range []string{"alice", "bob"} {
+		go func(user string) {
+			errs <- removeUnlessLastAdmin(user)
+		}(user)
+	}
+	read.Wait()
+	close(proceed)
+	err1, err2 := <-errs, <-errs
+	assert.True(t, (err1 == nil) != (err2 == nil), "%v, %v", err1, err2)
+	for _, err = range []error{err1, err2} {
+		if err != nil {
+			assert.Equal(t, ErrSerializationFailure, err)
+		}
+	}
+
+	rules, err := e.GetNamedPolicy("rbac", "g")
+	assert.Nil(t, err)
+	assert.Len(t, rules, 1)
+}
+
+func TestEngine_GetAllNamedValues(t *testing.T) {
+	p := "./__test_tmp__/named_values"
+	e, err := Open(p, nil)
+	assert.Nil(t, err)
+	defer func() {
+		e.Close()
+		os.RemoveAll(p)
+	}()
+	assert.Nil(t, e.CreateModelFromFile("domains", "../../examples/assets/model/rbac_with_domains_model.conf"))
+
+	count, err := e.CountNamedPolicy("domains", "p")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+	values, err := e.GetAllNamedValues("domains", "p", "sub")
+	assert.Nil(t, err)
+	assert.Empty(t, values)
+
+	assert.Nil(t, e.ReplacePolicy("domains", []Rule{
+		{PType: "p", Values: []string{"admin", "domain1", "data1", "read"}},
+		{PType: "p", Values: []string{"admin", "domain1", "data1", "write"}},
+		{PType: "p", Values: []string{"user", "domain2", "data2", "read"}},
+		{PType: "p", Values: []string{"admin", "domain2", "data2", "write"}},
+		{PType: "g", Values: []string{"alice", "admin", "domain1"}},
+	}))
+	count, err = e.CountNamedPolicy("domains", "p")
+	assert.Nil(t, err)
+	assert.Equal(t, 4, count)
+	count, err = e.CountNamedPolicy("domains", "g")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+
+	values, err = e.GetAllNamedValues("domains", "p", "sub")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"admin", "user"}, values)
+	values, err = e.GetAllNamedValues("domains", "p", "obj")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"data1", "data2"}, values)
+	values, err = e.GetAllNamedValues("domains", "p", "act")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"read", "write"}, values)
+
+	_, err = e.GetAllNamedValues("domains", "p", "unknown")
+	assert.True(t, errors.Is(err, plan.ErrUnknownColumn))
+}
+
+func TestEngine_Wildcard(t *testing.T) {
+	p := "./__test_tmp__/wildcard"
+	e, err := Open(p, nil)
+	assert.Nil(t, err)
+	defer func() {
+		e.Close()
+		os.RemoveAll(p)
+	}()
+	assert.Nil(t, e.CreateModelFromString("wildcard", `
+[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = (r.sub == p.sub || p.sub == "*") && r.obj == p.obj && r.act == p.act
+`))
+
+	// enough rules for the index union scan
+	rules := []Rule{{PType: "p", Values: []string{"*", "data0", "write"}}}
+	for i := 0; i < 1000; i++ {
+		rules = append(rules, Rule{PType: "p", Values: []string{fmt.Sprintf("user%d", i), fmt.Sprintf("data%d", i%2), "read"}})
+	}
+	assert.Nil(t, e.ReplacePolicy("wildcard", rules))
+
+	sets := []struct {
+		req      []string
+		expected bool
+	}{
+		{[]string{"user5", "data1", "read"}, true},
+		{[]string{"user5", "data0", "read"}, false},
+		{[]string{"user5", "data0", "write"}, true},
+		{[]string{"nobody", "data0", "write"}, true},
+		{[]string{"nobody", "data1", "write"}, false},
+		{[]string{"nobody", "data1", "read"}, false},
+	}
+	for _, set := range sets {
+		allowed, err := e.Enforce("wildcard", set.req...)
+		assert.Nil(t, err)
+		assert.Equal(t, set.expected, allowed, set.req)
+	}
+}
+
+func TestEngine_Explain(t *testing.T) {
+	p := "./__test_tmp__/explain"
+	e := openTestEngine(t, p)
+	defer func() {
+		e.Close()
+		os.RemoveAll(p)
+	}()
+
+	for i := 0; i < 10; i++ {
+		_, err := e.AddPolicy("basic", fmt.Sprintf("user%d", i), fmt.Sprintf("data%d", i), "read")
+		assert.Nil(t, err)
+	}
+
+	node, err := e.Explain("basic", "user1", "data1", "read")
+	assert.Nil(t, err)
+	// the request is covered by the primary index
+	expected := "Enforce model=basic\n" +
+		"-> TableRowIdScan table=p\n" +
+		"  -> IndexScan table=p index=primary prefix=(\"user1\",\"data1\",\"read\")\n"
+	assert.Equal(t, expected, node.String())
+	assert.Nil(t, node.Stats)
+
+	node, allowed, err := e.ExplainAnalyze("basic", "user1", "data1", "read")
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 1, node.Stats.Rows)
+	assert.Equal(t, 1, node.Children[0].Children[0].Stats.Rows)
+
+	node, allowed, err = e.ExplainAnalyze("basic", "user1", "data2", "read")
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 0, node.Children[0].Children[0].Stats.Rows)
+
+	_, err = e.Explain("basic", "user1", "data1")
+	assert.Equal(t, ErrInvalidRequest, err)
+	_, _, err = e.ExplainAnalyze("unknown", "user1", "data1", "read")
+	assert.NotNil(t, err)
+}
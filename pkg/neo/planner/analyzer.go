@@ -0,0 +1,296 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"github.com/casbin-mesh/neo/pkg/expression"
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/expression/exprutil"
+	"github.com/casbin-mesh/neo/pkg/expression/iterator"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
+)
+
+// Sarg is a sargable conjunct of a matcher, an equality between a policy
+// column and a request field or a literal, e.g. r.sub == p.sub or p.obj == "data1",
+// which can be answered by an index whose leftmost column is the policy column.
+type Sarg struct {
+	// Offset is the policy column.
+	Offset int
+	// Value is the request field or the literal the column equals to.
+	Value value.Value
+	// Node is the conjunct, pruned from the residual predicate.
+	Node ast.Evaluable
+}
+
+// Prefix returns the key prefix of the index entries satisfying the sargs,
+// which are on the leading columns of the index in order.
+func Prefix(index *model.IndexInfo, sargs ...Sarg) []byte {
+	values := make([][]byte, 0, len(sargs))
+	for _, sarg := range sargs {
+		values = append(values, codec.EncodeCmpValue(sarg.Value))
+	}
+	return codec.IndexEntryPrefix(index.ID, values)
+}
+
+// Disjunction is a conjunct of a matcher, an OR of sargs, e.g. r.sub == p.sub || p.sub == "*",
+// which can be answered by the union of the index scans of its sargs.
+type Disjunction struct {
+	Sargs []Sarg
+	// Node is the conjunct, which is kept in the residual predicate.
+	Node ast.Evaluable
+}
+
+// Range is a conjunct of a matcher, a comparison between a policy column and a literal of the type
+// of the column, e.g. p.priority < 10, which can be answered by an index range scan of an index
+// whose leftmost column is the policy column.
+type Range struct {
+	// Offset is the policy column.
+	Offset int
+	// Op is the comparison with the column on its left side, e.g. > for 10 < p.priority.
+	Op ast.Op
+	// Value is the literal the column is compared to.
+	Value value.Value
+	// Node is the conjunct, which is kept in the residual predicate.
+	Node ast.Evaluable
+}
+
+// Analysis is a matcher split into its sargable conjuncts and the rest of it.
+type Analysis struct {
+	Sargs []Sarg
+	// Disjunctions are the conjuncts of the residual predicate whose disjuncts are all sargable.
+	Disjunctions []Disjunction
+	// Ranges are the conjuncts of the residual predicate comparing a policy column to a literal.
+	Ranges []Range
+	// Residual is the predicate remains after the sargable conjuncts are pruned,
+	// it's nil if all the conjuncts are sargable.
+	Residual ast.Evaluable
+}
+
+// AnalyzeMatcher finds the sargable conjuncts of the matcher, and binds them to the request.
+// The predicate of the matcher is left untouched, the analysis works on its copy.
+func AnalyzeMatcher(table *model.TableInfo, matcher *model.MatcherInfo, request btuple.Reader) *Analysis {
+	a := &Analysis{}
+	if matcher.Predicate == nil {
+		return a
+	}
+	root := matcher.Predicate.Clone()
+
+	// only the conjuncts reachable from the root through AND nodes are sargable
+	var conjuncts []ast.Evaluable
+	if isAnd(root) {
+		// a post-order traversal keeps the order of the conjuncts of a left-deep tree
+		iter := iterator.NewPostOrderIterator(&root, &iterator.Option{Filter: isAnd})
+		for node := iter.Next(); node != nil; node = iter.Next() {
+			for i := 0; i < node.ChildrenLen(); i++ {
+				if child := node.GetChildAt(i); !isAnd(child) {
+					conjuncts = append(conjuncts, child)
+				}
+			}
+		}
+	} else {
+		conjuncts = append(conjuncts, root)
+	}
+
+	a.Residual = root
+	for _, conjunct := range conjuncts {
+		sarg, ok := bindSarg(table, matcher, request, conjunct)
+		if !ok {
+			if disjunction, ok := bindDisjunction(table, matcher, request, conjunct); ok {
+				a.Disjunctions = append(a.Disjunctions, disjunction)
+			} else if r, ok := bindRange(table, matcher, conjunct); ok {
+				a.Ranges = append(a.Ranges, r)
+			}
+			continue
+		}
+		a.Sargs = append(a.Sargs, sarg)
+		_, a.Residual = expression.PruneSubtree(a.Residual, func(node ast.Evaluable) bool {
+			return node == conjunct
+		})
+	}
+	return a
+}
+
+// Filter returns the predicate a plan must evaluate, when it has answered the sargs of
+// the given positions: the residual predicate and the sargs the plan didn't use.
+func (a *Analysis) Filter(used ...int) ast.Evaluable {
+	predicate := a.Residual
+	for i, sarg := range a.Sargs {
+		if containsInt(used, i) {
+			continue
+		}
+		if predicate == nil {
+			predicate = sarg.Node
+		} else {
+			predicate = expression.ConnectSubtree(predicate, sarg.Node)
+		}
+	}
+	return predicate
+}
+
+// sargOf returns the position of the first sarg on the column, or -1 if there is no such one.
+func (a *Analysis) sargOf(offset int) int {
+	for i, sarg := range a.Sargs {
+		if sarg.Offset == offset {
+			return i
+		}
+	}
+	return -1
+}
+
+func isAnd(node ast.Evaluable) bool {
+	expr, ok := node.(*ast.BinaryOperationExpr)
+	return ok && expr.Op == ast.AND_OP
+}
+
+func isOr(node ast.Evaluable) bool {
+	expr, ok := node.(*ast.BinaryOperationExpr)
+	return ok && expr.Op == ast.OR_OP
+}
+
+// bindDisjunction binds the conjunct if it's an OR of the sargs, the disjuncts are reachable through OR nodes.
+func bindDisjunction(table *model.TableInfo, matcher *model.MatcherInfo, request btuple.Reader, conjunct ast.Evaluable) (Disjunction, bool) {
+	if !isOr(conjunct) {
+		return Disjunction{}, false
+	}
+	d := Disjunction{Node: conjunct}
+	var bind func(node ast.Evaluable) bool
+	bind = func(node ast.Evaluable) bool {
+		if isOr(node) {
+			for i := 0; i < node.ChildrenLen(); i++ {
+				if !bind(node.GetChildAt(i)) {
+					return false
+				}
+			}
+			return true
+		}
+		sarg, ok := bindSarg(table, matcher, request, node)
+		if ok {
+			d.Sargs = append(d.Sargs, sarg)
+		}
+		return ok
+	}
+	if !bind(conjunct) {
+		return Disjunction{}, false
+	}
+	return d, true
+}
+
+func containsInt(s []int, v int) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+// bindSarg binds the conjunct if it's an equality between a policy column, and a request field or a string literal.
+func bindSarg(table *model.TableInfo, matcher *model.MatcherInfo, request btuple.Reader, conjunct ast.Evaluable) (Sarg, bool) {
+	expr, ok := conjunct.(*ast.BinaryOperationExpr)
+	if !ok || expr.Op != ast.EQ_OP {
+		return Sarg{}, false
+	}
+	if sarg, ok := bindEquality(table, matcher, request, expr.L, expr.R); ok {
+		sarg.Node = conjunct
+		return sarg, true
+	}
+	if sarg, ok := bindEquality(table, matcher, request, expr.R, expr.L); ok {
+		sarg.Node = conjunct
+		return sarg, true
+	}
+	return Sarg{}, false
+}
+
+// bindEquality binds column == value, where column is a policy column and value is a request field or a string literal.
+func bindEquality(table *model.TableInfo, matcher *model.MatcherInfo, request btuple.Reader, column, v ast.Evaluable) (Sarg, bool) {
+	ancestor, member, ok := exprutil.MemberOf(column)
+	if !ok || ancestor != matcher.Policy.L {
+		return Sarg{}, false
+	}
+	offset := table.Field(member)
+	if offset < 0 || table.Columns[offset].Tp != bsontype.String {
+		return Sarg{}, false
+	}
+
+	if literal, ok := v.(*ast.Primitive); ok && literal.Typ == ast.STRING {
+		if s, ok := literal.Value.(string); ok {
+			return Sarg{Offset: offset, Value: value.NewStringValue(s)}, true
+		}
+		return Sarg{}, false
+	}
+	ancestor, member, ok = exprutil.MemberOf(v)
+	if !ok || ancestor != matcher.Request.L {
+		return Sarg{}, false
+	}
+	for i, field := range matcher.RequestFields {
+		if field.L == member && i < len(request.Values()) {
+			return Sarg{Offset: offset, Value: codec.DecodeValue(request.ValueAt(i), bsontype.String)}, true
+		}
+	}
+	return Sarg{}, false
+}
+
+// flippedOps are the comparisons with their sides swapped, e.g. 10 < p.priority is p.priority > 10.
+var flippedOps = map[ast.Op]ast.Op{ast.LT: ast.GT, ast.LE: ast.GE, ast.GT: ast.LT, ast.GE: ast.LE}
+
+// bindRange binds the conjunct if it's a comparison between a policy column and a literal of its type,
+// an integer for an integer column, or a string for a string column.
+func bindRange(table *model.TableInfo, matcher *model.MatcherInfo, conjunct ast.Evaluable) (Range, bool) {
+	expr, ok := conjunct.(*ast.BinaryOperationExpr)
+	if !ok {
+		return Range{}, false
+	}
+	flipped, ok := flippedOps[expr.Op]
+	if !ok {
+		return Range{}, false
+	}
+	if r, ok := bindComparison(table, matcher, expr.L, expr.R); ok {
+		r.Op, r.Node = expr.Op, conjunct
+		return r, true
+	}
+	if r, ok := bindComparison(table, matcher, expr.R, expr.L); ok {
+		r.Op, r.Node = flipped, conjunct
+		return r, true
+	}
+	return Range{}, false
+}
+
+// bindComparison binds column and v of a comparison, where column is a policy column and v is a literal of its type.
+func bindComparison(table *model.TableInfo, matcher *model.MatcherInfo, column, v ast.Evaluable) (Range, bool) {
+	ancestor, member, ok := exprutil.MemberOf(column)
+	if !ok || ancestor != matcher.Policy.L {
+		return Range{}, false
+	}
+	offset := table.Field(member)
+	literal, ok := v.(*ast.Primitive)
+	if offset < 0 || !ok {
+		return Range{}, false
+	}
+	switch table.Columns[offset].Tp {
+	case bsontype.Int64:
+		if i, ok := literal.Value.(int); ok && literal.Typ == ast.INT {
+			return Range{Offset: offset, Value: value.NewInt64Value(int64(i))}, true
+		}
+	case bsontype.String:
+		if s, ok := literal.Value.(string); ok && literal.Typ == ast.STRING {
+			return Range{Offset: offset, Value: value.NewStringValue(s)}, true
+		}
+	}
+	return Range{}, false
+}
@@ -0,0 +1,170 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"testing"
+
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/utils"
+	"github.com/casbin-mesh/neo/pkg/parser"
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeMatcher(t *testing.T) {
+	info, err := utils.CompileModelFromString("basic", basicModel)
+	assert.Nil(t, err)
+	table, matcher := info.TableInfo[0], *info.MatcherInfo[0]
+	request := newRequest("alice", "data1", "read")
+
+	type sarg struct {
+		offset int
+		value  string
+	}
+	tests := []struct {
+		predicate    string
+		sargs        []sarg
+		disjunctions [][]sarg
+		residual     string
+	}{
+		{
+			predicate: `r.sub == p.sub && r.obj == p.obj && r.act == p.act`,
+			sargs:     []sarg{{0, "alice"}, {1, "data1"}, {2, "read"}},
+		},
+		{
+			predicate: `p.sub == r.sub && p.obj == "data2" && keyMatch(r.act, p.act)`,
+			sargs:     []sarg{{0, "alice"}, {1, "data2"}},
+			residual:  `keyMatch(r.act, p.act)`,
+		},
+		{
+			// the equalities under an OR are not sargable, but they are a disjunction
+			predicate:    `r.sub == p.sub && (r.obj == p.obj || r.act == p.act)`,
+			sargs:        []sarg{{0, "alice"}},
+			disjunctions: [][]sarg{{{1, "data1"}, {2, "read"}}},
+			residual:     `r.obj == p.obj || r.act == p.act`,
+		},
+		{
+			predicate:    `r.sub == p.sub || r.obj == p.obj`,
+			disjunctions: [][]sarg{{{0, "alice"}, {1, "data1"}}},
+			residual:     `r.sub == p.sub || r.obj == p.obj`,
+		},
+		{
+			predicate:    `(r.sub == p.sub || p.sub == "*" || p.sub == "admin") && r.obj == p.obj`,
+			sargs:        []sarg{{1, "data1"}},
+			disjunctions: [][]sarg{{{0, "alice"}, {0, "*"}, {0, "admin"}}},
+			residual:     `r.sub == p.sub || p.sub == "*" || p.sub == "admin"`,
+		},
+		{
+			// all the disjuncts must be sargable
+			predicate: `r.sub == p.sub || keyMatch(r.obj, p.obj)`,
+			residual:  `r.sub == p.sub || keyMatch(r.obj, p.obj)`,
+		},
+		{
+			predicate: `r.obj == p.obj`,
+			sargs:     []sarg{{1, "data1"}},
+		},
+		{
+			// neither the unknown columns nor the equalities between the request fields are sargable
+			predicate: `p.dom == r.sub && r.sub == r.obj && p.act == "read"`,
+			sargs:     []sarg{{2, "read"}},
+			residual:  `p.dom == r.sub && r.sub == r.obj`,
+		},
+	}
+	for _, test := range tests {
+		matcher.Predicate = parser.MustParseFromString(test.predicate)
+		a := AnalyzeMatcher(table, &matcher, request)
+		var sargs []sarg
+		for _, s := range a.Sargs {
+			sargs = append(sargs, sarg{s.Offset, s.Value.GetString()})
+		}
+		assert.Equal(t, test.sargs, sargs, test.predicate)
+		var disjunctions [][]sarg
+		for _, d := range a.Disjunctions {
+			var sargs []sarg
+			for _, s := range d.Sargs {
+				sargs = append(sargs, sarg{s.Offset, s.Value.GetString()})
+			}
+			disjunctions = append(disjunctions, sargs)
+		}
+		assert.Equal(t, test.disjunctions, disjunctions, test.predicate)
+		if test.residual == "" {
+			assert.Nil(t, a.Residual, test.predicate)
+		} else {
+			assert.Equal(t, parser.MustParseFromString(test.residual).String(), a.Residual.String(), test.predicate)
+		}
+		// the matcher is left untouched
+		assert.Equal(t, parser.MustParseFromString(test.predicate).String(), matcher.Predicate.String())
+	}
+}
+
+func TestAnalysis_Filter(t *testing.T) {
+	info, err := utils.CompileModelFromString("basic", basicModel)
+	assert.Nil(t, err)
+	table, matcher := info.TableInfo[0], *info.MatcherInfo[0]
+	matcher.Predicate = parser.MustParseFromString(`r.sub == p.sub && r.obj == p.obj && keyMatch(r.act, p.act)`)
+	a := AnalyzeMatcher(table, &matcher, newRequest("alice", "data1", "read"))
+
+	assert.Equal(t, `keyMatch(r.act, p.act) && r.sub == p.sub && r.obj == p.obj`, a.Filter().String())
+	assert.Equal(t, `keyMatch(r.act, p.act) && r.obj == p.obj`, a.Filter(0).String())
+	assert.Equal(t, `keyMatch(r.act, p.act)`, a.Filter(0, 1).String())
+
+	index := table.Indices[0]
+	assert.Equal(t, codec.IndexEntryPrefix(index.ID, [][]byte{codec.EncodeCmpValue(value.NewStringValue("alice"))}), Prefix(index, a.Sargs[0]))
+}
+
+func TestAnalyzeMatcher_Ranges(t *testing.T) {
+	info, err := utils.CompileModelFromString("priority", priorityModel)
+	assert.Nil(t, err)
+	table, matcher := info.TableInfo[0], *info.MatcherInfo[0]
+	request := newRequest("alice", "data1", "read")
+
+	type bound struct {
+		offset int
+		op     ast.Op
+		value  interface{}
+	}
+	tests := []struct {
+		predicate string
+		ranges    []bound
+	}{
+		{`r.sub == p.sub && p.priority < 10`, []bound{{0, ast.LT, int64(10)}}},
+		// the column is on the left of the bound
+		{`10 <= p.priority && p.priority > 2`, []bound{{0, ast.GE, int64(10)}, {0, ast.GT, int64(2)}}},
+		{`p.sub >= "bob" && r.obj == p.obj`, []bound{{1, ast.GE, "bob"}}},
+		// the literals of the other types, the request fields, and the comparisons under an OR are not ranges
+		{`p.priority < "10" && p.sub < 10 && p.sub < r.sub`, nil},
+		{`p.priority < 10 || r.sub == p.sub`, nil},
+	}
+	for _, test := range tests {
+		matcher.Predicate = parser.MustParseFromString(test.predicate)
+		a := AnalyzeMatcher(table, &matcher, request)
+		var ranges []bound
+		for _, r := range a.Ranges {
+			b := bound{offset: r.Offset, op: r.Op, value: r.Value.GetString()}
+			if r.Value.Type() == bsontype.Int64 {
+				b.value = r.Value.GetInt64()
+			}
+			ranges = append(ranges, b)
+		}
+		assert.Equal(t, test.ranges, ranges, test.predicate)
+		// the ranges are kept in the residual predicate
+		for _, r := range a.Ranges {
+			assert.Contains(t, a.Filter().String(), r.Node.String(), test.predicate)
+		}
+	}
+}
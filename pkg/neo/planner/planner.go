@@ -0,0 +1,327 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"bytes"
+	"context"
+	"sync"
+
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+)
+
+// The costs are relative to reading a row by a sequential scan.
+const (
+	// seqRowCost is the cost to read a row of a sequential scan.
+	seqRowCost = 1.0
+	// indexRowCost is the cost to read an index entry.
+	indexRowCost = 1.0
+	// lookupRowCost is the cost to fetch a row by its id, which seeks the table.
+	lookupRowCost = 3.0
+	// hashRowCost is the cost to insert or probe a row id of the multi-index scan row id set.
+	hashRowCost = 0.5
+)
+
+// parallelProbeRows is the estimated rows of the probe side, from which a multi-index scan
+// runs its children concurrently and probes by a pool of workers.
+const parallelProbeRows = 10000
+
+// Planner chooses the cheapest access path of a policy table for a request,
+// it keeps the statistics of the tables it planned for.
+type Planner struct {
+	mu     sync.Mutex
+	tables map[uint64]*TableStats
+}
+
+func New() *Planner {
+	return &Planner{tables: make(map[uint64]*TableStats)}
+}
+
+// Stats returns the statistics of the table, or nil if it has not been analyzed.
+func (p *Planner) Stats(tableId uint64) *TableStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.tables[tableId]
+}
+
+// Modify records that rows of the table are inserted or deleted, the table
+// is analyzed again by the next planning once enough rows are modified.
+func (p *Planner) Modify(tableId uint64, rows int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if s, ok := p.tables[tableId]; ok {
+		s.modified += uint64(rows)
+	}
+}
+
+// Invalidate drops the statistics of the table.
+func (p *Planner) Invalidate(tableId uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.tables, tableId)
+}
+
+// Analyze rebuilds the statistics of the table from the snapshot of the session.
+func (p *Planner) Analyze(ctx context.Context, sc session.Context, dbId uint64, table *model.TableInfo) (*TableStats, error) {
+	s, err := analyze(ctx, sc, dbId, table)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.tables[table.ID] = s
+	p.mu.Unlock()
+	return s, nil
+}
+
+// tableStats returns the statistics of the table, analyzes it if they are missing or stale.
+func (p *Planner) tableStats(ctx context.Context, sc session.Context, dbId uint64, table *model.TableInfo) (*TableStats, error) {
+	p.mu.Lock()
+	s, ok := p.tables[table.ID]
+	// the modified rows are counted under the lock
+	fresh := ok && !s.stale()
+	p.mu.Unlock()
+	if fresh {
+		return s, nil
+	}
+	return p.Analyze(ctx, sc, dbId, table)
+}
+
+// candidate is an index usable by the sargs on its leading columns.
+type candidate struct {
+	index int
+	sargs []int
+	rows  float64
+}
+
+// PlanAccess returns the cheapest plan yields the rows of the policy table
+// may match the request, among a sequential scan, an index scan on the sargs
+// of the matcher, a multi-index scan intersecting two of them, an index
+// union scan on the sargs of a disjunction, and an index range scan on the
+// ranges of a column, and the predicate the rows are left to be evaluated by.
+// The rows are yielded in the order of their ids, as a sequential scan does,
+// the index access paths are wrapped by a TableRowIdScan sorting the row ids.
+func (p *Planner) PlanAccess(ctx context.Context, sc session.Context, dbId uint64, table *model.TableInfo, matcher *model.MatcherInfo, request btuple.Reader) (plan.AbstractPlan, ast.Evaluable, error) {
+	seqScan := plan.NewSeqScanPlan(table, nil, nil, dbId, table.ID)
+	a := AnalyzeMatcher(table, matcher, request)
+	candidates := indexCandidates(table, a)
+	if len(candidates) == 0 && len(a.Disjunctions) == 0 && len(a.Ranges) == 0 {
+		return seqScan, a.Filter(), nil
+	}
+	s, err := p.tableStats(ctx, sc, dbId, table)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows := float64(s.RowCount)
+	for i := range candidates {
+		// the sargs are assumed independent
+		candidates[i].rows = rows
+		for _, j := range candidates[i].sargs {
+			if rows > 0 {
+				candidates[i].rows *= s.Estimate(a.Sargs[j].Offset, a.Sargs[j].Value.GetBytes()) / rows
+			}
+		}
+		// a unique index looks up at most one row by all its columns
+		if index := table.Indices[candidates[i].index]; index.Unique && len(candidates[i].sargs) == len(index.Columns) && candidates[i].rows > 1 {
+			candidates[i].rows = 1
+		}
+	}
+
+	best, bestCost, filter := plan.AbstractPlan(seqScan), rows*seqRowCost, a.Filter()
+	for _, c := range candidates {
+		cost := c.rows * (indexRowCost + lookupRowCost)
+		if cost < bestCost {
+			best, bestCost = plan.NewTableRowIdScan(table, dbId, table.ID, indexScan(table, dbId, a, c)), cost
+			filter = a.Filter(c.sargs...)
+		}
+	}
+	for i := range candidates {
+		for j := i + 1; j < len(candidates); j++ {
+			// the smaller side builds the row id set
+			build, probe := candidates[i], candidates[j]
+			if overlaps(build.sargs, probe.sargs) {
+				continue
+			}
+			if probe.rows < build.rows {
+				build, probe = probe, build
+			}
+			matched := build.rows
+			if rows > 0 {
+				matched = build.rows * probe.rows / rows
+			}
+			cost := (build.rows+probe.rows)*(indexRowCost+hashRowCost) + matched*lookupRowCost
+			if cost < bestCost {
+				children := []plan.AbstractPlan{indexScan(table, dbId, a, build), indexScan(table, dbId, a, probe)}
+				multiScan := plan.NewMultiIndexScan(children, dbId, table.ID)
+				if probe.rows >= parallelProbeRows {
+					multiScan = plan.NewParallelMultiIndexScan(children, dbId, table.ID, 0)
+				}
+				best, bestCost = plan.NewTableRowIdScan(table, dbId, table.ID, multiScan), cost
+				filter = a.Filter(append(append([]int{}, build.sargs...), probe.sargs...)...)
+			}
+		}
+	}
+	for _, d := range a.Disjunctions {
+		union, unionRows, ok := unionScan(table, dbId, s, d)
+		if !ok {
+			continue
+		}
+		// the disjunction is kept in the residual predicate
+		matched := unionRows
+		if matched > rows {
+			matched = rows
+		}
+		cost := unionRows*(indexRowCost+hashRowCost) + matched*lookupRowCost
+		if cost < bestCost {
+			best, bestCost = plan.NewTableRowIdScan(table, dbId, table.ID, union), cost
+			filter = a.Filter()
+		}
+	}
+	for _, r := range columnRanges(a) {
+		i := leadingIndex(table, r.offset)
+		if i < 0 {
+			continue
+		}
+		// the ranges are kept in the residual predicate
+		cost := s.EstimateRange(r.offset, r.start, r.startInclusive, r.end, r.endInclusive) * (indexRowCost + lookupRowCost)
+		if cost < bestCost {
+			best, bestCost = plan.NewTableRowIdScan(table, dbId, table.ID, rangeScan(table, dbId, i, r)), cost
+			filter = a.Filter()
+		}
+	}
+	return best, filter, nil
+}
+
+// columnRange is the intersection of the ranges on a column, the bounds are memory-comparable values,
+// a nil one leaves the range open on the side.
+type columnRange struct {
+	offset         int
+	start, end     []byte
+	startInclusive bool
+	endInclusive   bool
+}
+
+// columnRanges returns the intersections of the ranges of the analysis by their columns,
+// in the order the columns first appear.
+func columnRanges(a *Analysis) []columnRange {
+	var ranges []columnRange
+	for _, r := range a.Ranges {
+		i := 0
+		for i < len(ranges) && ranges[i].offset != r.Offset {
+			i++
+		}
+		if i == len(ranges) {
+			ranges = append(ranges, columnRange{offset: r.Offset})
+		}
+		c, bound := &ranges[i], codec.EncodeCmpValue(r.Value)
+		switch r.Op {
+		case ast.GT, ast.GE:
+			inclusive := r.Op == ast.GE
+			if cmp := bytes.Compare(bound, c.start); c.start == nil || cmp > 0 || cmp == 0 && !inclusive {
+				c.start, c.startInclusive = bound, inclusive
+			}
+		case ast.LT, ast.LE:
+			inclusive := r.Op == ast.LE
+			if cmp := bytes.Compare(bound, c.end); c.end == nil || cmp < 0 || cmp == 0 && !inclusive {
+				c.end, c.endInclusive = bound, inclusive
+			}
+		}
+	}
+	return ranges
+}
+
+// rangeScan returns the index range scan of the range on the index.
+func rangeScan(table *model.TableInfo, dbId uint64, index int, r columnRange) plan.IndexRangeScanPlan {
+	indexInfo := table.Indices[index]
+	indexRange := plan.IndexRange{StartInclusive: r.startInclusive, EndInclusive: r.endInclusive}
+	if r.start != nil {
+		indexRange.Start = codec.IndexEntryPrefix(indexInfo.ID, [][]byte{r.start})
+	}
+	if r.end != nil {
+		indexRange.End = codec.IndexEntryPrefix(indexInfo.ID, [][]byte{r.end})
+	}
+	return plan.NewIndexRangeScanPlan(model.NewIndexSchemaReader(table, index), indexInfo.ID, indexRange, false, nil, nil, dbId, table.ID)
+}
+
+// unionScan returns the index union scan of the sargs of the disjunction, and the estimated rows it yields
+// including the duplicates, it's false if a sarg has no index leading with its column.
+func unionScan(table *model.TableInfo, dbId uint64, s *TableStats, d Disjunction) (plan.IndexUnionPlan, float64, bool) {
+	children := make([]plan.AbstractPlan, 0, len(d.Sargs))
+	rows := 0.0
+	for _, sarg := range d.Sargs {
+		i := leadingIndex(table, sarg.Offset)
+		if i < 0 {
+			return nil, 0, false
+		}
+		children = append(children, plan.NewIndexScanPlan(model.NewIndexSchemaReader(table, i), Prefix(table.Indices[i], sarg), nil, nil, dbId, table.ID))
+		rows += s.Estimate(sarg.Offset, sarg.Value.GetBytes())
+	}
+	return plan.NewIndexUnionPlan(children, dbId, table.ID), rows, true
+}
+
+// leadingIndex returns the index with the fewest columns leads with the column, or -1 if there is no such one.
+func leadingIndex(table *model.TableInfo, offset int) int {
+	best := -1
+	for i, index := range table.Indices {
+		if len(index.Columns) == 0 || index.Columns[0].Offset != offset {
+			continue
+		}
+		if best < 0 || len(index.Columns) < len(table.Indices[best].Columns) {
+			best = i
+		}
+	}
+	return best
+}
+
+func indexScan(table *model.TableInfo, dbId uint64, a *Analysis, c candidate) plan.IndexScanPlan {
+	sargs := make([]Sarg, 0, len(c.sargs))
+	for _, i := range c.sargs {
+		sargs = append(sargs, a.Sargs[i])
+	}
+	prefix := Prefix(table.Indices[c.index], sargs...)
+	return plan.NewIndexScanPlan(model.NewIndexSchemaReader(table, c.index), prefix, nil, nil, dbId, table.ID)
+}
+
+// indexCandidates returns the indexes whose leading columns are compared by the sargs,
+// an index is usable by the sargs on any leading subset of its columns.
+func indexCandidates(table *model.TableInfo, a *Analysis) (candidates []candidate) {
+	for i, index := range table.Indices {
+		var sargs []int
+		for _, column := range index.Columns {
+			j := a.sargOf(column.Offset)
+			if j < 0 {
+				break
+			}
+			sargs = append(sargs, j)
+		}
+		if len(sargs) > 0 {
+			candidates = append(candidates, candidate{index: i, sargs: sargs})
+		}
+	}
+	return
+}
+
+func overlaps(a, b []int) bool {
+	for _, v := range a {
+		if containsInt(b, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,431 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	badgerAdapter "github.com/casbin-mesh/neo/pkg/db/adapter/badger"
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/index"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/neo/txn"
+	"github.com/casbin-mesh/neo/pkg/neo/utils"
+	"github.com/casbin-mesh/neo/pkg/parser"
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+const basicModel = `
+[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = r.sub == p.sub && r.obj == p.obj && r.act == p.act
+`
+
+const priorityModel = `
+[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = priority, sub, obj, act
+
+[policy_effect]
+e = priority(p.eft) || deny
+
+[matchers]
+m = r.sub == p.sub && r.obj == p.obj && r.act == p.act && p.priority < 10
+`
+
+// openTestDB creates the basic model with the extra indexes instead of the primary one, so the rules
+// generated by rule may repeat, and inserts them.
+func openTestDB(t *testing.T, path string, rows int, rule func(i int) []string, indexes ...*model.IndexInfo) (*txn.Manager, *model.DBInfo, func()) {
+	store, err := badgerAdapter.OpenManaged(badger.DefaultOptions(path).WithLogger(nil))
+	assert.Nil(t, err)
+	m := txn.NewManager(store, index.New[any](index.Options{}), index.New[*model.DBInfo](index.Options{}), txn.Options{})
+	cleanup := func() {
+		m.Close()
+		store.Close()
+		os.RemoveAll(path)
+	}
+
+	info, err := utils.CompileModelFromString("basic", basicModel)
+	assert.Nil(t, err)
+	table := info.TableInfo[0]
+	table.Indices = append(table.Indices[:len(table.Indices)-1], indexes...)
+	tx, err := m.NewTxn(true)
+	assert.Nil(t, err)
+	execute(t, tx.Session(), plan.NewCreateDBPlan(info))
+	values := make([]value.Values, 0, rows)
+	for i := 0; i < rows; i++ {
+		var row value.Values
+		for _, s := range rule(i) {
+			row = append(row, value.NewStringValue(s))
+		}
+		values = append(values, row)
+	}
+	execute(t, tx.Session(), plan.NewRawInsertPlan(values, info.ID, table.ID))
+	assert.Nil(t, tx.Commit(context.TODO()))
+	return m, info, cleanup
+}
+
+func execute(t *testing.T, sc session.Context, p plan.AbstractPlan) []btuple.Modifier {
+	builder := executor.NewExecutorBuilder(sc)
+	exec := builder.Build(p)
+	assert.Nil(t, builder.Error())
+	tuples, _, err := executor.Execute(exec, context.TODO())
+	assert.Nil(t, err)
+	return tuples
+}
+
+func newRequest(req ...string) btuple.Reader {
+	elems := make([]btuple.Elem, 0, len(req))
+	for _, s := range req {
+		elems = append(elems, btuple.Elem(s))
+	}
+	return btuple.NewModifier(elems)
+}
+
+// planAccess plans the access to the policy table in a new transaction, and executes it.
+func planAccess(t *testing.T, p *Planner, m *txn.Manager, info *model.DBInfo, req ...string) (plan.AbstractPlan, string, []btuple.Modifier) {
+	tx, err := m.NewTxn(false)
+	assert.Nil(t, err)
+	defer tx.Discard(context.TODO())
+	table := info.TableInfo[0]
+	access, filter, err := p.PlanAccess(context.TODO(), tx.Session(), info.ID, table, info.MatcherInfo[0], newRequest(req...))
+	assert.Nil(t, err)
+	if filter == nil {
+		return access, "", execute(t, tx.Session(), access)
+	}
+	return access, filter.String(), execute(t, tx.Session(), access)
+}
+
+// indexScanValue returns the index and the values an index scan looks up, joined by commas.
+func indexScanValue(t *testing.T, table *model.TableInfo, p plan.AbstractPlan) (string, string) {
+	scan, ok := p.(plan.IndexScanPlan)
+	assert.True(t, ok)
+	for _, index := range table.Indices {
+		prefix := codec.IndexEntryPrefix(index.ID, nil)
+		if !bytes.HasPrefix(scan.Prefix(), prefix) {
+			continue
+		}
+		var values []string
+		for rest := scan.Prefix()[len(prefix):]; len(rest) > 0; {
+			var (
+				v   value.Value
+				err error
+			)
+			v, rest, err = codec.DecodeCmpValue(rest)
+			assert.Nil(t, err)
+			values = append(values, v.GetString())
+		}
+		return index.Name.L, strings.Join(values, ",")
+	}
+	t.Fatalf("unknown index scan prefix %q", scan.Prefix())
+	return "", ""
+}
+
+func assertRows(t *testing.T, tuples []btuple.Modifier, expected func(row []string) bool, count int) {
+	assert.Len(t, tuples, count)
+	for _, tuple := range tuples {
+		row := make([]string, 0, 3)
+		for _, v := range tuple.Values()[:3] {
+			row = append(row, string(v))
+		}
+		assert.True(t, expected(row), row)
+	}
+}
+
+func TestPlanner_SeqScan(t *testing.T) {
+	m, info, cleanup := openTestDB(t, "./__test_tmp__/seq_scan", 10, func(i int) []string {
+		return []string{fmt.Sprintf("user%d", i%2), "data", "read"}
+	})
+	defer cleanup()
+
+	// no index is selective enough to pay off the row id lookups
+	p := New()
+	access, filter, tuples := planAccess(t, p, m, info, "user1", "data", "read")
+	_, ok := access.(plan.SeqScanPlan)
+	assert.True(t, ok)
+	assert.Equal(t, "r.sub == p.sub && r.obj == p.obj && r.act == p.act", filter)
+	assert.Len(t, tuples, 10)
+	assert.Equal(t, uint64(10), p.Stats(info.TableInfo[0].ID).RowCount)
+}
+
+func TestPlanner_IndexScan(t *testing.T) {
+	m, info, cleanup := openTestDB(t, "./__test_tmp__/index_scan", 1000, func(i int) []string {
+		return []string{fmt.Sprintf("user%d", i%100), fmt.Sprintf("data%d", i%10), "read"}
+	})
+	defer cleanup()
+
+	p := New()
+	access, filter, tuples := planAccess(t, p, m, info, "user1", "data1", "read")
+	rowIdScan, ok := access.(*plan.TableRowIdScan)
+	assert.True(t, ok)
+	assert.Equal(t, "r.obj == p.obj && r.act == p.act", filter)
+	index, value := indexScanValue(t, info.TableInfo[0], rowIdScan.GetChildAt(0))
+	assert.Equal(t, "sub_index", index)
+	assert.Equal(t, "user1", value)
+	assertRows(t, tuples, func(row []string) bool { return row[0] == "user1" }, 10)
+
+	// the estimations follow the request values
+	access, _, tuples = planAccess(t, p, m, info, "user1", "data1", "write")
+	rowIdScan, ok = access.(*plan.TableRowIdScan)
+	assert.True(t, ok)
+	index, value = indexScanValue(t, info.TableInfo[0], rowIdScan.GetChildAt(0))
+	assert.Equal(t, "act_index", index)
+	assert.Equal(t, "write", value)
+	assert.Empty(t, tuples)
+}
+
+func TestPlanner_MultiIndexScan(t *testing.T) {
+	m, info, cleanup := openTestDB(t, "./__test_tmp__/multi_index_scan", 1200, func(i int) []string {
+		return []string{fmt.Sprintf("user%d", i%10), fmt.Sprintf("data%d", i%12), "read"}
+	})
+	defer cleanup()
+
+	p := New()
+	access, filter, tuples := planAccess(t, p, m, info, "user1", "data1", "read")
+	rowIdScan, ok := access.(*plan.TableRowIdScan)
+	assert.True(t, ok)
+	assert.Equal(t, "r.act == p.act", filter)
+	multiScan, ok := rowIdScan.GetChildAt(0).(plan.MultiIndexScan)
+	assert.True(t, ok)
+	// the smaller side builds the hash table
+	index, value := indexScanValue(t, info.TableInfo[0], multiScan.GetChildAt(0))
+	assert.Equal(t, "obj_index", index)
+	assert.Equal(t, "data1", value)
+	index, value = indexScanValue(t, info.TableInfo[0], multiScan.GetChildAt(1))
+	assert.Equal(t, "sub_index", index)
+	assert.Equal(t, "user1", value)
+	assertRows(t, tuples, func(row []string) bool { return row[0] == "user1" && row[1] == "data1" }, 20)
+}
+
+func TestPlanner_CompositeIndexScan(t *testing.T) {
+	subObjIndex := &model.IndexInfo{
+		Name:  model.CIStr{O: "sub_obj_index", L: "sub_obj_index"},
+		Table: model.CIStr{O: "p", L: "p"},
+		Columns: []*model.IndexColumn{
+			{ColName: model.CIStr{O: "sub", L: "sub"}, Offset: 0},
+			{ColName: model.CIStr{O: "obj", L: "obj"}, Offset: 1},
+		},
+	}
+	m, info, cleanup := openTestDB(t, "./__test_tmp__/composite_index_scan", 400, func(i int) []string {
+		return []string{fmt.Sprintf("user%d", i%20), fmt.Sprintf("data%d", i/20), "read"}
+	}, subObjIndex)
+	defer cleanup()
+
+	p := New()
+	access, filter, tuples := planAccess(t, p, m, info, "user1", "data1", "read")
+	rowIdScan, ok := access.(*plan.TableRowIdScan)
+	assert.True(t, ok)
+	assert.Equal(t, "r.act == p.act", filter)
+	index, value := indexScanValue(t, info.TableInfo[0], rowIdScan.GetChildAt(0))
+	assert.Equal(t, "sub_obj_index", index)
+	assert.Equal(t, "user1,data1", value)
+	assertRows(t, tuples, func(row []string) bool { return row[0] == "user1" && row[1] == "data1" }, 1)
+
+	// the index is usable by its leading column
+	matcher := *info.MatcherInfo[0]
+	matcher.Predicate = parser.MustParseFromString("r.sub == p.sub && keyMatch(r.obj, p.obj)")
+	candidates := indexCandidates(info.TableInfo[0], AnalyzeMatcher(info.TableInfo[0], &matcher, newRequest("user1", "data1", "read")))
+	assert.Equal(t, []candidate{{index: 0, sargs: []int{0}}, {index: 3, sargs: []int{0}}}, candidates)
+}
+
+func TestPlanner_IndexUnionScan(t *testing.T) {
+	m, info, cleanup := openTestDB(t, "./__test_tmp__/index_union_scan", 1000, func(i int) []string {
+		if i%50 == 0 {
+			return []string{"*", fmt.Sprintf("data%d", i%2), "read"}
+		}
+		return []string{fmt.Sprintf("user%d", i%100), fmt.Sprintf("data%d", i%2), "read"}
+	})
+	defer cleanup()
+	info.MatcherInfo[0].Predicate = parser.MustParseFromString(`(r.sub == p.sub || p.sub == "*") && r.obj == p.obj && r.act == p.act`)
+
+	// the wildcard rules are looked up by the index too
+	p := New()
+	access, filter, tuples := planAccess(t, p, m, info, "user1", "data1", "read")
+	rowIdScan, ok := access.(*plan.TableRowIdScan)
+	assert.True(t, ok)
+	assert.Equal(t, info.MatcherInfo[0].Predicate.String(), filter)
+	union, ok := rowIdScan.GetChildAt(0).(plan.IndexUnionPlan)
+	assert.True(t, ok)
+	assert.Len(t, union.GetChildren(), 2)
+	for i, expected := range []string{"user1", "*"} {
+		index, value := indexScanValue(t, info.TableInfo[0], union.GetChildAt(i))
+		assert.Equal(t, "sub_index", index)
+		assert.Equal(t, expected, value)
+	}
+	assertRows(t, tuples, func(row []string) bool { return row[0] == "user1" || row[0] == "*" }, 30)
+
+	// the disjunction is not selective enough
+	info.MatcherInfo[0].Predicate = parser.MustParseFromString(`(r.obj == p.obj || p.sub == "*") && r.act == p.act`)
+	access, _, tuples = planAccess(t, p, m, info, "user1", "data1", "read")
+	_, ok = access.(plan.SeqScanPlan)
+	assert.True(t, ok)
+	assert.Len(t, tuples, 1000)
+}
+
+func TestPlanner_PrimaryIndexScan(t *testing.T) {
+	primaryIndex := &model.IndexInfo{
+		Name:  model.CIStr{O: model.PrimaryIndexName, L: model.PrimaryIndexName},
+		Table: model.CIStr{O: "p", L: "p"},
+		Columns: []*model.IndexColumn{
+			{ColName: model.CIStr{O: "sub", L: "sub"}, Offset: 0},
+			{ColName: model.CIStr{O: "obj", L: "obj"}, Offset: 1},
+			{ColName: model.CIStr{O: "act", L: "act"}, Offset: 2},
+		},
+		Primary: true,
+		Unique:  true,
+	}
+	m, info, cleanup := openTestDB(t, "./__test_tmp__/primary_index_scan", 400, func(i int) []string {
+		return []string{fmt.Sprintf("user%d", i%20), fmt.Sprintf("data%d", i/20), "read"}
+	}, primaryIndex)
+	defer cleanup()
+
+	// all the sargs are looked up by the primary index, nothing is left to filter
+	p := New()
+	access, filter, tuples := planAccess(t, p, m, info, "user1", "data1", "read")
+	rowIdScan, ok := access.(*plan.TableRowIdScan)
+	assert.True(t, ok)
+	assert.Empty(t, filter)
+	index, value := indexScanValue(t, info.TableInfo[0], rowIdScan.GetChildAt(0))
+	assert.Equal(t, model.PrimaryIndexName, index)
+	assert.Equal(t, "user1,data1,read", value)
+	assertRows(t, tuples, func(row []string) bool { return row[0] == "user1" && row[1] == "data1" }, 1)
+
+	_, _, tuples = planAccess(t, p, m, info, "user1", "data1", "write")
+	assert.Empty(t, tuples)
+}
+
+func TestPlanner_Analyze(t *testing.T) {
+	m, info, cleanup := openTestDB(t, "./__test_tmp__/analyze", 100, func(i int) []string {
+		return []string{fmt.Sprintf("user%d", i), "data", "read"}
+	})
+	defer cleanup()
+	table := info.TableInfo[0]
+
+	p := New()
+	planAccess(t, p, m, info, "user1", "data", "read")
+	s := p.Stats(table.ID)
+	assert.Equal(t, uint64(100), s.RowCount)
+	assert.Equal(t, float64(1), s.Estimate(0, []byte("user1")))
+	assert.Equal(t, float64(100), s.Estimate(1, []byte("data")))
+
+	// a few modifications keep the statistics
+	p.Modify(table.ID, 10)
+	planAccess(t, p, m, info, "user1", "data", "read")
+	assert.Equal(t, s, p.Stats(table.ID))
+
+	p.Modify(table.ID, 1)
+	planAccess(t, p, m, info, "user1", "data", "read")
+	assert.NotEqual(t, s, p.Stats(table.ID))
+
+	p.Invalidate(table.ID)
+	assert.Nil(t, p.Stats(table.ID))
+}
+
+func TestPlanner_PrefixValue(t *testing.T) {
+	m, info, cleanup := openTestDB(t, "./__test_tmp__/prefix_value", 100, func(i int) []string {
+		if i == 0 {
+			return []string{"user1_admin", "data", "read"}
+		}
+		return []string{fmt.Sprintf("user%d", i), "data", "read"}
+	})
+	defer cleanup()
+
+	// the entries of a value prefixed by the value are not scanned
+	access, _, tuples := planAccess(t, New(), m, info, "user1", "data", "read")
+	_, ok := access.(*plan.TableRowIdScan)
+	assert.True(t, ok)
+	assertRows(t, tuples, func(row []string) bool { return row[0] == "user1" }, 1)
+}
+
+func TestPlanner_IndexRangeScan(t *testing.T) {
+	path := "./__test_tmp__/index_range_scan"
+	store, err := badgerAdapter.OpenManaged(badger.DefaultOptions(path).WithLogger(nil))
+	assert.Nil(t, err)
+	m := txn.NewManager(store, index.New[any](index.Options{}), index.New[*model.DBInfo](index.Options{}), txn.Options{})
+	defer func() {
+		m.Close()
+		store.Close()
+		os.RemoveAll(path)
+	}()
+
+	// the priorities of the rules are 0 to 999, the subjects and the objects are the same
+	info, err := utils.CompileModelFromString("priority", priorityModel)
+	assert.Nil(t, err)
+	table := info.TableInfo[0]
+	rangeIndex := leadingIndex(table, 0)
+	assert.Equal(t, "priority_index", table.Indices[rangeIndex].Name.L)
+	tx, err := m.NewTxn(true)
+	assert.Nil(t, err)
+	execute(t, tx.Session(), plan.NewCreateDBPlan(info))
+	values := make([]value.Values, 0, 1000)
+	for i := 999; i >= 0; i-- {
+		values = append(values, value.Values{value.NewInt64Value(int64(i)), value.NewStringValue("alice"), value.NewStringValue("data"), value.NewStringValue("read")})
+	}
+	execute(t, tx.Session(), plan.NewRawInsertPlan(values, info.ID, table.ID))
+	assert.Nil(t, tx.Commit(context.TODO()))
+
+	p := New()
+	assertRange := func(predicate string, priorities ...int64) {
+		info.MatcherInfo[0].Predicate = parser.MustParseFromString(predicate)
+		access, filter, tuples := planAccess(t, p, m, info, "alice", "data", "read")
+		rowIdScan, ok := access.(*plan.TableRowIdScan)
+		assert.True(t, ok, predicate)
+		_, ok = rowIdScan.GetChildAt(0).(plan.IndexRangeScanPlan)
+		assert.True(t, ok, predicate)
+		// the ranges are left to the filter
+		assert.Contains(t, filter, "p.priority", predicate)
+		// the rows of the range in the order of their ids
+		var actual []int64
+		for _, tuple := range tuples {
+			v := codec.DecodeValue(tuple.ValueAt(0), bsontype.Int64)
+			actual = append(actual, v.GetInt64())
+		}
+		assert.Equal(t, priorities, actual, predicate)
+	}
+	assertRange(`r.sub == p.sub && r.obj == p.obj && r.act == p.act && p.priority < 3`, 2, 1, 0)
+	assertRange(`p.priority >= 997 && r.sub == p.sub`, 999, 998, 997)
+	// the bounds of a column are intersected
+	assertRange(`100 < p.priority && p.priority <= 103 && p.priority < 500 && p.priority >= 0`, 103, 102, 101)
+	assertRange(`p.priority > 5 && p.priority < 3`)
+
+	// the range is not selective enough
+	info.MatcherInfo[0].Predicate = parser.MustParseFromString(`r.sub == p.sub && p.priority < 900`)
+	access, _, tuples := planAccess(t, p, m, info, "alice", "data", "read")
+	_, ok := access.(plan.SeqScanPlan)
+	assert.True(t, ok)
+	assert.Len(t, tuples, 1000)
+}
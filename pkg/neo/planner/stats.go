@@ -0,0 +1,131 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"context"
+
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/primitive"
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/casbin-mesh/neo/pkg/stats"
+)
+
+const (
+	// sketchDepth and sketchWidth are the dimensions of the column sketches.
+	sketchDepth = 5
+	sketchWidth = 2048
+	// histogramBuckets is the number of the buckets of the column histograms.
+	histogramBuckets = 64
+	// autoAnalyzeRatio is the ratio of the modified rows to the analyzed rows
+	// that makes the statistics of a table stale.
+	autoAnalyzeRatio = 0.1
+)
+
+// TableStats is the statistics of a table as of the last analysis.
+type TableStats struct {
+	// RowCount is the number of rows.
+	RowCount uint64
+	// Columns are the frequency sketches of the columns, indexed by their offsets.
+	Columns []*stats.CMSketch
+	// Histograms are the histograms of the memory-comparable values of the columns, indexed by their offsets.
+	Histograms []*stats.Histogram
+	// modified is the number of rows inserted or deleted since the analysis.
+	modified uint64
+}
+
+// Estimate returns the estimated number of rows whose column at offset equals value.
+func (s *TableStats) Estimate(offset int, value []byte) float64 {
+	if offset >= len(s.Columns) {
+		return float64(s.RowCount)
+	}
+	count := s.Columns[offset].QueryBytes(value)
+	if count > s.RowCount {
+		count = s.RowCount
+	}
+	return float64(count)
+}
+
+// EstimateRange returns the estimated number of rows whose column at offset is in the range
+// from start to end, the bounds are memory-comparable values, a nil one leaves the range open on the side.
+func (s *TableStats) EstimateRange(offset int, start []byte, startInclusive bool, end []byte, endInclusive bool) float64 {
+	if offset >= len(s.Histograms) {
+		return float64(s.RowCount)
+	}
+	return s.Histograms[offset].RangeCount(start, startInclusive, end, endInclusive)
+}
+
+// stale reports whether enough rows are modified since the table is analyzed,
+// the caller holds the lock of the planner, which guards modified.
+func (s *TableStats) stale() bool {
+	return float64(s.modified) > float64(s.RowCount)*autoAnalyzeRatio
+}
+
+// analyze scans the table in the session once, and builds its statistics.
+// The table is grouped by all its columns with a single aggregation, a column sketch and histogram
+// are built from the counts of the distinct values of the column summed over the groups.
+func analyze(ctx context.Context, sc session.Context, dbId uint64, table *model.TableInfo) (*TableStats, error) {
+	columns := make([]string, 0, len(table.Columns))
+	for _, column := range table.Columns {
+		columns = append(columns, column.ColName.L)
+	}
+	groups, err := plan.NewAggregationPlan(plan.NewSeqScanPlan(table, nil, nil, dbId, table.ID), columns, []plan.Aggregate{{Type: plan.AggregateCount}})
+	if err != nil {
+		return nil, err
+	}
+	builder := executor.NewExecutorBuilder(sc)
+	exec, err := builder.Build(groups), builder.Error()
+	if err != nil {
+		return nil, err
+	}
+	counts := make([]map[string]uint64, len(table.Columns))
+	for i := range counts {
+		counts[i] = make(map[string]uint64)
+	}
+	s := &TableStats{
+		Columns:    make([]*stats.CMSketch, len(table.Columns)),
+		Histograms: make([]*stats.Histogram, len(table.Columns)),
+	}
+	err = executor.ForEach(exec, ctx, func(group btuple.Modifier, _ primitive.ObjectID) error {
+		// the group columns are followed by the count of the group
+		v := codec.DecodeValue(group.ValueAt(len(counts)), bsontype.Int64)
+		count := uint64(v.GetInt64())
+		for i := range counts {
+			counts[i][string(group.ValueAt(i))] += count
+		}
+		s.RowCount += count
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, column := range table.Columns {
+		sketch := stats.NewCMSketch(sketchDepth, sketchWidth)
+		values, frequencies := make([][]byte, 0, len(counts[i])), make([]uint64, 0, len(counts[i]))
+		for elem, count := range counts[i] {
+			sketch.InsertBytesByCount([]byte(elem), count)
+			values = append(values, codec.EncodeCmpValue(codec.DecodeValue([]byte(elem), column.Tp)))
+			frequencies = append(frequencies, count)
+		}
+		s.Columns[i], s.Histograms[i] = sketch, stats.NewHistogram(values, frequencies, histogramBuckets)
+	}
+	return s, nil
+}
@@ -0,0 +1,264 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package neo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/neo/rbac"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/casbin-mesh/neo/pkg/neo/txn"
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+	"github.com/casbin-mesh/neo/pkg/primitive/value"
+)
+
+// insertBatchSize is the max number of rules inserted by a single InsertPlan.
+const insertBatchSize = 512
+
+// Rule is a policy rule belongs to the ptype table.
+type Rule struct {
+	PType  string
+	Values []string
+}
+
+// PTypes returns the ptypes of the model, the policy definitions come first, then the role definitions.
+func (e *Engine) PTypes(model string) (ptypes []string, err error) {
+	err = e.view(context.TODO(), func(sc session.Context) error {
+		dbInfo, err := sc.GetCatalog().GetDBInfoByName(model)
+		if err != nil {
+			return err
+		}
+		for _, table := range dbInfo.TableInfo {
+			ptypes = append(ptypes, table.Name.L)
+		}
+		return nil
+	})
+	return
+}
+
+// GetNamedPolicy returns all rules of the ptype table in insertion order,
+// trailing empty values are omitted.
+func (e *Engine) GetNamedPolicy(model string, ptype string) (rules [][]string, err error) {
+	ctx := context.TODO()
+	err = e.view(ctx, func(sc session.Context) error {
+		dbInfo, tableInfo, err := lookupTable(sc, model, ptype)
+		if err != nil {
+			return err
+		}
+		tuples, err := executeTuples(ctx, sc, plan.NewSeqScanPlan(tableInfo, nil, nil, dbInfo.ID, tableInfo.ID))
+		if err != nil {
+			return err
+		}
+		rules = make([][]string, 0, len(tuples))
+		for _, tuple := range tuples {
+			rules = append(rules, formatRule(tableInfo, tuple.Values()))
+		}
+		return nil
+	})
+	return
+}
+
+// NormalizeRules returns the values of the rules as GetNamedPolicy returns them once they are stored,
+// e.g. the omitted effects are allow and the priorities are formatted as integers, so the rules can be
+// compared to the stored ones. The values of a rule that can't be stored are returned as they are.
+func (e *Engine) NormalizeRules(model string, rules []Rule) (normalized [][]string, err error) {
+	err = e.view(context.TODO(), func(sc session.Context) error {
+		dbInfo, err := sc.GetCatalog().GetDBInfoByName(model)
+		if err != nil {
+			return err
+		}
+		normalized = make([][]string, 0, len(rules))
+		for _, rule := range rules {
+			normalized = append(normalized, rule.Values)
+			tableInfo, err := dbInfo.TableByLName(strings.ToLower(rule.PType))
+			if err != nil {
+				continue
+			}
+			values, ok := normalizeRule(tableInfo, rule.Values)
+			if !ok {
+				continue
+			}
+			parsed, ok := ruleValues(tableInfo, values)
+			if !ok {
+				continue
+			}
+			tuple := btuple.NewModifierFromBytes(codec.EncodeValues(parsed))
+			normalized[len(normalized)-1] = formatRule(tableInfo, tuple.Values())
+		}
+		return nil
+	})
+	return
+}
+
+// formatRule returns the rule of the values of the columns of the table, trailing empty values are omitted.
+func formatRule(tableInfo *model.TableInfo, values []btuple.Elem) []string {
+	end := len(values)
+	for end > 0 && len(values[end-1]) == 0 {
+		end--
+	}
+	rule := make([]string, 0, end)
+	for i, v := range values[:end] {
+		rule = append(rule, formatElem(tableInfo.Columns[i], v))
+	}
+	return rule
+}
+
+// GetAllNamedValues returns the distinct non-empty values of the field of the ptype table,
+// in the order they first appear, e.g. the subjects of the p rules by the field sub.
+func (e *Engine) GetAllNamedValues(model string, ptype string, field string) (values []string, err error) {
+	ctx := context.TODO()
+	err = e.view(ctx, func(sc session.Context) error {
+		dbInfo, tableInfo, err := lookupTable(sc, model, ptype)
+		if err != nil {
+			return err
+		}
+		offset := tableInfo.Field(strings.ToLower(field))
+		p, err := plan.NewAggregationPlan(plan.NewSeqScanPlan(tableInfo, nil, nil, dbInfo.ID, tableInfo.ID), []string{strings.ToLower(field)}, nil)
+		if err != nil {
+			return err
+		}
+		tuples, err := executeTuples(ctx, sc, p)
+		if err != nil {
+			return err
+		}
+		values = make([]string, 0, len(tuples))
+		for _, tuple := range tuples {
+			if v := tuple.ValueAt(0); len(v) > 0 {
+				values = append(values, formatElem(tableInfo.Columns[offset], v))
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// CountNamedPolicy returns the number of rules of the ptype table.
+func (e *Engine) CountNamedPolicy(model string, ptype string) (count int, err error) {
+	ctx := context.TODO()
+	err = e.view(ctx, func(sc session.Context) error {
+		dbInfo, tableInfo, err := lookupTable(sc, model, ptype)
+		if err != nil {
+			return err
+		}
+		p, err := plan.NewAggregationPlan(plan.NewSeqScanPlan(tableInfo, nil, nil, dbInfo.ID, tableInfo.ID), nil, []plan.Aggregate{{Type: plan.AggregateCount}})
+		if err != nil {
+			return err
+		}
+		tuples, err := executeTuples(ctx, sc, p)
+		if err != nil {
+			return err
+		}
+		v := codec.DecodeValue(tuples[0].ValueAt(0), bsontype.Int64)
+		count = int(v.GetInt64())
+		return nil
+	})
+	return
+}
+
+// ReplacePolicy replaces all rules of the model with rules in a single transaction,
+// duplicated rules are inserted once. The role graphs are replaced by the ones of the rules in the commit.
+func (e *Engine) ReplacePolicy(model string, rules []Rule) error {
+	ctx := context.TODO()
+	var tableIds []uint64
+	err := e.updateTxn(ctx, func(t *txn.Txn) error {
+		sc := t.Session()
+		dbInfo, err := sc.GetCatalog().GetDBInfoByName(model)
+		if err != nil {
+			return err
+		}
+		for _, table := range dbInfo.TableInfo {
+			tableIds = append(tableIds, table.ID)
+		}
+		batches, err := groupRules(dbInfo, rules)
+		if err != nil {
+			return err
+		}
+		for _, table := range dbInfo.TableInfo {
+			scan := plan.NewSeqScanPlan(table, nil, nil, dbInfo.ID, table.ID)
+			if _, err = execute(ctx, sc, plan.NewDeletePlan([]plan.AbstractPlan{scan}, table.ID, dbInfo.ID)); err != nil {
+				return err
+			}
+			rows := batches[table.Name.L]
+			for start := 0; start < len(rows); start += insertBatchSize {
+				end := start + insertBatchSize
+				if end > len(rows) {
+					end = len(rows)
+				}
+				if _, err = execute(ctx, sc, plan.NewRawInsertPlan(rows[start:end], dbInfo.ID, table.ID)); err != nil {
+					return err
+				}
+			}
+		}
+
+		roleRules := make(map[string][][]string)
+		for ptype, rows := range batches {
+			if !rbac.IsRoleType(ptype) {
+				continue
+			}
+			for _, row := range rows {
+				rule := make([]string, 0, len(row))
+				for _, v := range row {
+					rule = append(rule, v.GetString())
+				}
+				roleRules[ptype] = append(roleRules[ptype], rule)
+			}
+		}
+		managers := e.newRoleManagers(dbInfo, roleRules)
+		t.OnCommit(func(commitTs uint64) {
+			e.replaceRoleManagers(dbInfo.Name.L, managers, commitTs)
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, id := range tableIds {
+		e.planner.Invalidate(id)
+	}
+	return nil
+}
+
+// groupRules dispatches the rules to their tables by ptype, keeps the first one of the duplicated rules.
+func groupRules(dbInfo *model.DBInfo, rules []Rule) (map[string][]value.Values, error) {
+	batches := make(map[string][]value.Values)
+	seen := make(map[string]struct{}, len(rules))
+	for _, rule := range rules {
+		tableInfo, err := dbInfo.TableByLName(rule.PType)
+		if err != nil {
+			return nil, err
+		}
+		normalized, ok := normalizeRule(tableInfo, rule.Values)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+		key := tableInfo.Name.L + "\x00" + strings.Join(normalized, "\x00")
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		values, ok := ruleValues(tableInfo, normalized)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+		batches[tableInfo.Name.L] = append(batches[tableInfo.Name.L], values)
+	}
+	return batches, nil
+}
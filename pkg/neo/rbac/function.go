@@ -0,0 +1,47 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/expression/builtin"
+)
+
+type roleFn struct {
+	rm *RoleManager
+}
+
+// NewFunction exposes the role manager to matchers as g(name1, name2[, domain]).
+func NewFunction(rm *RoleManager) ast.FunctionWithCtx {
+	return &roleFn{rm: rm}
+}
+
+func (r roleFn) Eval(ctx ast.EvaluateCtx, args ...ast.Evaluable) (*ast.Primitive, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, builtin.ErrInvalidArgs
+	}
+	names := make([]string, 0, len(args))
+	for _, arg := range args {
+		v, err := arg.Evaluate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if v.Typ != ast.STRING {
+			return nil, builtin.ErrInvalidArgType
+		}
+		names = append(names, v.Value.(string))
+	}
+	return &ast.Primitive{Typ: ast.BOOLEAN, Value: r.rm.HasLink(names[0], names[1], names[2:]...), Mutable: true}, nil
+}
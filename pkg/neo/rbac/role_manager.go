@@ -0,0 +1,152 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxHierarchyLevel is the default max number of links between a member and its roles.
+const DefaultMaxHierarchyLevel = 10
+
+// defaultDomain is used by the links without domain.
+const defaultDomain = ""
+
+// IsRoleType reports whether ptype names a role definition, e.g. g, g2.
+func IsRoleType(ptype string) bool {
+	return strings.HasPrefix(strings.ToLower(ptype), "g")
+}
+
+// RoleManager is an in-memory role graph of a role definition.
+// A link name1 -> name2 means name1 inherits the role name2,
+// links are scoped to their domains.
+type RoleManager struct {
+	mu                sync.RWMutex
+	maxHierarchyLevel int
+	// links: domain -> member -> roles
+	links map[string]map[string]map[string]struct{}
+}
+
+// NewRoleManager returns a RoleManager, DefaultMaxHierarchyLevel is used if maxHierarchyLevel isn't positive.
+func NewRoleManager(maxHierarchyLevel int) *RoleManager {
+	if maxHierarchyLevel <= 0 {
+		maxHierarchyLevel = DefaultMaxHierarchyLevel
+	}
+	return &RoleManager{
+		maxHierarchyLevel: maxHierarchyLevel,
+		links:             make(map[string]map[string]map[string]struct{}),
+	}
+}
+
+func domainOf(domain []string) string {
+	if len(domain) > 0 {
+		return domain[0]
+	}
+	return defaultDomain
+}
+
+// AddLink adds the inheritance link name1 -> name2.
+func (rm *RoleManager) AddLink(name1, name2 string, domain ...string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	dom := domainOf(domain)
+	members, ok := rm.links[dom]
+	if !ok {
+		members = make(map[string]map[string]struct{})
+		rm.links[dom] = members
+	}
+	roles, ok := members[name1]
+	if !ok {
+		roles = make(map[string]struct{})
+		members[name1] = roles
+	}
+	roles[name2] = struct{}{}
+}
+
+// DeleteLink deletes the inheritance link name1 -> name2.
+func (rm *RoleManager) DeleteLink(name1, name2 string, domain ...string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	dom := domainOf(domain)
+	members, ok := rm.links[dom]
+	if !ok {
+		return
+	}
+	roles, ok := members[name1]
+	if !ok {
+		return
+	}
+	delete(roles, name2)
+	if len(roles) == 0 {
+		delete(members, name1)
+	}
+	if len(members) == 0 {
+		delete(rm.links, dom)
+	}
+}
+
+// HasLink reports whether name1 inherits name2 directly or transitively,
+// through at most maxHierarchyLevel links.
+func (rm *RoleManager) HasLink(name1, name2 string, domain ...string) bool {
+	if name1 == name2 {
+		return true
+	}
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	members, ok := rm.links[domainOf(domain)]
+	if !ok {
+		return false
+	}
+
+	visited := map[string]struct{}{name1: {}}
+	frontier := []string{name1}
+	for level := 0; level < rm.maxHierarchyLevel && len(frontier) > 0; level++ {
+		var next []string
+		for _, member := range frontier {
+			for role := range members[member] {
+				if role == name2 {
+					return true
+				}
+				if _, ok := visited[role]; !ok {
+					visited[role] = struct{}{}
+					next = append(next, role)
+				}
+			}
+		}
+		frontier = next
+	}
+	return false
+}
+
+// GetRoles returns the roles that name inherits directly.
+func (rm *RoleManager) GetRoles(name string, domain ...string) []string {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	roles := make([]string, 0, len(rm.links[domainOf(domain)][name]))
+	for role := range rm.links[domainOf(domain)][name] {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// Clear removes all links.
+func (rm *RoleManager) Clear() {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.links = make(map[string]map[string]map[string]struct{})
+}
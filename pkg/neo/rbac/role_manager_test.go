@@ -0,0 +1,114 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"testing"
+
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/expression/builtin"
+	"github.com/casbin-mesh/neo/pkg/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleManager_HasLink(t *testing.T) {
+	rm := NewRoleManager(0)
+	rm.AddLink("u1", "g1")
+	rm.AddLink("u2", "g1")
+	rm.AddLink("u3", "g2")
+	rm.AddLink("g1", "g3")
+	rm.AddLink("g3", "g1") // cycle
+
+	sets := []struct {
+		name1, name2 string
+		expected     bool
+	}{
+		{"u1", "u1", true},
+		{"u1", "g1", true},
+		{"u1", "g3", true},
+		{"u1", "g2", false},
+		{"u3", "g2", true},
+		{"u3", "g1", false},
+		{"g1", "g1", true},
+		{"g3", "u1", false},
+		{"unknown", "g1", false},
+	}
+	for _, set := range sets {
+		assert.Equal(t, set.expected, rm.HasLink(set.name1, set.name2), "%s -> %s", set.name1, set.name2)
+	}
+	assert.Equal(t, []string{"g1"}, rm.GetRoles("u1"))
+
+	rm.DeleteLink("g1", "g3")
+	assert.False(t, rm.HasLink("u1", "g3"))
+	assert.True(t, rm.HasLink("u1", "g1"))
+
+	rm.Clear()
+	assert.False(t, rm.HasLink("u1", "g1"))
+}
+
+func TestRoleManager_Domain(t *testing.T) {
+	rm := NewRoleManager(0)
+	rm.AddLink("alice", "admin", "domain1")
+	rm.AddLink("bob", "admin", "domain2")
+	rm.AddLink("admin", "root", "domain1")
+
+	assert.True(t, rm.HasLink("alice", "admin", "domain1"))
+	assert.True(t, rm.HasLink("alice", "root", "domain1"))
+	assert.False(t, rm.HasLink("alice", "admin", "domain2"))
+	assert.False(t, rm.HasLink("alice", "admin"))
+	assert.True(t, rm.HasLink("bob", "admin", "domain2"))
+	assert.False(t, rm.HasLink("bob", "root", "domain2"))
+}
+
+func TestRoleManager_MaxHierarchyLevel(t *testing.T) {
+	rm := NewRoleManager(2)
+	rm.AddLink("u1", "g1")
+	rm.AddLink("g1", "g2")
+	rm.AddLink("g2", "g3")
+
+	assert.True(t, rm.HasLink("u1", "g1"))
+	assert.True(t, rm.HasLink("u1", "g2"))
+	assert.False(t, rm.HasLink("u1", "g3"))
+	assert.True(t, rm.HasLink("g1", "g3"))
+}
+
+func TestRoleFunction(t *testing.T) {
+	rm := NewRoleManager(0)
+	rm.AddLink("alice", "admin")
+	rm.AddLink("bob", "admin", "domain1")
+
+	ctx := ast.NewContext()
+	ctx.AddFunctionWithCtx("g", NewFunction(rm))
+	sets := []struct {
+		expr     string
+		expected bool
+	}{
+		{`g("alice", "admin")`, true},
+		{`g("bob", "admin")`, false},
+		{`g("bob", "admin", "domain1")`, true},
+		{`g("alice", "admin") && g("bob", "admin", "domain1")`, true},
+	}
+	for _, set := range sets {
+		result, err := parser.MustParseFromString(set.expr).Evaluate(ctx)
+		assert.Nil(t, err, set.expr)
+		assert.Equal(t, ast.BOOLEAN, result.Typ, set.expr)
+		assert.Equal(t, set.expected, result.Value, set.expr)
+	}
+
+	_, err := parser.MustParseFromString(`g("alice")`).Evaluate(ctx)
+	assert.Equal(t, builtin.ErrInvalidArgs, err)
+	_, err = parser.MustParseFromString(`g("alice", 1)`).Evaluate(ctx)
+	assert.Equal(t, builtin.ErrInvalidArgType, err)
+}
@@ -0,0 +1,208 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package neo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/neo/executor/plan"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/neo/rbac"
+	"github.com/casbin-mesh/neo/pkg/neo/txn"
+	"github.com/casbin-mesh/neo/pkg/primitive/btuple"
+)
+
+const defaultGType = "g"
+
+// roleManagers returns the role managers of the model, keyed by their ptype.
+func (e *Engine) roleManagers(model string) map[string]*rbac.RoleManager {
+	e.rolesMu.RLock()
+	defer e.rolesMu.RUnlock()
+	return e.roles[strings.ToLower(model)]
+}
+
+func (e *Engine) roleManager(model, ptype string) *rbac.RoleManager {
+	return e.roleManagers(model)[strings.ToLower(ptype)]
+}
+
+// setupRoles creates an empty role manager for every role definition of the model.
+func (e *Engine) setupRoles(info *model.DBInfo) {
+	managers := make(map[string]*rbac.RoleManager)
+	for _, table := range info.TableInfo {
+		if rbac.IsRoleType(table.Name.L) {
+			managers[table.Name.L] = rbac.NewRoleManager(e.opts.MaxHierarchyLevel)
+		}
+	}
+	e.rolesMu.Lock()
+	e.roles[info.Name.L] = managers
+	e.rolesMu.Unlock()
+}
+
+// addRoleFunctions exposes the role managers of the model to the matcher.
+func (e *Engine) addRoleFunctions(ctx *ast.Context, model string) {
+	for ptype, rm := range e.roleManagers(model) {
+		ctx.AddFunctionWithCtx(ptype, rbac.NewFunction(rm))
+	}
+}
+
+// BuildRoleLinks rebuilds the role graphs of the model from the committed role rules. The graphs are built
+// again if a role rule committed after the snapshot they are built from was applied to the replaced graphs.
+func (e *Engine) BuildRoleLinks(model string) error {
+	ctx := context.TODO()
+	for {
+		var (
+			name     string
+			readTs   uint64
+			managers map[string]*rbac.RoleManager
+		)
+		err := e.viewTxn(ctx, func(t *txn.Txn) error {
+			sc := t.Session()
+			dbInfo, err := sc.GetCatalog().GetDBInfoByName(model)
+			if err != nil {
+				return err
+			}
+			rules := make(map[string][][]string)
+			for _, table := range dbInfo.TableInfo {
+				if !rbac.IsRoleType(table.Name.L) {
+					continue
+				}
+				tuples, err := executeTuples(ctx, sc, plan.NewSeqScanPlan(table, nil, nil, dbInfo.ID, table.ID))
+				if err != nil {
+					return err
+				}
+				rules[table.Name.L] = tupleStrings(table, tuples)
+			}
+			name, readTs, managers = dbInfo.Name.L, t.ReadTs(), e.newRoleManagers(dbInfo, rules)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		e.rolesMu.Lock()
+		if e.rolesTs[name] <= readTs {
+			e.roles[name] = managers
+			e.rolesMu.Unlock()
+			return nil
+		}
+		e.rolesMu.Unlock()
+	}
+}
+
+// newRoleManagers returns the role graphs of the role definitions of the model, built from their rules.
+func (e *Engine) newRoleManagers(info *model.DBInfo, rules map[string][][]string) map[string]*rbac.RoleManager {
+	managers := make(map[string]*rbac.RoleManager)
+	for _, table := range info.TableInfo {
+		if !rbac.IsRoleType(table.Name.L) {
+			continue
+		}
+		rm := rbac.NewRoleManager(e.opts.MaxHierarchyLevel)
+		for _, rule := range rules[table.Name.L] {
+			if len(rule) >= 2 {
+				rm.AddLink(rule[0], rule[1], rule[2:]...)
+			}
+		}
+		managers[table.Name.L] = rm
+	}
+	return managers
+}
+
+// replaceRoleManagers replaces the role graphs of the model, it's called in the order of the commits.
+func (e *Engine) replaceRoleManagers(model string, managers map[string]*rbac.RoleManager, commitTs uint64) {
+	e.rolesMu.Lock()
+	defer e.rolesMu.Unlock()
+	e.roles[model], e.rolesTs[model] = managers, commitTs
+}
+
+// applyRoleRules applies the role rules added or removed by a commit to the role graph of the ptype,
+// it's called in the order of the commits, so the graph follows the committed rules.
+func (e *Engine) applyRoleRules(model, ptype string, rules [][]string, add bool, commitTs uint64) {
+	if !rbac.IsRoleType(ptype) {
+		return
+	}
+	model = strings.ToLower(model)
+	e.rolesMu.Lock()
+	defer e.rolesMu.Unlock()
+	e.rolesTs[model] = commitTs
+	rm := e.roles[model][strings.ToLower(ptype)]
+	if rm == nil {
+		return
+	}
+	for _, rule := range rules {
+		if len(rule) < 2 {
+			continue
+		}
+		if add {
+			rm.AddLink(rule[0], rule[1], rule[2:]...)
+		} else {
+			rm.DeleteLink(rule[0], rule[1], rule[2:]...)
+		}
+	}
+}
+
+// tupleStrings returns the values of the tuples of the table as strings.
+func tupleStrings(table *model.TableInfo, tuples []btuple.Modifier) [][]string {
+	rules := make([][]string, 0, len(tuples))
+	for _, tuple := range tuples {
+		values := tuple.Values()
+		rule := make([]string, 0, len(values))
+		for i, v := range values {
+			rule = append(rule, formatElem(table.Columns[i], v))
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// AddGroupingPolicy adds a role inheritance rule to the default role definition of the model,
+// returns false if the rule already exists.
+func (e *Engine) AddGroupingPolicy(model string, rule ...string) (bool, error) {
+	return e.AddNamedGroupingPolicy(model, defaultGType, rule...)
+}
+
+// AddNamedGroupingPolicy adds a role inheritance rule to the ptype role definition of the model,
+// returns false if the rule already exists.
+func (e *Engine) AddNamedGroupingPolicy(model string, ptype string, rule ...string) (bool, error) {
+	if !rbac.IsRoleType(ptype) {
+		return false, ErrInvalidRequest
+	}
+	return e.AddNamedPolicy(model, ptype, rule...)
+}
+
+// RemoveGroupingPolicy removes a role inheritance rule from the default role definition of the model,
+// returns false if the rule does not exist.
+func (e *Engine) RemoveGroupingPolicy(model string, rule ...string) (bool, error) {
+	return e.RemoveNamedGroupingPolicy(model, defaultGType, rule...)
+}
+
+// RemoveNamedGroupingPolicy removes a role inheritance rule from the ptype role definition of the model,
+// returns false if the rule does not exist.
+func (e *Engine) RemoveNamedGroupingPolicy(model string, ptype string, rule ...string) (bool, error) {
+	if !rbac.IsRoleType(ptype) {
+		return false, ErrInvalidRequest
+	}
+	return e.RemoveNamedPolicy(model, ptype, rule...)
+}
+
+// HasRoleForUser reports whether the user inherits the role of the default role definition.
+func (e *Engine) HasRoleForUser(model string, user string, role string, domain ...string) (bool, error) {
+	rm := e.roleManager(model, defaultGType)
+	if rm == nil {
+		return false, ErrRoleNotExists
+	}
+	return rm.HasLink(user, role, domain...), nil
+}
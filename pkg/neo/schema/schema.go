@@ -1,19 +1,27 @@
 package schema
 
 import (
+	"bytes"
+
+	"github.com/casbin-mesh/neo/pkg/neo/codec"
 	"github.com/casbin-mesh/neo/pkg/neo/index"
 	"github.com/casbin-mesh/neo/pkg/neo/model"
 )
 
 type Reader interface {
 	Get(key []byte) (*model.DBInfo, error)
+	// List returns the DBInfo of all databases in ascending order of the ids.
+	List() ([]*model.DBInfo, error)
 }
 
 type ReaderWriter interface {
 	Reader
 	Set(key []byte, info *model.DBInfo) error
+	Delete(key []byte) error
 
-	CommitAt(commitTs uint64) error
+	// CommitAt commits the writes at commitTs, it never fails, so a session
+	// commits it after the Badger txn, which may conflict.
+	CommitAt(commitTs uint64)
 	Rollback()
 }
 
@@ -29,12 +37,30 @@ func (i inMemSchema) Set(key []byte, info *model.DBInfo) error {
 	return i.Txn.Set(key, info)
 }
 
-func (i inMemSchema) CommitAt(commitTs uint64) error {
-	return i.Txn.CommitAt(commitTs, nil)
+func (i inMemSchema) Delete(key []byte) error {
+	return i.Txn.Delete(key)
+}
+
+func (i inMemSchema) List() ([]*model.DBInfo, error) {
+	var infos []*model.DBInfo
+	prefix := codec.DBInfoKeyPrefix()
+	iter := i.Txn.Iterator(prefix, nil)
+	for iter.Next() {
+		if !bytes.HasPrefix(iter.Key(), prefix) {
+			break
+		}
+		infos = append(infos, iter.Value())
+	}
+	return infos, iter.Err()
+}
+
+func (i inMemSchema) CommitAt(commitTs uint64) {
+	// never fails, see index.Txn
+	_ = i.Txn.CommitAt(commitTs, nil)
 }
 
 func (i inMemSchema) Rollback() {
-	//TODO implement me
+	i.Txn.Discard()
 }
 
 func New(txn index.Txn[*model.DBInfo]) ReaderWriter {
@@ -30,6 +30,22 @@ type Context interface {
 	GetMetaReaderWriter() meta.ReaderWriter
 	GetSchemaReaderWriter() schema.ReaderWriter
 	GetTxn() db.Txn
+	// TrackRead records a point read of the key, it's a no-op unless the session is tracked.
+	TrackRead(key []byte)
+	// TrackPrefixRead records a predicate read of all keys with the prefix, it's a no-op unless the session is tracked.
+	TrackPrefixRead(prefix []byte)
+	// Snapshot returns a session reading the snapshot of the session on a Badger txn of its own, so the two
+	// sessions can be read concurrently. The snapshot opens its txn on the first use, and discards it by
+	// RollbackTxn, it's reopened by the next use. It returns false if the session is an update one,
+	// whose pending writes are invisible to another txn.
+	Snapshot() (Context, bool)
+}
+
+// Tracker collects the reads and writes of a session, e.g. for the serializability checks.
+type Tracker interface {
+	AddRead(key []byte)
+	AddPrefixRead(prefix []byte)
+	AddWrite(key []byte)
 }
 
 type ctx struct {
@@ -38,6 +54,9 @@ type ctx struct {
 	meta    meta.ReaderWriter
 	schema  schema.ReaderWriter
 	txnMark *y.WaterMark
+	tracker Tracker
+	// newSnapshot opens a read-only txn reading at the read timestamp of the session, it's nil for an update session.
+	newSnapshot func() db.Txn
 }
 
 func (c *ctx) GetSchemaReaderWriter() schema.ReaderWriter {
@@ -52,23 +71,35 @@ func (c *ctx) GetTxn() db.Txn {
 	return c.txn
 }
 
+func (c *ctx) TrackRead(key []byte) {
+	if c.tracker != nil {
+		c.tracker.AddRead(key)
+	}
+}
+
+func (c *ctx) TrackPrefixRead(prefix []byte) {
+	if c.tracker != nil {
+		c.tracker.AddPrefixRead(prefix)
+	}
+}
+
 func (c *ctx) GetMetaReaderWriter() meta.ReaderWriter {
 	return c.meta
 }
 
-func (c *ctx) CommitTxn(ctx context.Context, commitTs uint64) error {
-	err := c.txn.CommitAt(commitTs, func(err error) {
-		if err != nil {
-			c.RollbackTxn(ctx)
-			return
-		}
-
-		// TODO: handle failures
-		err = c.meta.CommitAt(commitTs)
-		err = c.schema.CommitAt(commitTs)
-		c.txnMark.Done(commitTs)
-	})
-	return err
+// CommitTxn commits the Badger txn and the meta/schema txns at commitTs, and marks commitTs done.
+// The meta/schema writes acquired their write-locks when they were made, so their commits never
+// fail and the Badger commit decides the outcome: all stores are rolled back if it fails.
+func (c *ctx) CommitTxn(ctx context.Context, commitTs uint64) (err error) {
+	defer c.txnMark.Done(commitTs)
+	if err = c.txn.CommitAt(commitTs, nil); err != nil {
+		c.meta.Rollback()
+		c.schema.Rollback()
+		return err
+	}
+	c.meta.CommitAt(commitTs)
+	c.schema.CommitAt(commitTs)
+	return nil
 }
 
 func (c *ctx) RollbackTxn(ctx context.Context) {
@@ -77,6 +108,19 @@ func (c *ctx) RollbackTxn(ctx context.Context) {
 	c.schema.Rollback()
 }
 
+func (c *ctx) Snapshot() (Context, bool) {
+	if c.newSnapshot == nil {
+		return nil, false
+	}
+	return &snapshot{ctx: &ctx{
+		catalog:     c.catalog,
+		meta:        c.meta,
+		schema:      c.schema,
+		txnMark:     c.txnMark,
+		newSnapshot: c.newSnapshot,
+	}}, true
+}
+
 func NewSessionCtx(txn db.Txn, meta meta.ReaderWriter, schema schema.ReaderWriter, txnMark *y.WaterMark) Context {
 	sessCtx := &ctx{
 		txn:     txn,
@@ -87,3 +131,57 @@ func NewSessionCtx(txn db.Txn, meta meta.ReaderWriter, schema schema.ReaderWrite
 	}
 	return sessCtx
 }
+
+// NewTrackedSessionCtx returns a session reporting its reads and writes to the tracker.
+func NewTrackedSessionCtx(txn db.Txn, meta meta.ReaderWriter, schema schema.ReaderWriter, txnMark *y.WaterMark, tracker Tracker) Context {
+	txn = &trackedTxn{Txn: txn, tracker: tracker}
+	return &ctx{
+		txn:     txn,
+		catalog: catalog.NewCatalog(meta, schema, txn),
+		meta:    meta,
+		schema:  schema,
+		txnMark: txnMark,
+		tracker: tracker,
+	}
+}
+
+// NewReadOnlySessionCtx returns a read-only session reading the store at readTs, its snapshots read the store at readTs as well.
+func NewReadOnlySessionCtx(store db.DB, readTs uint64, meta meta.ReaderWriter, schema schema.ReaderWriter, txnMark *y.WaterMark) Context {
+	txn := store.NewTransactionAt(readTs, false)
+	return &ctx{
+		txn:     txn,
+		catalog: catalog.NewCatalog(meta, schema, txn),
+		meta:    meta,
+		schema:  schema,
+		txnMark: txnMark,
+		newSnapshot: func() db.Txn {
+			return store.NewTransactionAt(readTs, false)
+		},
+	}
+}
+
+// snapshot is a session sharing the catalog and the meta/schema txns of its parent session,
+// which are only read, and reading the store on a txn of its own.
+type snapshot struct {
+	*ctx
+}
+
+func (s *snapshot) GetTxn() db.Txn {
+	if s.txn == nil {
+		s.txn = s.newSnapshot()
+	}
+	return s.txn
+}
+
+func (s *snapshot) CommitTxn(ctx context.Context, commitTs uint64) error {
+	s.RollbackTxn(ctx)
+	return nil
+}
+
+// RollbackTxn discards the txn of the snapshot, the txns of the parent session are left to it.
+func (s *snapshot) RollbackTxn(ctx context.Context) {
+	if s.txn != nil {
+		s.txn.Discard()
+		s.txn = nil
+	}
+}
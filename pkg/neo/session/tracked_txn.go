@@ -0,0 +1,39 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import "github.com/casbin-mesh/neo/pkg/db"
+
+// trackedTxn reports the point reads and the writes to the tracker,
+// the reads through iterators are reported by their users with TrackPrefixRead.
+type trackedTxn struct {
+	db.Txn
+	tracker Tracker
+}
+
+func (t *trackedTxn) Get(key []byte) (db.Item, error) {
+	t.tracker.AddRead(key)
+	return t.Txn.Get(key)
+}
+
+func (t *trackedTxn) Set(key []byte, value []byte) error {
+	t.tracker.AddWrite(key)
+	return t.Txn.Set(key, value)
+}
+
+func (t *trackedTxn) Delete(key []byte) error {
+	t.tracker.AddWrite(key)
+	return t.Txn.Delete(key)
+}
@@ -0,0 +1,215 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txn
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/casbin-mesh/neo/pkg/db"
+	"github.com/casbin-mesh/neo/pkg/neo/index"
+	"github.com/casbin-mesh/neo/pkg/neo/meta"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/neo/schema"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+	"github.com/dgraph-io/badger/v3/y"
+	"github.com/dgraph-io/ristretto/z"
+)
+
+// DefaultDiscardInterval is the default interval between two pushes of the discard timestamp.
+const DefaultDiscardInterval = time.Minute
+
+var (
+	ErrManagerClosed = errors.New("transaction manager closed")
+	ErrTxnDone       = errors.New("transaction has been committed or discarded")
+	// ErrConflict is returned when a transaction conflicts with a concurrent one, it's safe to retry.
+	ErrConflict = errors.New("transaction conflicts")
+	// ErrSerializationFailure is returned when a serializable transaction could
+	// break the serializability by committing, it's safe to retry.
+	ErrSerializationFailure = errors.New("could not serialize access due to read/write dependencies among transactions")
+)
+
+type Options struct {
+	// DiscardInterval is the interval between two pushes of the discard timestamp,
+	// DefaultDiscardInterval is used if it's zero.
+	DiscardInterval time.Duration
+	// Serializable enables the serializable snapshot isolation for the read-write transactions,
+	// otherwise they run under the snapshot isolation. The read-only transactions always read
+	// a consistent snapshot and are not tracked.
+	Serializable bool
+}
+
+// Collector reclaims the versions that are invisible to the transactions reading at or above ts.
+type Collector interface {
+	SetDiscardTs(ts uint64)
+}
+
+// Manager is the timestamp oracle of the engine. It hands out read and
+// commit timestamps, commits the Badger txn and the meta/schema txns of a
+// transaction atomically, and pushes the oldest active read timestamp to
+// the collectors periodically.
+type Manager struct {
+	db         db.DB
+	metaIndex  index.Index[any]
+	infoIndex  index.Index[*model.DBInfo]
+	collectors []Collector
+
+	// mu guards nextTs and closed, timestamps must enter the watermarks in order.
+	mu     sync.Mutex
+	nextTs uint64
+	closed bool
+	// commitMu serializes the commits, so a transaction checks conflicts
+	// against all the transactions committed before its commit timestamp.
+	commitMu     sync.Mutex
+	serializable bool
+	// committed are the serializable transactions that may be concurrent with an active one.
+	committed []*committedTxn
+
+	// readMark tracks the read timestamps of the active transactions.
+	readMark y.WaterMark
+	// txnMark tracks the commit timestamps, transactions committed at or below its DoneUntil are visible.
+	txnMark   y.WaterMark
+	discardTs uint64
+	closer    *z.Closer
+}
+
+// NewManager returns a Manager of the stores.
+func NewManager(store db.DB, metaIndex index.Index[any], infoIndex index.Index[*model.DBInfo], opts Options) *Manager {
+	if opts.DiscardInterval <= 0 {
+		opts.DiscardInterval = DefaultDiscardInterval
+	}
+	m := &Manager{
+		db:           store,
+		metaIndex:    metaIndex,
+		infoIndex:    infoIndex,
+		collectors:   []Collector{store, metaIndex, infoIndex},
+		serializable: opts.Serializable,
+		closer:       z.NewCloser(3),
+	}
+	m.readMark.Name = "neo.ReadTs"
+	m.txnMark.Name = "neo.TxnTs"
+	m.readMark.Init(m.closer)
+	m.txnMark.Init(m.closer)
+	// continue from the versions committed before the store was reopened
+	m.nextTs = store.MaxVersion()
+	m.readMark.SetDoneUntil(m.nextTs)
+	m.txnMark.SetDoneUntil(m.nextTs)
+	go m.discardLoop(opts.DiscardInterval)
+	return m
+}
+
+// NewTxn starts a transaction reading at the latest visible timestamp.
+func (m *Manager) NewTxn(update bool) (*Txn, error) {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, ErrManagerClosed
+	}
+	readTs := m.txnMark.DoneUntil()
+	m.readMark.Begin(readTs)
+	m.mu.Unlock()
+
+	metaTxn := m.metaIndex.NewTransactionAt(readTs, update)
+	infoTxn := m.infoIndex.NewTransactionAt(readTs, update)
+	t := &Txn{
+		m:      m,
+		readTs: readTs,
+		update: update,
+	}
+	switch {
+	case !update:
+		// the read-only sessions can open snapshots, e.g. for the children of a parallel scan
+		t.sc = session.NewReadOnlySessionCtx(m.db, readTs, meta.NewInMemMeta(metaTxn), schema.New(infoTxn), &m.txnMark)
+	case m.serializable:
+		t.set = newRWSet()
+		t.sc = session.NewTrackedSessionCtx(m.db.NewTransactionAt(readTs, true), meta.NewInMemMeta(metaTxn), schema.New(infoTxn), &m.txnMark, t.set)
+	default:
+		t.sc = session.NewSessionCtx(m.db.NewTransactionAt(readTs, true), meta.NewInMemMeta(metaTxn), schema.New(infoTxn), &m.txnMark)
+	}
+	return t, nil
+}
+
+// ReadTs returns the timestamp that all committed transactions are visible at.
+func (m *Manager) ReadTs() uint64 {
+	return m.txnMark.DoneUntil()
+}
+
+// DiscardTs returns the latest timestamp pushed to the collectors.
+func (m *Manager) DiscardTs() uint64 {
+	return atomic.LoadUint64(&m.discardTs)
+}
+
+func (m *Manager) newCommitTs() (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return 0, ErrManagerClosed
+	}
+	m.nextTs++
+	m.txnMark.Begin(m.nextTs)
+	return m.nextTs, nil
+}
+
+func (m *Manager) doneRead(readTs uint64) {
+	m.readMark.Done(readTs)
+}
+
+// PushDiscardTs pushes the oldest active read timestamp to the collectors,
+// the versions only visible below it are reclaimable.
+func (m *Manager) PushDiscardTs() {
+	ts := m.readMark.DoneUntil()
+	for {
+		old := atomic.LoadUint64(&m.discardTs)
+		if ts <= old {
+			return
+		}
+		if atomic.CompareAndSwapUint64(&m.discardTs, old, ts) {
+			break
+		}
+	}
+	for _, c := range m.collectors {
+		c.SetDiscardTs(ts)
+	}
+}
+
+func (m *Manager) discardLoop(interval time.Duration) {
+	defer m.closer.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.PushDiscardTs()
+		case <-m.closer.HasBeenClosed():
+			return
+		}
+	}
+}
+
+// Close stops the manager, new transactions are rejected.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return ErrManagerClosed
+	}
+	m.closed = true
+	m.mu.Unlock()
+
+	m.closer.SignalAndWait()
+	return nil
+}
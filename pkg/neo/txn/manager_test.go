@@ -0,0 +1,245 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txn
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/casbin-mesh/neo/pkg/db"
+	badgerAdapter "github.com/casbin-mesh/neo/pkg/db/adapter/badger"
+	"github.com/casbin-mesh/neo/pkg/neo/index"
+	"github.com/casbin-mesh/neo/pkg/neo/meta"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockStore struct {
+	db.DB
+	discardTs uint64
+}
+
+func (s *mockStore) SetDiscardTs(ts uint64) {
+	s.discardTs = ts
+	s.DB.SetDiscardTs(ts)
+}
+
+func openTestManager(t *testing.T, path string) (*Manager, *mockStore) {
+	store, err := badgerAdapter.OpenManaged(badger.DefaultOptions(path).WithLogger(nil))
+	assert.Nil(t, err)
+	mock := &mockStore{DB: store}
+	return NewManager(mock, index.New[any](index.Options{}), index.New[*model.DBInfo](index.Options{}), Options{}), mock
+}
+
+func TestManager_Commit(t *testing.T) {
+	p := "./__test_tmp__/commit"
+	m, store := openTestManager(t, p)
+	defer func() {
+		m.Close()
+		store.Close()
+		os.RemoveAll(p)
+	}()
+	ctx := context.TODO()
+
+	txn1, err := m.NewTxn(true)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), txn1.ReadTs())
+	assert.Nil(t, txn1.Session().GetTxn().Set([]byte("hello"), []byte("world")))
+	_, err = txn1.Session().GetMetaReaderWriter().NewDb("db")
+	assert.Nil(t, err)
+	assert.Nil(t, txn1.Commit(ctx))
+	assert.Equal(t, ErrTxnDone, txn1.Commit(ctx))
+	assert.Equal(t, uint64(1), m.ReadTs())
+
+	// the changes of both stores are visible
+	txn2, err := m.NewTxn(false)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), txn2.ReadTs())
+	item, err := txn2.Session().GetTxn().Get([]byte("hello"))
+	assert.Nil(t, err)
+	value, err := item.ValueCopy(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("world"), value)
+	_, err = txn2.Session().GetMetaReaderWriter().GetDBId("db")
+	assert.Nil(t, err)
+	assert.Nil(t, txn2.Commit(ctx))
+}
+
+func TestManager_Conflict(t *testing.T) {
+	p := "./__test_tmp__/conflict"
+	m, store := openTestManager(t, p)
+	defer func() {
+		m.Close()
+		store.Close()
+		os.RemoveAll(p)
+	}()
+	ctx := context.TODO()
+
+	txn1, err := m.NewTxn(true)
+	assert.Nil(t, err)
+	txn2, err := m.NewTxn(true)
+	assert.Nil(t, err)
+	for _, txn := range []*Txn{txn1, txn2} {
+		_, err = txn.Session().GetTxn().Get([]byte("counter"))
+		assert.NotNil(t, err)
+		assert.Nil(t, txn.Session().GetTxn().Set([]byte("counter"), []byte("1")))
+	}
+	_, err = txn2.Session().GetMetaReaderWriter().NewDb("db")
+	assert.Nil(t, err)
+
+	assert.Nil(t, txn1.Commit(ctx))
+	err = txn2.Commit(ctx)
+	assert.Equal(t, ErrConflict, err)
+	assert.True(t, IsConflict(err))
+
+	// the meta changes of txn2 are rolled back
+	txn3, err := m.NewTxn(true)
+	assert.Nil(t, err)
+	metaRW := txn3.Session().GetMetaReaderWriter()
+	_, err = metaRW.GetDBId("db")
+	assert.Equal(t, meta.ErrKeyNotExists, err)
+	_, err = metaRW.NewDb("db")
+	assert.Nil(t, err)
+	assert.Nil(t, txn3.Commit(ctx))
+}
+
+func TestManager_OnCommit(t *testing.T) {
+	p := "./__test_tmp__/on_commit"
+	m, store := openTestManager(t, p)
+	defer func() {
+		m.Close()
+		store.Close()
+		os.RemoveAll(p)
+	}()
+	ctx := context.TODO()
+
+	var commits []uint64
+	onCommit := func(commitTs uint64) {
+		commits = append(commits, commitTs)
+	}
+	txn1, err := m.NewTxn(true)
+	assert.Nil(t, err)
+	txn2, err := m.NewTxn(true)
+	assert.Nil(t, err)
+	for _, txn := range []*Txn{txn1, txn2} {
+		_, err = txn.Session().GetTxn().Get([]byte("counter"))
+		assert.NotNil(t, err)
+		assert.Nil(t, txn.Session().GetTxn().Set([]byte("counter"), []byte("1")))
+		txn.OnCommit(onCommit)
+	}
+	assert.Nil(t, txn1.Commit(ctx))
+	assert.Equal(t, []uint64{1}, commits)
+
+	// the functions of a failed or discarded transaction are not called
+	assert.Equal(t, ErrConflict, txn2.Commit(ctx))
+	txn3, err := m.NewTxn(true)
+	assert.Nil(t, err)
+	txn3.OnCommit(onCommit)
+	txn3.Discard(ctx)
+	assert.Equal(t, []uint64{1}, commits)
+
+	txn4, err := m.NewTxn(true)
+	assert.Nil(t, err)
+	txn4.OnCommit(onCommit)
+	txn4.OnCommit(onCommit)
+	assert.Nil(t, txn4.Commit(ctx))
+	assert.Equal(t, []uint64{1, m.ReadTs(), m.ReadTs()}, commits)
+}
+
+func TestManager_Discard(t *testing.T) {
+	p := "./__test_tmp__/discard"
+	m, store := openTestManager(t, p)
+	defer func() {
+		m.Close()
+		store.Close()
+		os.RemoveAll(p)
+	}()
+	ctx := context.TODO()
+
+	txn1, err := m.NewTxn(true)
+	assert.Nil(t, err)
+	_, err = txn1.Session().GetMetaReaderWriter().NewDb("db")
+	assert.Nil(t, err)
+	txn1.Discard(ctx)
+	assert.Equal(t, ErrTxnDone, txn1.Commit(ctx))
+
+	txn2, err := m.NewTxn(true)
+	assert.Nil(t, err)
+	_, err = txn2.Session().GetMetaReaderWriter().NewDb("db")
+	assert.Nil(t, err)
+	assert.Nil(t, txn2.Commit(ctx))
+}
+
+func TestManager_PushDiscardTs(t *testing.T) {
+	p := "./__test_tmp__/discard_ts"
+	m, store := openTestManager(t, p)
+	defer func() {
+		m.Close()
+		store.Close()
+		os.RemoveAll(p)
+	}()
+	ctx := context.TODO()
+
+	commit := func() {
+		txn, err := m.NewTxn(true)
+		assert.Nil(t, err)
+		assert.Nil(t, txn.Session().GetTxn().Set([]byte("key"), []byte("value")))
+		assert.Nil(t, txn.Commit(ctx))
+	}
+	commit()
+	commit()
+
+	// the long-running reader holds the discard timestamp
+	reader, err := m.NewTxn(false)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), reader.ReadTs())
+	commit()
+	commit()
+	assert.Eventually(t, func() bool {
+		m.PushDiscardTs()
+		return m.DiscardTs() == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, uint64(1), store.discardTs)
+
+	reader.Discard(ctx)
+	commit()
+	assert.Eventually(t, func() bool {
+		m.PushDiscardTs()
+		return m.DiscardTs() == 4
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, uint64(4), store.discardTs)
+	assert.Equal(t, uint64(4), m.metaIndex.Stats().DiscardTs)
+	assert.Equal(t, uint64(4), m.infoIndex.Stats().DiscardTs)
+}
+
+func TestManager_Close(t *testing.T) {
+	p := "./__test_tmp__/close"
+	m, store := openTestManager(t, p)
+	defer func() {
+		store.Close()
+		os.RemoveAll(p)
+	}()
+
+	txn, err := m.NewTxn(true)
+	assert.Nil(t, err)
+	assert.Nil(t, m.Close())
+	assert.Equal(t, ErrManagerClosed, m.Close())
+	_, err = m.NewTxn(false)
+	assert.Equal(t, ErrManagerClosed, err)
+	assert.Equal(t, ErrManagerClosed, txn.Commit(context.TODO()))
+}
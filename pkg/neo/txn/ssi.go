@@ -0,0 +1,147 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txn
+
+import (
+	"bytes"
+	"sync"
+)
+
+// rwSet is the read set and the write set of a serializable transaction.
+type rwSet struct {
+	mu       sync.Mutex
+	reads    map[string]struct{}
+	prefixes [][]byte
+	writes   map[string]struct{}
+}
+
+func newRWSet() *rwSet {
+	return &rwSet{
+		reads:  make(map[string]struct{}),
+		writes: make(map[string]struct{}),
+	}
+}
+
+func (s *rwSet) AddRead(key []byte) {
+	s.mu.Lock()
+	s.reads[string(key)] = struct{}{}
+	s.mu.Unlock()
+}
+
+func (s *rwSet) AddPrefixRead(prefix []byte) {
+	s.mu.Lock()
+	s.prefixes = append(s.prefixes, append([]byte(nil), prefix...))
+	s.mu.Unlock()
+}
+
+func (s *rwSet) AddWrite(key []byte) {
+	s.mu.Lock()
+	s.writes[string(key)] = struct{}{}
+	s.mu.Unlock()
+}
+
+// readsAnyOf reports whether s has read any key written by other.
+func (s *rwSet) readsAnyOf(other *rwSet) bool {
+	for key := range other.writes {
+		if _, ok := s.reads[key]; ok {
+			return true
+		}
+		for _, prefix := range s.prefixes {
+			if bytes.HasPrefix([]byte(key), prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// committedTxn is a committed serializable transaction, it's kept until
+// no active transaction is concurrent with it.
+type committedTxn struct {
+	commitTs uint64
+	set      *rwSet
+	// in and out mark the rw-antidependencies from and to the concurrent transactions.
+	in, out bool
+}
+
+// conflicts are the rw-antidependencies between a committing transaction
+// and the committed transactions concurrent with it.
+type conflicts struct {
+	// inFrom -rw-> t
+	inFrom []*committedTxn
+	// t -rw-> outTo
+	outTo []*committedTxn
+}
+
+// checkSerializable detects the rw-antidependencies between t and the
+// committed transactions concurrent with it. It fails if t would become
+// the pivot of a dangerous structure T1 -rw-> T2 -rw-> T3, or would turn a
+// committed transaction into one. The caller must hold commitMu.
+func (m *Manager) checkSerializable(t *Txn) (*conflicts, error) {
+	cs := &conflicts{}
+	for _, c := range m.committed {
+		if c.commitTs <= t.readTs {
+			// t has seen c
+			continue
+		}
+		if t.set.readsAnyOf(c.set) {
+			// t has read the versions before c
+			if c.out {
+				return nil, ErrSerializationFailure
+			}
+			cs.outTo = append(cs.outTo, c)
+		}
+		if c.set.readsAnyOf(t.set) {
+			// c has read the versions before t
+			if c.in {
+				return nil, ErrSerializationFailure
+			}
+			cs.inFrom = append(cs.inFrom, c)
+		}
+	}
+	if len(cs.inFrom) > 0 && len(cs.outTo) > 0 {
+		return nil, ErrSerializationFailure
+	}
+	return cs, nil
+}
+
+// addCommitted keeps the committed transaction and its conflicts for the
+// subsequent checks, and forgets the transactions that no active
+// transaction is concurrent with. The caller must hold commitMu.
+func (m *Manager) addCommitted(t *Txn, commitTs uint64, cs *conflicts) {
+	for _, c := range cs.outTo {
+		c.in = true
+	}
+	for _, c := range cs.inFrom {
+		c.out = true
+	}
+
+	oldest := m.readMark.DoneUntil()
+	committed := m.committed[:0]
+	for _, c := range m.committed {
+		if c.commitTs > oldest {
+			committed = append(committed, c)
+		}
+	}
+	for i := len(committed); i < len(m.committed); i++ {
+		m.committed[i] = nil
+	}
+	m.committed = append(committed, &committedTxn{
+		commitTs: commitTs,
+		set:      t.set,
+		in:       len(cs.inFrom) > 0,
+		out:      len(cs.outTo) > 0,
+	})
+}
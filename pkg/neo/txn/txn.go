@@ -0,0 +1,122 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txn
+
+import (
+	"context"
+
+	"github.com/casbin-mesh/neo/pkg/db"
+	"github.com/casbin-mesh/neo/pkg/neo/index"
+	"github.com/casbin-mesh/neo/pkg/neo/session"
+)
+
+// Txn is a transaction spans the Badger txn and the meta/schema txns.
+type Txn struct {
+	m      *Manager
+	readTs uint64
+	update bool
+	sc     session.Context
+	done   bool
+	// set is the read/write set of a serializable transaction.
+	set *rwSet
+	// onCommit are called with the commit timestamp once the transaction is committed.
+	onCommit []func(commitTs uint64)
+}
+
+// Session returns the session of the transaction, it must not be committed or rolled back directly.
+func (t *Txn) Session() session.Context {
+	return t.sc
+}
+
+// ReadTs returns the read timestamp of the transaction.
+func (t *Txn) ReadTs() uint64 {
+	return t.readTs
+}
+
+// OnCommit registers fn to be called with the commit timestamp once the update transaction is committed.
+// The functions of the transactions are called in the order of their commits, before the next commit
+// and before Commit returns, so they can keep a state derived from the committed data in order.
+func (t *Txn) OnCommit(fn func(commitTs uint64)) {
+	t.onCommit = append(t.onCommit, fn)
+}
+
+// Commit commits the transaction at a new commit timestamp, and returns
+// after the changes are visible to the subsequent transactions.
+// A read-only transaction is discarded.
+func (t *Txn) Commit(ctx context.Context) error {
+	if t.done {
+		return ErrTxnDone
+	}
+	t.done = true
+	defer t.m.doneRead(t.readTs)
+	if !t.update {
+		t.sc.RollbackTxn(ctx)
+		return nil
+	}
+
+	t.m.commitMu.Lock()
+	var (
+		cs  *conflicts
+		err error
+	)
+	if t.set != nil {
+		if cs, err = t.m.checkSerializable(t); err != nil {
+			t.m.commitMu.Unlock()
+			t.sc.RollbackTxn(ctx)
+			return err
+		}
+	}
+	commitTs, err := t.m.newCommitTs()
+	if err != nil {
+		t.m.commitMu.Unlock()
+		t.sc.RollbackTxn(ctx)
+		return err
+	}
+	if err = t.sc.CommitTxn(ctx, commitTs); err == nil && t.set != nil {
+		t.m.addCommitted(t, commitTs, cs)
+	}
+	if err == nil {
+		for _, fn := range t.onCommit {
+			fn(commitTs)
+		}
+	}
+	t.m.commitMu.Unlock()
+	if err != nil {
+		if IsConflict(err) {
+			return ErrConflict
+		}
+		return err
+	}
+	return t.m.txnMark.WaitForMark(ctx, commitTs)
+}
+
+// Discard rolls back the transaction, it's a no-op if the transaction is done.
+func (t *Txn) Discard(ctx context.Context) {
+	if t.done {
+		return
+	}
+	t.done = true
+	t.sc.RollbackTxn(ctx)
+	t.m.doneRead(t.readTs)
+}
+
+// IsConflict reports whether err is caused by a concurrent transaction, the transaction can be retried.
+func IsConflict(err error) bool {
+	switch err {
+	case ErrConflict, ErrSerializationFailure, db.ErrConflict, index.ErrWriteConflicts, index.ErrFailedToAcquireWLock, index.ErrAnotherTxnHeldWLock:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,355 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/casbin-mesh/neo/pkg/expression/ast"
+	"github.com/casbin-mesh/neo/pkg/expression/exprutil"
+	"github.com/casbin-mesh/neo/pkg/expression/iterator"
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+)
+
+var (
+	ErrInvalidModel      = errors.New("invalid model")
+	ErrUnsupportedEffect = errors.New("unsupported policy effect")
+)
+
+var (
+	// effectPolicies are the effects the enforce executor folds, e.g., subjectPriority(p.eft) || deny isn't one of them yet.
+	effectPolicies = map[string]model.EffectPolicyType{
+		"some(where(p.eft==allow))":                            model.AllowOverride,
+		"!some(where(p.eft==deny))":                            model.DenyOverride,
+		"some(where(p.eft==allow))&&!some(where(p.eft==deny))": model.AllowAndDeny,
+		"priority(p.eft)||deny":                                model.Priority,
+	}
+	effectAccessor = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*\.eft\b`)
+	blanks         = regexp.MustCompile(`\s+`)
+)
+
+// CompileModelFromFile compiles the model.conf located at path into a database named name.
+func CompileModelFromFile(name string, path string) (*model.DBInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c, err := NewParse(bufio.NewReader(f))
+	if err != nil {
+		return nil, err
+	}
+	return CompileModel(name, c)
+}
+
+// CompileModelFromString compiles the model text into a database named name.
+func CompileModelFromString(name string, text string) (*model.DBInfo, error) {
+	c, err := NewParse(bufio.NewReader(strings.NewReader(text)))
+	if err != nil {
+		return nil, err
+	}
+	return CompileModel(name, c)
+}
+
+// CompileModel compiles a casbin model into a database named name:
+// every policy_definition and role_definition section becomes a table,
+// every matcher becomes a MatcherInfo bound to its request and policy table.
+func CompileModel(name string, c Reader) (*model.DBInfo, error) {
+	requests, policies, roles := c.RequestDef(), c.PolicyDef(), c.RoleDef()
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("%w: missing request_definition", ErrInvalidModel)
+	}
+	if len(policies) == 0 {
+		return nil, fmt.Errorf("%w: missing policy_definition", ErrInvalidModel)
+	}
+	if len(c.Matchers()) == 0 {
+		return nil, fmt.Errorf("%w: missing matchers", ErrInvalidModel)
+	}
+
+	info := &model.DBInfo{Name: newCIStr(name)}
+	for _, key := range sortedKeys(policies) {
+		table, err := compileTable(key, policies[key], false)
+		if err != nil {
+			return nil, err
+		}
+		info.TableInfo = append(info.TableInfo, table)
+	}
+	for _, key := range sortedKeys(roles) {
+		table, err := compileTable(key, roles[key], true)
+		if err != nil {
+			return nil, err
+		}
+		// role lookups always start from the member
+		addIndex(table, 0)
+		info.TableInfo = append(info.TableInfo, table)
+	}
+
+	for _, key := range sortedKeys(c.Matchers()) {
+		matcher, err := compileMatcher(key, c)
+		if err != nil {
+			return nil, err
+		}
+		table, err := info.TableByLName(matcher.Policy.L)
+		if err != nil {
+			return nil, err
+		}
+		for _, col := range append(equalityColumns(matcher), rangeColumns(matcher)...) {
+			if offset := table.Field(col); offset >= 0 {
+				addIndex(table, offset)
+			}
+		}
+		info.MatcherInfo = append(info.MatcherInfo, matcher)
+	}
+	// a policy or role can't be added twice
+	for _, table := range info.TableInfo {
+		addPrimaryIndex(table)
+	}
+	return info, nil
+}
+
+func compileTable(key, definition string, role bool) (*model.TableInfo, error) {
+	tokens := splitTokens(definition)
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: empty definition of %s", ErrInvalidModel, key)
+	}
+	table := &model.TableInfo{Name: newCIStr(key)}
+	for i, token := range tokens {
+		// role definitions are written as "_, _", named by their positions instead
+		if role {
+			token = fmt.Sprintf("v%d", i)
+		}
+		if table.Field(strings.ToLower(token)) >= 0 {
+			return nil, fmt.Errorf("%w: duplicated token %s in %s", ErrInvalidModel, token, key)
+		}
+		column := &model.ColumnInfo{ColName: newCIStr(token), Offset: i, Tp: bsontype.String}
+		switch column.ColName.L {
+		case model.EffectColumnName:
+			column.DefaultValueBit = []byte(model.AllowEffect)
+		case model.PriorityColumnName:
+			// the priorities are compared as numbers, e.g. by p.priority < 10
+			column.Tp = bsontype.Int64
+		}
+		table.Columns = append(table.Columns, column)
+	}
+	return table, nil
+}
+
+func compileMatcher(key string, c Reader) (*model.MatcherInfo, error) {
+	raw := c.Matchers()[key]
+	predicate, err := exprutil.ParseMatcher(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: matcher %s: %v", ErrInvalidModel, key, err)
+	}
+	suffix := strings.TrimPrefix(key, "m")
+	ancestors := accessorAncestors(predicate)
+
+	request, err := bindSection(key, ancestors, c.RequestDef(), "r"+suffix, "r")
+	if err != nil {
+		return nil, err
+	}
+	policy, err := bindSection(key, ancestors, c.PolicyDef(), "p"+suffix, "p")
+	if err != nil {
+		return nil, err
+	}
+	effect, err := compileEffect(c.PolicyEffect(), "e"+suffix)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &model.MatcherInfo{
+		Name:         newCIStr(key),
+		Raw:          raw,
+		EffectPolicy: effect,
+		Predicate:    predicate,
+		Request:      newCIStr(request),
+		Policy:       newCIStr(policy),
+	}
+	for _, token := range splitTokens(c.RequestDef()[request]) {
+		info.RequestFields = append(info.RequestFields, newCIStr(token))
+	}
+	return info, nil
+}
+
+// bindSection returns the section key in defs that the matcher refers to,
+// or the conventional one if the matcher doesn't refer to any, e.g. r2 for m2.
+func bindSection(matcher string, ancestors map[string]struct{}, defs map[string]string, conventional, fallback string) (string, error) {
+	var bound []string
+	for key := range defs {
+		if _, ok := ancestors[key]; ok {
+			bound = append(bound, key)
+		}
+	}
+	switch len(bound) {
+	case 1:
+		return bound[0], nil
+	case 0:
+		if _, ok := defs[conventional]; ok {
+			return conventional, nil
+		}
+		if _, ok := defs[fallback]; ok {
+			return fallback, nil
+		}
+		return "", fmt.Errorf("%w: matcher %s has no definition of %s", ErrInvalidModel, matcher, conventional)
+	default:
+		sort.Strings(bound)
+		return "", fmt.Errorf("%w: matcher %s refers to multiple definitions %v", ErrInvalidModel, matcher, bound)
+	}
+}
+
+func compileEffect(effects map[string]string, key string) (model.EffectPolicyType, error) {
+	raw, ok := effects[key]
+	if !ok {
+		if raw, ok = effects["e"]; !ok {
+			return 0, fmt.Errorf("%w: missing policy_effect %s", ErrInvalidModel, key)
+		}
+	}
+	normalized := effectAccessor.ReplaceAllString(blanks.ReplaceAllString(raw, ""), "p.eft")
+	if tp, ok := effectPolicies[normalized]; ok {
+		return tp, nil
+	}
+	return 0, fmt.Errorf("%w: %s", ErrUnsupportedEffect, raw)
+}
+
+// accessorAncestors returns the identifiers that accessors of root start from, e.g. r and p of r.sub == p.sub.
+func accessorAncestors(root ast.Evaluable) map[string]struct{} {
+	set := make(map[string]struct{})
+	iter := iterator.NewDfsIterator(root)
+	for node := iter.Next(); node != nil; node = iter.Next() {
+		if accessor, ok := node.(*ast.Accessor); ok {
+			if ancestor, ok := accessor.Ancestor.(*ast.Primitive); ok && ancestor.Typ == ast.IDENTIFIER {
+				if name, ok := ancestor.Value.(string); ok {
+					set[name] = struct{}{}
+				}
+			}
+		}
+	}
+	return set
+}
+
+// equalityColumns returns the policy columns compared to the request
+// by the top-level conjunctions of the matcher, e.g. sub of r.sub == p.sub && ...
+func equalityColumns(matcher *model.MatcherInfo) (columns []string) {
+	var visit func(node ast.Evaluable)
+	visit = func(node ast.Evaluable) {
+		expr, ok := node.(*ast.BinaryOperationExpr)
+		if !ok {
+			return
+		}
+		switch expr.Op {
+		case ast.AND_OP:
+			visit(expr.L)
+			visit(expr.R)
+		case ast.EQ_OP:
+			lAncestor, lMember, lok := exprutil.MemberOf(expr.L)
+			rAncestor, rMember, rok := exprutil.MemberOf(expr.R)
+			if !lok || !rok {
+				return
+			}
+			if lAncestor == matcher.Policy.L && rAncestor == matcher.Request.L {
+				columns = append(columns, lMember)
+			} else if rAncestor == matcher.Policy.L && lAncestor == matcher.Request.L {
+				columns = append(columns, rMember)
+			}
+		}
+	}
+	visit(matcher.Predicate)
+	return
+}
+
+// rangeColumns returns the policy columns compared to the literals by the top-level
+// conjunctions of the matcher, e.g. priority of p.priority < 10 && ...
+func rangeColumns(matcher *model.MatcherInfo) (columns []string) {
+	var visit func(node ast.Evaluable)
+	visit = func(node ast.Evaluable) {
+		expr, ok := node.(*ast.BinaryOperationExpr)
+		if !ok {
+			return
+		}
+		switch expr.Op {
+		case ast.AND_OP:
+			visit(expr.L)
+			visit(expr.R)
+		case ast.LT, ast.LE, ast.GT, ast.GE:
+			column, v := expr.L, expr.R
+			if _, ok := column.(*ast.Primitive); ok {
+				column, v = v, column
+			}
+			ancestor, member, ok := exprutil.MemberOf(column)
+			if _, literal := v.(*ast.Primitive); ok && literal && ancestor == matcher.Policy.L {
+				columns = append(columns, member)
+			}
+		}
+	}
+	visit(matcher.Predicate)
+	return
+}
+
+func addIndex(table *model.TableInfo, offset int) {
+	column := table.Columns[offset]
+	for _, index := range table.Indices {
+		if index.Leftmost().Offset == offset {
+			return
+		}
+	}
+	table.Indices = append(table.Indices, &model.IndexInfo{
+		Name:    newCIStr(column.ColName.O + "_index"),
+		Table:   table.Name,
+		Columns: []*model.IndexColumn{{ColName: column.ColName, Offset: offset}},
+	})
+}
+
+// addPrimaryIndex adds the unique index over all the columns of the table.
+func addPrimaryIndex(table *model.TableInfo) {
+	columns := make([]*model.IndexColumn, 0, len(table.Columns))
+	for _, column := range table.Columns {
+		columns = append(columns, &model.IndexColumn{ColName: column.ColName, Offset: column.Offset})
+	}
+	table.Indices = append(table.Indices, &model.IndexInfo{
+		Name:    newCIStr(model.PrimaryIndexName),
+		Table:   table.Name,
+		Columns: columns,
+		Primary: true,
+		Unique:  true,
+	})
+}
+
+func splitTokens(definition string) (tokens []string) {
+	for _, token := range strings.Split(definition, ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func newCIStr(s string) model.CIStr {
+	return model.CIStr{O: s, L: strings.ToLower(s)}
+}
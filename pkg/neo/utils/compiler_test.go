@@ -0,0 +1,226 @@
+// Copyright 2022 The casbin-mesh Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/casbin-mesh/neo/pkg/neo/model"
+	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileModel_Examples(t *testing.T) {
+	files, err := filepath.Glob("../../../examples/assets/model/*.conf")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, files)
+	for _, file := range files {
+		info, err := CompileModelFromFile("example", file)
+		if !assert.Nil(t, err, file) {
+			continue
+		}
+		assert.NotEmpty(t, info.TableInfo, file)
+		assert.Len(t, info.MatcherInfo, 1, file)
+		matcher := info.MatcherInfo[0]
+		assert.NotNil(t, matcher.Predicate, file)
+		assert.Equal(t, "r", matcher.Request.L, file)
+		assert.Equal(t, "p", matcher.Policy.L, file)
+		_, err = info.TableByLName(matcher.Policy.L)
+		assert.Nil(t, err, file)
+	}
+}
+
+func TestCompileModel_Basic(t *testing.T) {
+	info, err := CompileModelFromString("Basic", basicModel)
+	assert.Nil(t, err)
+	assert.Equal(t, model.CIStr{O: "Basic", L: "basic"}, info.Name)
+	assert.Len(t, info.TableInfo, 2)
+
+	p, err := info.TableByLName("p")
+	assert.Nil(t, err)
+	assert.Len(t, p.Columns, 3)
+	for i, name := range []string{"sub", "obj", "act"} {
+		assert.Equal(t, name, p.Columns[i].ColName.L)
+		assert.Equal(t, i, p.Columns[i].Offset)
+		assert.Equal(t, bsontype.String, p.Columns[i].Tp)
+	}
+	assert.Len(t, p.Indices, 4)
+	for i, name := range []string{"sub_index", "obj_index", "act_index"} {
+		assert.Equal(t, name, p.Indices[i].Name.L)
+		assert.Equal(t, i, p.Indices[i].Leftmost().Offset)
+	}
+	// the primary index covers all the columns
+	primary := p.Indices[3]
+	assert.Equal(t, model.PrimaryIndexName, primary.Name.L)
+	assert.True(t, primary.Primary)
+	assert.True(t, primary.Unique)
+	assert.Len(t, primary.Columns, 3)
+
+	g, err := info.TableByLName("g")
+	assert.Nil(t, err)
+	assert.Len(t, g.Columns, 2)
+	assert.Equal(t, "v0", g.Columns[0].ColName.L)
+	assert.Equal(t, "v1", g.Columns[1].ColName.L)
+	assert.Len(t, g.Indices, 2)
+	assert.Equal(t, 0, g.Indices[0].Leftmost().Offset)
+	assert.Equal(t, model.PrimaryIndexName, g.Indices[1].Name.L)
+
+	matcher, err := info.MatcherByLName("m")
+	assert.Nil(t, err)
+	assert.Equal(t, model.AllowOverride, matcher.EffectPolicy)
+	assert.Equal(t, []model.CIStr{{O: "sub", L: "sub"}, {O: "obj", L: "obj"}, {O: "act", L: "act"}}, matcher.RequestFields)
+}
+
+func TestCompileModel_Effects(t *testing.T) {
+	sets := []struct {
+		effect   string
+		expected model.EffectPolicyType
+	}{
+		{"some(where (p.eft == allow))", model.AllowOverride},
+		{"!some(where (p.eft == deny))", model.DenyOverride},
+		{"some(where (p.eft == allow)) && !some(where (p.eft == deny))", model.AllowAndDeny},
+		{"priority(p.eft) || deny", model.Priority},
+	}
+	for _, set := range sets {
+		info, err := CompileModelFromString("effect", `[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act, eft
+
+[policy_effect]
+e = `+set.effect+`
+
+[matchers]
+m = r.sub == p.sub && r.obj == p.obj && r.act == p.act`)
+		assert.Nil(t, err, set.effect)
+		assert.Equal(t, set.expected, info.MatcherInfo[0].EffectPolicy, set.effect)
+		p, _ := info.TableByLName("p")
+		assert.Equal(t, []byte(model.AllowEffect), p.Columns[3].DefaultValueBit)
+	}
+
+	_, err := CompileModelFromString("effect", `[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[policy_effect]
+e = max(p.eft)
+
+[matchers]
+m = r.sub == p.sub`)
+	assert.True(t, errors.Is(err, ErrUnsupportedEffect))
+
+	// a model is rejected if its effect can't be enforced
+	_, err = CompileModelFromString("effect", `[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act, eft
+
+[policy_effect]
+e = subjectPriority(p.eft) || deny
+
+[matchers]
+m = r.sub == p.sub`)
+	assert.True(t, errors.Is(err, ErrUnsupportedEffect))
+}
+
+func TestCompileModel_MultipleSections(t *testing.T) {
+	info, err := CompileModelFromString("multiple", `[request_definition]
+r = sub, obj, act
+r2 = sub, obj
+
+[policy_definition]
+p = sub, obj, act
+p2 = sub, obj, eft
+
+[role_definition]
+g = _, _
+g2 = _, _, _
+
+[policy_effect]
+e = some(where (p.eft == allow))
+e2 = !some(where (p2.eft == deny))
+
+[matchers]
+m = g(r.sub, p.sub) && r.obj == p.obj && r.act == p.act
+m2 = r2.sub == p2.sub && keyMatch(r2.obj, p2.obj)`)
+	assert.Nil(t, err)
+	assert.Len(t, info.TableInfo, 4)
+	g2, err := info.TableByLName("g2")
+	assert.Nil(t, err)
+	assert.Len(t, g2.Columns, 3)
+
+	m, _ := info.MatcherByLName("m")
+	assert.Equal(t, "r", m.Request.L)
+	assert.Equal(t, "p", m.Policy.L)
+	p, _ := info.TableByLName("p")
+	assert.Len(t, p.Indices, 3)
+
+	m2, _ := info.MatcherByLName("m2")
+	assert.Equal(t, "r2", m2.Request.L)
+	assert.Equal(t, "p2", m2.Policy.L)
+	assert.Equal(t, model.DenyOverride, m2.EffectPolicy)
+	assert.Len(t, m2.RequestFields, 2)
+	p2, _ := info.TableByLName("p2")
+	assert.Len(t, p2.Indices, 2)
+	assert.Equal(t, "sub_index", p2.Indices[0].Name.L)
+}
+
+func TestCompileModel_Invalid(t *testing.T) {
+	sets := []string{
+		// missing request_definition
+		`[policy_definition]
+p = sub, obj, act
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = r.sub == p.sub`,
+		// syntax error
+		`[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = r.sub == == p.sub`,
+		// duplicated tokens
+		`[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, sub, act
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = r.sub == p.sub`,
+	}
+	for _, text := range sets {
+		_, err := CompileModelFromString("invalid", text)
+		assert.True(t, errors.Is(err, ErrInvalidModel), text)
+	}
+}
@@ -1199,12 +1199,14 @@ var dfas = []dfa{
 		},
 	}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1}, nil},
 
-	// \'.*\'
-	{[]bool{false, false, true, false}, []func(rune) int{ // Transitions
+	// \'(\\.|[^\\'])*\'
+	{[]bool{false, false, true, false, false, false}, []func(rune) int{ // Transitions
 		func(r rune) int {
 			switch r {
 			case 39:
 				return 1
+			case 92:
+				return -1
 			}
 			return -1
 		},
@@ -1212,24 +1214,48 @@ var dfas = []dfa{
 			switch r {
 			case 39:
 				return 2
+			case 92:
+				return 3
 			}
-			return 3
+			return 4
+		},
+		func(r rune) int {
+			switch r {
+			case 39:
+				return -1
+			case 92:
+				return -1
+			}
+			return -1
+		},
+		func(r rune) int {
+			switch r {
+			case 39:
+				return 5
+			case 92:
+				return 5
+			}
+			return 5
 		},
 		func(r rune) int {
 			switch r {
 			case 39:
 				return 2
+			case 92:
+				return 3
 			}
-			return 3
+			return 4
 		},
 		func(r rune) int {
 			switch r {
 			case 39:
 				return 2
+			case 92:
+				return 3
 			}
-			return 3
+			return 4
 		},
-	}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1}, nil},
+	}, []int{ /* Start-of-input transitions */ -1, -1, -1, -1, -1, -1}, []int{ /* End-of-input transitions */ -1, -1, -1, -1, -1, -1}, nil},
 
 	// \(
 	{[]bool{false, true}, []func(rune) int{ // Transitions
@@ -408,6 +408,22 @@ func TestComplexExprs(t *testing.T) {
 				},
 			},
 		},
+		{
+			parseStr: "(p_act == '*' || r_act == 'read')",
+			expected: &ast.BinaryOperationExpr{
+				Op: ast.OR_OP,
+				L: &ast.BinaryOperationExpr{
+					Op: ast.EQ_OP,
+					L:  &ast.Primitive{Typ: ast.IDENTIFIER, Value: "p_act"},
+					R:  &ast.Primitive{Typ: ast.STRING, Value: "*"},
+				},
+				R: &ast.BinaryOperationExpr{
+					Op: ast.EQ_OP,
+					L:  &ast.Primitive{Typ: ast.IDENTIFIER, Value: "r_act"},
+					R:  &ast.Primitive{Typ: ast.STRING, Value: "read"},
+				},
+			},
+		},
 	}
 	runTests(sets, t)
 }
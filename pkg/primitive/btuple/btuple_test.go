@@ -49,6 +49,17 @@ func TestBufferedReader_Test(t *testing.T) {
 			assert.True(t, reader.Occupied(i))
 		}
 	})
+	t.Run("Terminator", func(t *testing.T) {
+		// the small value tuple with terminators in its elements is encoded as a large one
+		e := []Elem{Elem("Alice"), {1, 0, 0, 0, 0, 0, 0, 0}, Elem(""), Elem("read")}
+		buf := newValueBTuple(t, SmallValueType, e...)
+		h := &header{}
+		h.decode(buf[:SizeOfHeader])
+		assert.Equal(t, LargeValueType, h.typ)
+		reader, err := NewReader(buf)
+		assert.Nil(t, err)
+		assert.Equal(t, e, reader.Values())
+	})
 }
 
 func BenchmarkBufferedReader_ValueAt(b *testing.B) {
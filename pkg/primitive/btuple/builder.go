@@ -15,6 +15,7 @@
 package btuple
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"github.com/casbin-mesh/neo/pkg/primitive/codec"
@@ -55,7 +56,23 @@ func (b *builder) Reset() {
 }
 
 func (b *builder) Size() int {
-	return SizeOfHeader + len(b.offset)*4 + b.len
+	if b.encodedType() == LargeValueType {
+		return SizeOfHeader + len(b.offset)*4 + b.len
+	}
+	return SizeOfHeader + b.len
+}
+
+// encodedType returns the type the tuple is encoded as. The elements of a small value tuple
+// are split by their terminators, so a tuple with a terminator in an element is a large one.
+func (b *builder) encodedType() BTupleType {
+	if b.tupleType == SmallValueType {
+		for _, elem := range b.elems {
+			if bytes.IndexByte(elem, codec.NullTerminator) >= 0 {
+				return LargeValueType
+			}
+		}
+	}
+	return b.tupleType
 }
 
 var (
@@ -64,13 +81,13 @@ var (
 
 // writeTo encode BTuple, return written size
 func (b *builder) writeTo(dst []byte) int {
-	//TODO: determine binary tuple types
+	tupleType := b.encodedType()
 	writeTo := NewHeader(
-		b.tupleType,          // tuple type
+		tupleType,            // tuple type
 		uint32(len(b.elems)), // tuple count
 	).writeTo(dst)
 
-	if b.tupleType == LargeValueType {
+	if tupleType == LargeValueType {
 		for i := 0; i < len(b.offset); i++ {
 			binary.BigEndian.PutUint32(dst[writeTo:], b.offset[i])
 			writeTo += 4
@@ -102,10 +119,9 @@ func (b *builder) Encode() []byte {
 // NOTES: element should be immutable.
 func (b *builder) Append(e ...Elem) {
 	for _, elem := range e {
-		if b.tupleType == LargeValueType {
-			b.offset = append(b.offset, uint32(b.len)) // points to start
-		}
-		b.len += len(elem) + 1 // +1 for CString terminator
+		// a small value tuple may be encoded as a large one
+		b.offset = append(b.offset, uint32(b.len)) // points to start
+		b.len += len(elem) + 1                     // +1 for CString terminator
 	}
 	b.elems = append(b.elems, e...)
 }
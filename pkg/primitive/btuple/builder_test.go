@@ -53,3 +53,28 @@ func TestBuilder_Append(t *testing.T) {
 		assert.Equal(t, LargeValueType, h.typ)
 	})
 }
+
+func TestBuilder_Compatibility(t *testing.T) {
+	// the tuples without terminators in their elements are encoded as before,
+	// so the stored tuples are decoded the same
+	e := []Elem{Elem("Alice"), Elem("data1"), Elem("read")}
+	body := []byte("Alice\x00data1\x00read\x00")
+	encoded := map[BTupleType][]byte{
+		SmallValueType: append([]byte{0x1, 0, 0, 0, 0x3, 0, 0, 0}, body...),
+		LargeValueType: append([]byte{0x2, 0, 0, 0, 0x3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x6, 0, 0, 0, 0xc}, body...),
+	}
+	for typ, expected := range encoded {
+		b := NewTupleBuilder(typ, e...)
+		assert.Equal(t, expected, b.Encode())
+		assert.Equal(t, len(expected), b.Size())
+
+		reader, err := NewReader(expected)
+		assert.Nil(t, err)
+		assert.Equal(t, e, reader.Values())
+	}
+
+	// a small value tuple with a terminator in an element used to be split by it
+	b := NewTupleBuilder(SmallValueType, Elem("Alice"), Elem{1, 0}, Elem("read"))
+	assert.Equal(t, LargeValueType, BTupleType(b.Encode()[0]))
+	assert.Equal(t, len(b.Encode()), b.Size())
+}
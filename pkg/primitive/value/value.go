@@ -1,6 +1,9 @@
 package value
 
 import (
+	"math"
+	"time"
+
 	"github.com/casbin-mesh/neo/pkg/primitive/bsontype"
 	"github.com/casbin-mesh/neo/pkg/utils/trick"
 )
@@ -59,3 +62,76 @@ func NewStringValue(s string) Value {
 func (v *Value) GetString() string {
 	return string(trick.String(v.b))
 }
+
+func NewBinaryValue(b []byte) Value {
+	return Value{
+		t: bsontype.Binary,
+		b: b,
+	}
+}
+
+func NewInt32Value(i int32) Value {
+	return Value{
+		t: bsontype.Int32,
+		i: int64(i),
+	}
+}
+
+func NewInt64Value(i int64) Value {
+	return Value{
+		t: bsontype.Int64,
+		i: i,
+	}
+}
+
+func NewDoubleValue(f float64) Value {
+	return Value{
+		t: bsontype.Double,
+		i: int64(math.Float64bits(f)),
+	}
+}
+
+func NewBooleanValue(b bool) Value {
+	v := Value{t: bsontype.Boolean}
+	if b {
+		v.i = 1
+	}
+	return v
+}
+
+// NewDateTimeValue returns a DateTime value, which has the precision of milliseconds as BSON's.
+func NewDateTimeValue(t time.Time) Value {
+	return Value{
+		t: bsontype.DateTime,
+		i: t.UnixMilli(),
+	}
+}
+
+func NewNullValue() Value {
+	return Value{t: bsontype.Null}
+}
+
+func (v *Value) GetInt32() int32 {
+	return int32(v.i)
+}
+
+func (v *Value) GetInt64() int64 {
+	return v.i
+}
+
+func (v *Value) GetDouble() float64 {
+	return math.Float64frombits(uint64(v.i))
+}
+
+func (v *Value) GetBoolean() bool {
+	return v.i != 0
+}
+
+// GetDateTime returns the milliseconds since the Unix epoch.
+func (v *Value) GetDateTime() int64 {
+	return v.i
+}
+
+func (v *Value) GetTime() time.Time {
+	return time.UnixMilli(v.i)
+}
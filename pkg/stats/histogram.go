@@ -0,0 +1,95 @@
+package stats
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Histogram is an equi-depth histogram used to estimate range queries.
+// The values are memory-comparable, so the order of their bytes is the order of the values.
+type Histogram struct {
+	// bounds are the upper bounds of the buckets in ascending order.
+	bounds [][]byte
+	// counts are the cumulative counts of the values up to the bounds.
+	counts []uint64
+	// repeats are the counts of the bounds.
+	repeats []uint64
+}
+
+// NewHistogram builds a histogram of at most buckets buckets from the distinct values and their counts.
+func NewHistogram(values [][]byte, counts []uint64, buckets int) *Histogram {
+	h := &Histogram{}
+	order := make([]int, len(values))
+	total := uint64(0)
+	for i := range order {
+		order[i] = i
+		total += counts[i]
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return bytes.Compare(values[order[i]], values[order[j]]) < 0
+	})
+	if buckets < 1 {
+		buckets = 1
+	}
+	depth := (total + uint64(buckets) - 1) / uint64(buckets)
+
+	cum, last := uint64(0), uint64(0)
+	for i, j := range order {
+		cum += counts[j]
+		if cum-last >= depth || i == len(order)-1 {
+			h.bounds = append(h.bounds, values[j])
+			h.counts = append(h.counts, cum)
+			h.repeats = append(h.repeats, counts[j])
+			last = cum
+		}
+	}
+	return h
+}
+
+// Total returns the number of the values.
+func (h *Histogram) Total() uint64 {
+	if len(h.counts) == 0 {
+		return 0
+	}
+	return h.counts[len(h.counts)-1]
+}
+
+// lessCount returns the estimated number of the values less than value, or equal to it if inclusive.
+// The values inside a bucket are assumed to spread evenly on both sides of value.
+func (h *Histogram) lessCount(value []byte, inclusive bool) float64 {
+	i := sort.Search(len(h.bounds), func(i int) bool {
+		return bytes.Compare(h.bounds[i], value) >= 0
+	})
+	if i == len(h.bounds) {
+		return float64(h.Total())
+	}
+	prev := uint64(0)
+	if i > 0 {
+		prev = h.counts[i-1]
+	}
+	below := h.counts[i] - h.repeats[i]
+	if bytes.Equal(h.bounds[i], value) {
+		if inclusive {
+			return float64(h.counts[i])
+		}
+		return float64(below)
+	}
+	return float64(prev) + float64(below-prev)/2
+}
+
+// RangeCount returns the estimated number of the values in the range from start to end,
+// a nil bound leaves the range open on the side.
+func (h *Histogram) RangeCount(start []byte, startInclusive bool, end []byte, endInclusive bool) float64 {
+	hi := float64(h.Total())
+	if end != nil {
+		hi = h.lessCount(end, endInclusive)
+	}
+	lo := 0.0
+	if start != nil {
+		lo = h.lessCount(start, !startInclusive)
+	}
+	if hi < lo {
+		return 0
+	}
+	return hi - lo
+}
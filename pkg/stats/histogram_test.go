@@ -0,0 +1,54 @@
+package stats
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHistogram(t *testing.T) {
+	// 0 to 99 of 10 rows each, in reverse order
+	var (
+		values [][]byte
+		counts []uint64
+	)
+	for i := int64(99); i >= 0; i-- {
+		values = append(values, encodeInt64(i))
+		counts = append(counts, 10)
+	}
+	h := NewHistogram(values, counts, 10)
+	assert.Equal(t, uint64(1000), h.Total())
+
+	// the bounds of the buckets are exact
+	assert.Equal(t, 100.0, h.RangeCount(nil, false, encodeInt64(9), true))
+	assert.Equal(t, 90.0, h.RangeCount(nil, false, encodeInt64(9), false))
+	assert.Equal(t, 900.0, h.RangeCount(encodeInt64(9), false, nil, false))
+	assert.Equal(t, 910.0, h.RangeCount(encodeInt64(9), true, nil, false))
+	assert.Equal(t, 1000.0, h.RangeCount(nil, false, nil, false))
+
+	// the others are within a bucket
+	sets := []struct {
+		start, end                   int64
+		startInclusive, endInclusive bool
+		expected                     float64
+	}{
+		{0, 50, true, false, 500},
+		{25, 75, false, true, 500},
+		{42, 42, true, true, 10},
+		{0, 200, true, true, 1000},
+	}
+	for _, set := range sets {
+		assert.InDelta(t, set.expected, h.RangeCount(encodeInt64(set.start), set.startInclusive, encodeInt64(set.end), set.endInclusive), 100, set)
+	}
+	// the empty ranges
+	assert.Equal(t, 0.0, h.RangeCount(encodeInt64(50), false, encodeInt64(10), false))
+	assert.Equal(t, 0.0, h.RangeCount(encodeInt64(100), true, nil, false))
+
+	// a frequent value is a bound of its own
+	h = NewHistogram([][]byte{encodeInt64(1), encodeInt64(2), encodeInt64(3)}, []uint64{1, 100, 1}, 4)
+	assert.Equal(t, 100.0, h.RangeCount(encodeInt64(2), true, encodeInt64(2), true))
+	assert.Equal(t, 1.0, h.RangeCount(nil, false, encodeInt64(2), false))
+
+	h = NewHistogram(nil, nil, 4)
+	assert.Equal(t, uint64(0), h.Total())
+	assert.Equal(t, 0.0, h.RangeCount(nil, false, encodeInt64(2), true))
+}